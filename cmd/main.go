@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"wechat-crawler/internal/api"
 	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
 	"wechat-crawler/internal/scheduler"
 	"wechat-crawler/internal/service"
+	"wechat-crawler/pkg/blobstore"
 	"wechat-crawler/pkg/database"
 	"wechat-crawler/pkg/logger"
 
@@ -36,44 +39,107 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// migrate 子命令：执行数据库迁移后退出
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			fmt.Printf("数据库迁移失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger.Info("========== 微信公众号爬虫系统启动 ==========")
 
-	// 初始化MongoDB
-	if err := database.Init(database.Config{
-		URI:      viper.GetString("mongodb.uri"),
-		Database: viper.GetString("mongodb.database"),
-		Timeout:  viper.GetInt("mongodb.timeout"),
-	}); err != nil {
-		logger.Fatal("初始化MongoDB失败", zap.Error(err))
+	// 初始化数据库，database.driver 为 sqlite 或 postgres 时所有数据保存在关系型数据库中，不连接MongoDB
+	if err := initDatabases(); err != nil {
+		logger.Fatal("初始化数据库失败", zap.Error(err))
 	}
 	defer database.Close()
-	logger.Info("MongoDB连接成功")
-
-	// 创建浏览器实例
-	browser, err := crawler.NewBrowser(
-		viper.GetString("crawler.cookie_file"),
-		viper.GetString("wechat.mp_url"),
-		viper.GetInt("crawler.timeout"),
-		viper.GetBool("crawler.debug_mode"), // 从配置文件读取debug模式
-	)
-	if err != nil {
-		logger.Fatal("创建浏览器实例失败", zap.Error(err))
+	defer database.CloseSQL()
+	if database.SQL != nil {
+		logger.Info("关系型数据库连接成功", zap.String("driver", database.SQL.Driver))
+	} else {
+		logger.Info("MongoDB连接成功")
+	}
+
+	// 执行数据库迁移，创建索引等，失败时不启动，避免在缺少唯一索引的情况下写入重复数据
+	if migrateOnStart() {
+		if err := migrate(context.Background()); err != nil {
+			logger.Fatal("数据库迁移失败，可修复后执行 migrate 子命令重试", zap.Error(err))
+		}
+	}
+
+	// 加载运行时设置，配置文件中的值作为默认值，管理后台修改的设置保存在数据库中
+	settingsService := service.NewSettingsService(model.RuntimeSettings{
+		CrawlInterval: viper.GetInt("crawler.interval"),
+		FetchCount:    viper.GetInt("crawler.fetch_count"),
+		Timeout:       viper.GetInt("crawler.timeout"),
+	})
+	if err := settingsService.Load(context.Background()); err != nil {
+		logger.Warn("加载运行时设置失败，使用配置文件中的默认值", zap.Error(err))
 	}
-	defer browser.Close()
-	logger.Info("浏览器实例创建成功")
 
-	// 执行登录
-	// if err := browser.Login(); err != nil {
-	// 	logger.Fatal("登录微信公众号平台失败", zap.Error(err))
-	// }
-	// logger.Info("微信公众号平台登录成功")
+	// 创建运营者会话池，每个会话使用独立的限流器
+	pool, err := newSessionPool(settingsService.Current().Timeout)
+	if err != nil {
+		logger.Fatal("创建运营者会话池失败", zap.Error(err))
+	}
+	defer pool.Close()
 
 	// 创建爬虫服务
 	crawlerService := service.NewCrawlerService(
-		browser,
+		pool,
 		viper.GetInt("crawler.concurrent"),
+		settingsService,
+		service.BackfillConfig{
+			PageSize:  viper.GetInt("crawler.backfill.page_size"),
+			PageDelay: time.Duration(viper.GetInt("crawler.backfill.page_delay")) * time.Second,
+			MaxCount:  viper.GetInt("crawler.backfill.max_count"),
+			UntilTime: parseDate(viper.GetString("crawler.backfill.until")),
+		},
+		service.ContentRetryConfig{
+			MaxAttempts: viper.GetInt("crawler.content_retry.max_attempts"),
+			BaseDelay:   time.Duration(viper.GetInt("crawler.content_retry.base_delay")) * time.Minute,
+			MaxDelay:    time.Duration(viper.GetInt("crawler.content_retry.max_delay")) * time.Minute,
+			BatchSize:   viper.GetInt("crawler.content_retry.batch_size"),
+		},
+		service.AdaptiveConfig{
+			Enabled:     viper.GetBool("crawler.adaptive.enabled"),
+			MinSamples:  viper.GetInt("crawler.adaptive.min_samples"),
+			HistoryDays: viper.GetInt("crawler.adaptive.history_days"),
+		},
+		service.VerifyConfig{
+			WindowDays:   viper.GetInt("crawler.verify.window_days"),
+			RecheckAfter: time.Duration(viper.GetInt("crawler.verify.recheck_after")) * time.Minute,
+			BatchSize:    viper.GetInt("crawler.verify.batch_size"),
+		},
 	)
 
+	// 图片归档和页面快照：采集时把正文图片、封面和页面快照保存到文件存储，避免原图失效或防盗链
+	mediaService, err := newMediaService()
+	if err != nil {
+		logger.Fatal("初始化文件存储失败", zap.Error(err))
+	}
+	if mediaService != nil {
+		crawlerService.UseMedia(mediaService)
+	}
+
+	// 为历史文章补充生成Markdown、纯文本和检索词，启用图片归档时同时归档历史文章的图片
+	go func() {
+		if _, err := crawlerService.ExtractMissingContent(context.Background()); err != nil {
+			logger.Warn("处理历史文章正文失败", zap.Error(err))
+		}
+	}()
+
+	// 根据已采集的文章统计各公众号的发文规律
+	go crawlerService.RefreshPostingPatterns(context.Background())
+
+	// recoverTasks 标记上次运行未结束的爬取任务，恢复未完成的历史文章回溯
+	recoverTasks := func(ctx context.Context) {
+		crawlerService.RecoverCrawlRuns(ctx)
+		go crawlerService.ResumeBackfills(context.Background())
+	}
+
 	// 创建飞书服务
 	feishuService := service.NewFeishuService()
 
@@ -81,15 +147,27 @@ func main() {
 	cronScheduler := scheduler.NewScheduler(
 		crawlerService,
 		feishuService,
-		viper.GetInt("crawler.interval"),
+		settingsService,
+		viper.GetInt("crawler.content_retry.interval"),
+		viper.GetInt("crawler.verify.interval"),
 	)
+	if viper.GetBool("ha.enabled") {
+		// 多实例共用数据库时，由获得租约的实例恢复中断的任务，避免备用实例启动时中断主实例正在执行的任务
+		cronScheduler.UseLease(scheduler.LeaseConfig{
+			Name:  viper.GetString("ha.lease_name"),
+			Owner: viper.GetString("ha.instance_id"),
+			TTL:   time.Duration(viper.GetInt("ha.lease_ttl")) * time.Second,
+		}, recoverTasks)
+	} else {
+		recoverTasks(context.Background())
+	}
 	if err := cronScheduler.Start(); err != nil {
 		logger.Fatal("启动定时任务失败", zap.Error(err))
 	}
 	defer cronScheduler.Stop()
 
 	// 设置路由并启动HTTP服务
-	router := api.SetupRouter(crawlerService)
+	router := api.SetupRouter(crawlerService, settingsService, cronScheduler, mediaService)
 
 	// 获取服务端口
 	port := viper.GetString("server.port")
@@ -141,6 +219,186 @@ func main() {
 	logger.Info("========== 微信公众号爬虫系统已退出 ==========")
 }
 
+// newMediaService 创建图片归档和页面快照服务，都未启用时返回nil
+func newMediaService() (*service.MediaService, error) {
+	snapshots, err := crawler.ParseSnapshotFormats(viper.GetStringSlice("crawler.snapshot.formats"))
+	if err != nil {
+		return nil, err
+	}
+	images := viper.GetBool("crawler.media.enabled")
+	if !images && len(snapshots) == 0 {
+		return nil, nil
+	}
+	if len(snapshots) > 0 && viper.GetString("crawler.source") == "fake" {
+		logger.Warn("模拟数据源不支持页面快照，只获取正文")
+	}
+
+	store, err := blobstore.New(blobstore.Config{
+		Type:         viper.GetString("storage.type"),
+		LocalDir:     viper.GetString("storage.local_dir"),
+		GridFSBucket: viper.GetString("storage.gridfs_bucket"),
+	}, database.Database)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewMediaService(store, service.MediaConfig{
+		Images:    images,
+		Timeout:   time.Duration(viper.GetInt("crawler.media.timeout")) * time.Second,
+		MaxSize:   viper.GetInt64("crawler.media.max_size") << 20,
+		Snapshots: snapshots,
+	}), nil
+}
+
+// sessionConfig 运营者登录会话配置（crawler.sessions）
+type sessionConfig struct {
+	Name        string `mapstructure:"name"`
+	CookieFile  string `mapstructure:"cookie_file"`
+	UserDataDir string `mapstructure:"user_data_dir"`
+}
+
+// loadSessionConfigs 读取会话列表，未配置时使用 crawler.cookie_file 作为唯一会话
+func loadSessionConfigs() ([]sessionConfig, error) {
+	var sessions []sessionConfig
+	if err := viper.UnmarshalKey("crawler.sessions", &sessions); err != nil {
+		return nil, fmt.Errorf("解析 crawler.sessions 失败: %w", err)
+	}
+	if len(sessions) == 0 {
+		return []sessionConfig{{
+			Name:       "default",
+			CookieFile: viper.GetString("crawler.cookie_file"),
+		}}, nil
+	}
+
+	names := make(map[string]bool)
+	for i := range sessions {
+		if sessions[i].Name == "" {
+			sessions[i].Name = fmt.Sprintf("session-%d", i+1)
+		}
+		if names[sessions[i].Name] {
+			return nil, fmt.Errorf("会话名称重复: %s", sessions[i].Name)
+		}
+		names[sessions[i].Name] = true
+
+		if sessions[i].CookieFile == "" {
+			sessions[i].CookieFile = fmt.Sprintf("./cookie_%s.json", sessions[i].Name)
+		}
+		// 多个Chrome实例不能共用同一个用户数据目录
+		if sessions[i].UserDataDir == "" {
+			sessions[i].UserDataDir = filepath.Join(viper.GetString("crawler.user_data_dir"), sessions[i].Name)
+		}
+	}
+	return sessions, nil
+}
+
+// newSessionPool 根据配置创建运营者会话池
+// timeout 为单次请求超时时间（秒），之后可在管理后台修改
+// crawler.source=fake 时启动内置的模拟公众号后台，用于离线演示，否则每个会话使用独立的chromedp浏览器
+func newSessionPool(timeout int) (*crawler.SessionPool, error) {
+	sessions, err := loadSessionConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := crawler.RateLimitConfig{
+		Budgets: map[crawler.Endpoint]crawler.EndpointBudget{
+			crawler.EndpointSearch:  endpointBudget("search"),
+			crawler.EndpointList:    endpointBudget("list"),
+			crawler.EndpointArticle: endpointBudget("article"),
+		},
+		Cooldown:   time.Duration(viper.GetInt("crawler.rate_limit.cooldown")) * time.Minute,
+		MaxBackoff: viper.GetFloat64("crawler.rate_limit.max_backoff"),
+	}
+
+	// 扫码登录的最长等待时间（秒），未配置或配置无效时等待120秒
+	loginScanTimeout := viper.GetInt("wechat.login_scan_timeout")
+	if loginScanTimeout <= 0 {
+		loginScanTimeout = 120
+	}
+
+	var fakeURL, fakeToken string
+	if viper.GetString("crawler.source") == "fake" {
+		addr := viper.GetString("crawler.fake_mp.addr")
+		fakeToken = viper.GetString("crawler.fake_mp.token")
+
+		fakeServer := crawler.NewFakeMPServer(fakeToken)
+		fakeServer.SeedDemoData()
+		if err := fakeServer.Start(addr); err != nil {
+			return nil, err
+		}
+
+		fakeURL = "http://" + addr
+		logger.Info("使用模拟公众号后台作为数据源", zap.String("address", addr))
+	}
+
+	members := make([]*crawler.PoolSession, 0, len(sessions))
+	for _, sc := range sessions {
+		limiter := crawler.NewRateLimiter(rateLimit)
+
+		if fakeURL != "" {
+			source := crawler.NewFakeSource(fakeURL, fakeToken, timeout, limiter)
+			members = append(members, crawler.NewPoolSession(sc.Name, source, limiter))
+			continue
+		}
+
+		// 创建浏览器实例
+		browser, err := crawler.NewBrowser(crawler.BrowserConfig{
+			CookieFile:       sc.CookieFile,
+			UserDataDir:      sc.UserDataDir,
+			MPURL:            viper.GetString("wechat.mp_url"),
+			Timeout:          timeout,
+			DebugMode:        viper.GetBool("crawler.debug_mode"), // 从配置文件读取debug模式
+			Headless:         viper.GetBool("crawler.headless"),
+			NoSandbox:        viper.GetBool("crawler.no_sandbox"),
+			QRCodeASCII:      viper.GetBool("crawler.qrcode_ascii"),
+			LoginScanTimeout: loginScanTimeout,
+		}, limiter)
+		if err != nil {
+			for _, m := range members {
+				m.Source.Close()
+			}
+			return nil, fmt.Errorf("创建会话 %s 的浏览器失败: %w", sc.Name, err)
+		}
+		logger.Info("浏览器实例创建成功", zap.String("session", sc.Name))
+
+		// 启动时不阻塞等待扫码，会话缺失或失效时在管理后台扫码登录
+		if !browser.IsLoggedIn() {
+			logger.Warn("未找到可用的公众号登录会话，请访问管理后台 /admin/login-wechat 扫码登录",
+				zap.String("session", sc.Name))
+		}
+
+		members = append(members, crawler.NewPoolSession(sc.Name, browser, limiter))
+	}
+
+	strategy := viper.GetString("crawler.pool_strategy")
+	logger.Info("运营者会话池创建成功",
+		zap.Int("sessions", len(members)),
+		zap.String("strategy", strategy))
+	return crawler.NewSessionPool(strategy, members...), nil
+}
+
+// endpointBudget 读取指定接口类别的频率预算（crawler.rate_limit.<name>）
+func endpointBudget(name string) crawler.EndpointBudget {
+	prefix := "crawler.rate_limit." + name
+	return crawler.EndpointBudget{
+		Interval:   time.Duration(viper.GetInt(prefix+".interval")) * time.Second,
+		Jitter:     time.Duration(viper.GetInt(prefix+".jitter")) * time.Second,
+		MaxPerHour: viper.GetInt(prefix + ".max_per_hour"),
+	}
+}
+
+// parseDate 解析 YYYY-MM-DD 格式的日期为时间戳，为空或格式错误时返回0
+func parseDate(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		logger.Warn("日期格式错误（应为YYYY-MM-DD）", zap.String("value", value))
+		return 0
+	}
+	return t.Unix()
+}
+
 // loadConfig 加载配置文件
 func loadConfig() error {
 	viper.SetConfigName("config")
@@ -155,11 +413,61 @@ func loadConfig() error {
 	viper.SetDefault("mongodb.uri", "mongodb://localhost:27017")
 	viper.SetDefault("mongodb.database", "wechat_crawler")
 	viper.SetDefault("mongodb.timeout", 10)
+	viper.SetDefault("database.driver", "mongodb")
+	viper.SetDefault("database.dsn", "./data/wechat.db")
+	viper.SetDefault("database.timeout", 10)
 	viper.SetDefault("crawler.interval", 10)
 	viper.SetDefault("crawler.concurrent", 3)
+	viper.SetDefault("crawler.fetch_count", 10)
 	viper.SetDefault("crawler.timeout", 60)
 	viper.SetDefault("crawler.cookie_file", "./cookie.json")
+	viper.SetDefault("crawler.user_data_dir", "./chrome_data")
+	viper.SetDefault("crawler.pool_strategy", crawler.PoolStrategyShard)
 	viper.SetDefault("crawler.debug_mode", false)
+	viper.SetDefault("crawler.headless", false)
+	viper.SetDefault("crawler.no_sandbox", false)
+	viper.SetDefault("crawler.qrcode_ascii", false)
+	viper.SetDefault("crawler.backfill.page_size", 5)
+	viper.SetDefault("crawler.backfill.page_delay", 5)
+	viper.SetDefault("crawler.backfill.max_count", 500)
+	viper.SetDefault("crawler.backfill.until", "")
+	viper.SetDefault("crawler.adaptive.enabled", true)
+	viper.SetDefault("crawler.adaptive.min_samples", 10)
+	viper.SetDefault("crawler.adaptive.history_days", 90)
+	viper.SetDefault("crawler.content_retry.interval", 10)
+	viper.SetDefault("crawler.content_retry.max_attempts", 5)
+	viper.SetDefault("crawler.content_retry.base_delay", 10)
+	viper.SetDefault("crawler.content_retry.max_delay", 720)
+	viper.SetDefault("crawler.content_retry.batch_size", 20)
+	viper.SetDefault("crawler.verify.interval", 60)
+	viper.SetDefault("crawler.verify.window_days", 7)
+	viper.SetDefault("crawler.verify.recheck_after", 360)
+	viper.SetDefault("crawler.verify.batch_size", 20)
+	viper.SetDefault("crawler.rate_limit.cooldown", 30)
+	viper.SetDefault("crawler.rate_limit.max_backoff", 8)
+	viper.SetDefault("crawler.rate_limit.search.interval", 5)
+	viper.SetDefault("crawler.rate_limit.search.jitter", 3)
+	viper.SetDefault("crawler.rate_limit.search.max_per_hour", 60)
+	viper.SetDefault("crawler.rate_limit.list.interval", 3)
+	viper.SetDefault("crawler.rate_limit.list.jitter", 3)
+	viper.SetDefault("crawler.rate_limit.list.max_per_hour", 300)
+	viper.SetDefault("crawler.rate_limit.article.interval", 2)
+	viper.SetDefault("crawler.rate_limit.article.jitter", 2)
+	viper.SetDefault("crawler.rate_limit.article.max_per_hour", 600)
+	viper.SetDefault("crawler.media.enabled", false)
+	viper.SetDefault("crawler.media.timeout", 30)
+	viper.SetDefault("crawler.media.max_size", 10)
+	viper.SetDefault("crawler.snapshot.formats", []string{})
+	viper.SetDefault("storage.type", "local")
+	viper.SetDefault("storage.local_dir", "./data/media")
+	viper.SetDefault("storage.gridfs_bucket", "media")
+	viper.SetDefault("ha.enabled", false)
+	viper.SetDefault("ha.lease_name", "scheduler")
+	viper.SetDefault("ha.instance_id", "")
+	viper.SetDefault("ha.lease_ttl", 30)
+	viper.SetDefault("crawler.source", "browser")
+	viper.SetDefault("crawler.fake_mp.addr", "127.0.0.1:8090")
+	viper.SetDefault("crawler.fake_mp.token", "fake-token")
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.output", "./logs/app.log")
 	viper.SetDefault("wechat.mp_url", "https://mp.weixin.qq.com")
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/logger"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// initDatabase 按配置连接MongoDB
+func initDatabase() error {
+	return database.Init(database.Config{
+		URI:      viper.GetString("mongodb.uri"),
+		Database: viper.GetString("mongodb.database"),
+		Timeout:  viper.GetInt("mongodb.timeout"),
+	})
+}
+
+// sqlDriver 配置的关系型数据库驱动，database.driver 为 mongodb 时返回空字符串
+func sqlDriver() string {
+	driver := viper.GetString("database.driver")
+	if driver == "mongodb" {
+		return ""
+	}
+	return driver
+}
+
+// migrateOnStart 启动时是否执行数据库迁移，读取 database.migrate_on_start，都未配置时默认执行
+// 使用 MongoDB 且未配置时兼容旧的 mongodb.migrate_on_start；使用 SQLite/PostgreSQL 时不读取 mongodb 下的配置
+func migrateOnStart() bool {
+	if viper.IsSet("database.migrate_on_start") {
+		return viper.GetBool("database.migrate_on_start")
+	}
+	if sqlDriver() == "" && viper.IsSet("mongodb.migrate_on_start") {
+		return viper.GetBool("mongodb.migrate_on_start")
+	}
+	return true
+}
+
+// initDatabases 按 database.driver 连接数据库，使用 SQLite 或 PostgreSQL 时不连接MongoDB
+func initDatabases() error {
+	if sqlDriver() == "" {
+		if err := initDatabase(); err != nil {
+			return fmt.Errorf("初始化MongoDB失败: %w", err)
+		}
+		return nil
+	}
+	if err := initSQLDatabase(); err != nil {
+		return fmt.Errorf("初始化%s失败: %w", sqlDriver(), err)
+	}
+	return nil
+}
+
+// initSQLDatabase 按配置连接关系型数据库，database.driver 为 mongodb 时不连接
+func initSQLDatabase() error {
+	driver := sqlDriver()
+	if driver == "" {
+		return nil
+	}
+	return database.InitSQL(database.SQLConfig{
+		Driver:  driver,
+		DSN:     viper.GetString("database.dsn"),
+		Timeout: viper.GetInt("database.timeout"),
+	})
+}
+
+// migrate 对已连接的数据库执行尚未执行的迁移
+func migrate(ctx context.Context) error {
+	if database.Database != nil {
+		done, err := database.Migrate(ctx, database.Database, repository.Migrations())
+		for _, m := range done {
+			logger.Info("数据库迁移完成", zap.Int("version", m.Version), zap.String("description", m.Description))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if database.SQL == nil {
+		return nil
+	}
+
+	sqlDone, err := database.MigrateSQL(ctx, database.SQL, repository.SQLMigrations())
+	for _, m := range sqlDone {
+		logger.Info("数据库迁移完成", zap.String("driver", database.SQL.Driver),
+			zap.Int("version", m.Version), zap.String("description", m.Description))
+	}
+	return err
+}
+
+// runMigrateCommand 执行 migrate 子命令：
+//
+//	migrate         执行尚未执行的迁移
+//	migrate status  查看各迁移的执行状态
+func runMigrateCommand(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "status") {
+		return fmt.Errorf("未知参数 %v，用法: migrate [status]", args)
+	}
+
+	if err := initDatabases(); err != nil {
+		return err
+	}
+	defer database.Close()
+	defer database.CloseSQL()
+
+	ctx := context.Background()
+	if len(args) == 0 {
+		if err := migrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	if database.SQL == nil {
+		statuses, err := database.MigrationStatuses(ctx, database.Database, repository.Migrations())
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "未执行"
+			if s.Applied {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-19s  %s\n", s.Version, applied, s.Description)
+		}
+		return nil
+	}
+
+	sqlStatuses, err := database.SQLMigrationStatuses(ctx, database.SQL, repository.SQLMigrations())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s:\n", database.SQL.Driver)
+	for _, s := range sqlStatuses {
+		applied := "未执行"
+		if s.Applied {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%4d  %-19s  %s\n", s.Version, applied, s.Description)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// 验证启动迁移开关优先读取 database.migrate_on_start，兼容旧的 mongodb.migrate_on_start
+func TestMigrateOnStart(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		want     bool
+	}{
+		{"默认执行", nil, true},
+		{"通用配置", map[string]interface{}{"database.migrate_on_start": false}, false},
+		{"旧配置", map[string]interface{}{"mongodb.migrate_on_start": false}, false},
+		{"通用配置优先", map[string]interface{}{"database.migrate_on_start": true, "mongodb.migrate_on_start": false}, true},
+		{"SQL不读取旧配置", map[string]interface{}{"database.driver": "sqlite", "mongodb.migrate_on_start": false}, true},
+		{"SQL通用配置", map[string]interface{}{"database.driver": "postgres", "database.migrate_on_start": false}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			for key, value := range tc.settings {
+				viper.Set(key, value)
+			}
+			if got := migrateOnStart(); got != tc.want {
+				t.Errorf("migrateOnStart() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"html/template"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -11,8 +15,10 @@ import (
 	"strings"
 	"time"
 
+	"wechat-crawler/internal/crawler"
 	"wechat-crawler/internal/middleware"
 	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/scheduler"
 	"wechat-crawler/internal/service"
 	"wechat-crawler/pkg/captcha"
 	"wechat-crawler/pkg/logger"
@@ -29,14 +35,18 @@ import (
 type AdminHandler struct {
 	crawlerService *service.CrawlerService
 	feishuService  *service.FeishuService
+	settings       *service.SettingsService
+	scheduler      *scheduler.Scheduler
 	sessionStore   *session.Store
 }
 
 // NewAdminHandler 创建管理后台处理器
-func NewAdminHandler(crawlerService *service.CrawlerService, feishuService *service.FeishuService, sessionStore *session.Store) *AdminHandler {
+func NewAdminHandler(crawlerService *service.CrawlerService, feishuService *service.FeishuService, settingsService *service.SettingsService, cronScheduler *scheduler.Scheduler, sessionStore *session.Store) *AdminHandler {
 	return &AdminHandler{
 		crawlerService: crawlerService,
 		feishuService:  feishuService,
+		settings:       settingsService,
+		scheduler:      cronScheduler,
 		sessionStore:   sessionStore,
 	}
 }
@@ -160,8 +170,10 @@ func (h *AdminHandler) ShowDashboard(c *gin.Context) {
 		"Stats": gin.H{
 			"AccountCount":  len(accounts),
 			"ArticleCount":  total,
-			"CrawlInterval": viper.GetInt("crawler.interval"),
+			"CrawlInterval": h.settings.Current().CrawlInterval,
 		},
+		"RateLimit":      h.crawlerService.RateLimitStatus(),
+		"Session":        h.crawlerService.SessionStatus(),
 		"LatestArticles": latestArticles,
 	})
 }
@@ -181,6 +193,7 @@ func (h *AdminHandler) ShowAccounts(c *gin.Context) {
 		"IsLogin":  true,
 		"Username": middleware.GetUsername(c),
 		"Accounts": accounts,
+		"Adaptive": h.crawlerService.AdaptiveEnabled(),
 	})
 }
 
@@ -194,6 +207,11 @@ func (h *AdminHandler) ShowArticles(c *gin.Context) {
 	pageSize := int64(20)
 	accountID := c.Query("account_id")
 	keyword := c.Query("keyword")
+	missingContent := c.Query("missing_content") == "1"
+	status := c.Query("status")
+	tag := c.Query("tag")
+	readState := c.Query("read")
+	starred := c.Query("starred") == "1"
 	startTimeStr := c.Query("start_time")
 	endTimeStr := c.Query("end_time")
 
@@ -211,14 +229,30 @@ func (h *AdminHandler) ShowArticles(c *gin.Context) {
 		}
 	}
 
-	// 获取文章列表（使用新的过滤方法）
-	articles, total, err := h.crawlerService.GetArticleListWithFilter(ctx, accountID, keyword, startTime, endTime, page, pageSize)
-	if err != nil {
+	// 获取文章列表，有关键词时全文搜索并高亮
+	hits, total, err := h.crawlerService.SearchArticles(ctx, model.ArticleFilter{
+		AccountID:      accountID,
+		Keyword:        keyword,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		MissingContent: missingContent,
+		Status:         status,
+		Tag:            tag,
+		User:           middleware.GetUsername(c),
+		Read:           readState,
+		Starred:        starred,
+	}, page, pageSize)
+	searchError := searchErrorOf(err)
+	if err != nil && searchError == "" {
 		logger.Error("获取文章列表失败", zap.Error(err))
 	}
+	articles, titles, snippets := splitSearchHits(hits, keyword != "")
 
 	// 获取公众号列表（用于筛选）
 	accounts, _ := h.crawlerService.GetAccountList(ctx)
+	missingCount, _ := h.crawlerService.CountMissingContent(ctx)
+	removedCount, _ := h.crawlerService.CountRemovedArticles(ctx)
+	tags, _ := h.crawlerService.ListArticleTags(ctx)
 
 	// 计算总页数
 	totalPages := int((total + pageSize - 1) / pageSize)
@@ -241,11 +275,46 @@ func (h *AdminHandler) ShowArticles(c *gin.Context) {
 		"Pages":           pages,
 		"FilterAccountID": accountID,
 		"SearchKeyword":   keyword,
+		"SearchError":     searchError,
+		"Titles":          titles,
+		"Snippets":        snippets,
 		"StartTime":       startTimeStr,
 		"EndTime":         endTimeStr,
+		"MissingContent":  missingContent,
+		"MissingCount":    missingCount,
+		"FilterStatus":    status,
+		"RemovedCount":    removedCount,
+		"FilterTag":       tag,
+		"FilterRead":      readState,
+		"FilterStarred":   starred,
+		"Tags":            tags,
 	})
 }
 
+// searchErrorOf 搜索条件不合法时返回提示信息
+func searchErrorOf(err error) string {
+	if errors.Is(err, service.ErrInvalidSearch) {
+		return err.Error()
+	}
+	return ""
+}
+
+// splitSearchHits 拆分搜索结果为文章列表和按文章ID索引的高亮标题、正文片段，没有关键词时不生成高亮
+func splitSearchHits(hits []*service.SearchHit, highlight bool) ([]*model.Article, map[string]template.HTML, map[string]template.HTML) {
+	articles := make([]*model.Article, 0, len(hits))
+	titles := make(map[string]template.HTML)
+	snippets := make(map[string]template.HTML)
+	for _, hit := range hits {
+		articles = append(articles, hit.Article)
+		if highlight {
+			// 高亮内容由 pkg/search 转义生成
+			titles[hit.Article.ID.Hex()] = template.HTML(hit.Title)
+			snippets[hit.Article.ID.Hex()] = template.HTML(hit.Snippet)
+		}
+	}
+	return articles, titles, snippets
+}
+
 // ShowAccountArticles 显示某个公众号的文章列表
 func (h *AdminHandler) ShowAccountArticles(c *gin.Context) {
 	ctx := context.Background()
@@ -267,6 +336,11 @@ func (h *AdminHandler) ShowAccountArticles(c *gin.Context) {
 	page := int64(pageInt)
 	pageSize := int64(20)
 	keyword := c.Query("keyword")
+	missingContent := c.Query("missing_content") == "1"
+	status := c.Query("status")
+	tag := c.Query("tag")
+	readState := c.Query("read")
+	starred := c.Query("starred") == "1"
 	startTimeStr := c.Query("start_time")
 	endTimeStr := c.Query("end_time")
 
@@ -283,14 +357,30 @@ func (h *AdminHandler) ShowAccountArticles(c *gin.Context) {
 		}
 	}
 
-	// 获取文章列表
-	articles, total, err := h.crawlerService.GetArticleListWithFilter(ctx, accountID, keyword, startTime, endTime, page, pageSize)
-	if err != nil {
+	// 获取文章列表，有关键词时全文搜索并高亮
+	hits, total, err := h.crawlerService.SearchArticles(ctx, model.ArticleFilter{
+		AccountID:      accountID,
+		Keyword:        keyword,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		MissingContent: missingContent,
+		Status:         status,
+		Tag:            tag,
+		User:           middleware.GetUsername(c),
+		Read:           readState,
+		Starred:        starred,
+	}, page, pageSize)
+	searchError := searchErrorOf(err)
+	if err != nil && searchError == "" {
 		logger.Error("获取文章列表失败", zap.Error(err))
 	}
+	articles, titles, snippets := splitSearchHits(hits, keyword != "")
 
 	// 获取所有公众号列表（用于筛选下拉框）
 	accounts, _ := h.crawlerService.GetAccountList(ctx)
+	missingCount, _ := h.crawlerService.CountMissingContent(ctx)
+	removedCount, _ := h.crawlerService.CountRemovedArticles(ctx)
+	tags, _ := h.crawlerService.ListArticleTags(ctx)
 
 	// 计算总页数
 	totalPages := int((total + pageSize - 1) / pageSize)
@@ -314,20 +404,93 @@ func (h *AdminHandler) ShowAccountArticles(c *gin.Context) {
 		"Pages":           pages,
 		"FilterAccountID": accountID,
 		"SearchKeyword":   keyword,
+		"SearchError":     searchError,
+		"Titles":          titles,
+		"Snippets":        snippets,
 		"StartTime":       startTimeStr,
 		"EndTime":         endTimeStr,
+		"MissingContent":  missingContent,
+		"MissingCount":    missingCount,
+		"FilterStatus":    status,
+		"RemovedCount":    removedCount,
+		"FilterTag":       tag,
+		"FilterRead":      readState,
+		"FilterStarred":   starred,
+		"Tags":            tags,
 	})
 }
 
+// ShowArticleRevisions 显示文章正文的修改记录，并排对比两个版本
+func (h *AdminHandler) ShowArticleRevisions(c *gin.Context) {
+	ctx := c.Request.Context()
+	articleID := c.Param("id")
+	from, _ := strconv.Atoi(c.Query("from"))
+	to, _ := strconv.Atoi(c.Query("to"))
+
+	data := gin.H{
+		"Title":    "修改记录",
+		"Active":   "articles",
+		"IsLogin":  true,
+		"Username": middleware.GetUsername(c),
+	}
+
+	article, revisions, err := h.crawlerService.ListRevisions(ctx, articleID)
+	if err != nil {
+		logger.Error("获取文章版本失败", zap.String("id", articleID), zap.Error(err))
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "revisions", data)
+		return
+	}
+	data["Title"] = article.Title + " - 修改记录"
+	data["Article"] = article
+	data["Revisions"] = revisions
+
+	if len(revisions) > 1 {
+		diff, err := h.crawlerService.CompareRevisions(ctx, articleID, from, to)
+		if err != nil {
+			logger.Error("对比文章版本失败", zap.String("id", articleID), zap.Error(err))
+			data["Error"] = err.Error()
+		} else {
+			data["Diff"] = diff
+		}
+	}
+
+	c.HTML(http.StatusOK, "revisions", data)
+}
+
 // ShowTasks 显示任务管理页面
 func (h *AdminHandler) ShowTasks(c *gin.Context) {
+	nextAccount, err := h.crawlerService.NextScheduledAccount(c.Request.Context())
+	if err != nil {
+		logger.Warn("获取下一个到期的公众号失败", zap.Error(err))
+	}
 
 	c.HTML(http.StatusOK, "tasks", gin.H{
 		"Title":         "任务管理",
 		"Active":        "tasks",
 		"IsLogin":       true,
 		"Username":      middleware.GetUsername(c),
-		"CrawlInterval": viper.GetInt("crawler.interval"),
+		"CrawlInterval": h.settings.Current().CrawlInterval,
+		"NextAccount":   nextAccount,
+		"Jobs":          h.scheduler.Jobs(),
+		"Leader":        h.scheduler.LeaderStatus(c.Request.Context()),
+	})
+}
+
+// GetTaskSchedule 获取各定时任务的下次执行时间、主实例状态和下一个到期的公众号
+func (h *AdminHandler) GetTaskSchedule(c *gin.Context) {
+	nextAccount, err := h.crawlerService.NextScheduledAccount(c.Request.Context())
+	if err != nil {
+		logger.Warn("获取下一个到期的公众号失败", zap.Error(err))
+	}
+
+	settings := h.settings.Current()
+	response.Success(c, gin.H{
+		"jobs":         h.scheduler.Jobs(),
+		"leader":       h.scheduler.LeaderStatus(c.Request.Context()),
+		"next_account": nextAccount,
+		"interval":     settings.CrawlInterval,
+		"fetch_count":  settings.FetchCount,
 	})
 }
 
@@ -348,35 +511,158 @@ func (h *AdminHandler) ShowSettings(c *gin.Context) {
 		}
 	}
 
+	settings := h.settings.Current()
+	_, updatedBy, updatedAt := h.settings.Overrides()
 	c.HTML(http.StatusOK, "settings", gin.H{
-		"Title":         "系统设置",
-		"Active":        "settings",
-		"IsLogin":       true,
-		"Username":      middleware.GetUsername(c),
-		"CrawlInterval": viper.GetInt("crawler.interval"),
-		"FetchCount":    10, // 默认值
-		"Timeout":       viper.GetInt("crawler.timeout"),
-		"DatabaseName":  viper.GetString("mongodb.database"),
-		"ServerMode":    viper.GetString("server.mode"),
-		"ServerPort":    viper.GetString("server.port"),
-		"GoVersion":     runtime.Version(),
-		"FeishuConfig":  feishuConfig,
+		"Title":             "系统设置",
+		"Active":            "settings",
+		"IsLogin":           true,
+		"Username":          middleware.GetUsername(c),
+		"CrawlInterval":     settings.CrawlInterval,
+		"FetchCount":        settings.FetchCount,
+		"Timeout":           settings.Timeout,
+		"Defaults":          h.settings.Defaults(),
+		"SettingsUpdatedBy": updatedBy,
+		"SettingsUpdatedAt": updatedAt,
+		"DatabaseName":      viper.GetString("mongodb.database"),
+		"ServerMode":        viper.GetString("server.mode"),
+		"ServerPort":        viper.GetString("server.port"),
+		"GoVersion":         runtime.Version(),
+		"FeishuConfig":      feishuConfig,
 	})
 }
 
 // TriggerCrawl 手动触发爬取任务
 func (h *AdminHandler) TriggerCrawl(c *gin.Context) {
-	ctx := context.Background()
+	operator := middleware.GetUsername(c)
 
-	logger.Info("手动触发爬取任务", zap.String("operator", middleware.GetUsername(c)))
+	logger.Info("手动触发爬取任务", zap.String("operator", operator))
 
-	go func() {
-		if err := h.crawlerService.FetchAllAccounts(ctx); err != nil {
-			logger.Error("手动爬取任务失败", zap.Error(err))
-		}
-	}()
+	if rejectIfUnavailable(c, h.crawlerService) {
+		return
+	}
+
+	progress, ok := startCrawl(c, h.crawlerService, h.scheduler, model.CrawlTriggerManual, operator)
+	if !ok {
+		return
+	}
+
+	response.Success(c, gin.H{"msg": "爬取任务已启动", "progress": progress})
+}
+
+// GetCrawlProgress 获取当前爬取任务的执行进度
+func (h *AdminHandler) GetCrawlProgress(c *gin.Context) {
+	response.Success(c, h.crawlerService.CrawlProgress())
+}
+
+// CancelCrawl 取消正在执行的爬取任务
+func (h *AdminHandler) CancelCrawl(c *gin.Context) {
+	if err := h.crawlerService.CancelCrawl(middleware.GetUsername(c)); err != nil {
+		response.Error(c, 400, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"msg": "已请求取消，当前公众号处理完成后停止"})
+}
+
+// TriggerBackfill 手动触发公众号历史文章回溯
+func (h *AdminHandler) TriggerBackfill(c *gin.Context) {
+	logger.Info("手动触发历史文章回溯",
+		zap.String("operator", middleware.GetUsername(c)),
+		zap.String("id", c.Param("id")))
+
+	state, ok := startBackfill(c, h.crawlerService)
+	if !ok {
+		return
+	}
+
+	response.Success(c, gin.H{"msg": "回溯任务已启动", "backfill": state})
+}
+
+// ShowWeChatLogin 显示公众号后台扫码登录页面
+func (h *AdminHandler) ShowWeChatLogin(c *gin.Context) {
+	c.HTML(http.StatusOK, "login_wechat", gin.H{
+		"Title":    "公众号登录",
+		"Active":   "login-wechat",
+		"IsLogin":  true,
+		"Username": middleware.GetUsername(c),
+		"Session":  h.crawlerService.SessionStatus(),
+		"Sessions": h.crawlerService.SessionHealth(),
+	})
+}
+
+// StartWeChatLogin 发起公众号后台扫码登录（session参数指定会话，为空时使用第一个会话）
+func (h *AdminHandler) StartWeChatLogin(c *gin.Context) {
+	session := c.Query("session")
+	logger.Info("管理后台发起扫码登录",
+		zap.String("operator", middleware.GetUsername(c)),
+		zap.String("session", session))
+
+	if err := h.crawlerService.StartWeChatLogin(session); err != nil {
+		response.Error(c, 400, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"msg": "已打开登录页，请使用微信扫码"})
+}
+
+// GetWeChatLoginStatus 获取扫码登录进度、登录页截图和当前登录态
+func (h *AdminHandler) GetWeChatLoginStatus(c *gin.Context) {
+	session := c.Query("session")
+	state, err := h.crawlerService.WeChatLoginState(session)
+	if err != nil {
+		response.Error(c, 400, err.Error())
+		return
+	}
+
+	// 二维码图片通过单独的接口获取，带上更新时间避免浏览器缓存
+	qrcodeURL := ""
+	if len(state.QRCode) > 0 {
+		qrcodeURL = fmt.Sprintf("/admin/api/wechat-login/qrcode?session=%s&t=%d",
+			url.QueryEscape(session), state.UpdatedAt.UnixMilli())
+	}
+
+	response.Success(c, gin.H{
+		"session":    h.crawlerService.SessionStatus(),
+		"sessions":   h.crawlerService.SessionHealth(),
+		"login":      state,
+		"qrcode_url": qrcodeURL,
+	})
+}
+
+// GetWeChatLoginQRCode 获取登录二维码图片（PNG）
+func (h *AdminHandler) GetWeChatLoginQRCode(c *gin.Context) {
+	state, err := h.crawlerService.WeChatLoginState(c.Query("session"))
+	if err != nil {
+		response.Error(c, 400, err.Error())
+		return
+	}
+
+	if state.Status != crawler.QRLoginWaiting || len(state.QRCode) == 0 {
+		response.NotFound(c, "暂无登录二维码，请先发起扫码登录")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", state.QRCode)
+}
 
-	response.Success(c, gin.H{"msg": "爬取任务已启动"})
+// GetRateLimitStatus 获取限流器状态（是否处于频率限制冷却期、各接口用量）
+func (h *AdminHandler) GetRateLimitStatus(c *gin.Context) {
+	response.Success(c, h.crawlerService.RateLimitStatus())
+}
+
+// GetSessions 获取运营者会话池中各会话的健康状况和配额
+func (h *AdminHandler) GetSessions(c *gin.Context) {
+	response.Success(c, h.crawlerService.SessionHealth())
+}
+
+// ResumeRateLimit 手动解除频率限制暂停
+func (h *AdminHandler) ResumeRateLimit(c *gin.Context) {
+	logger.Info("手动解除频率限制暂停", zap.String("operator", middleware.GetUsername(c)))
+
+	h.crawlerService.ResumeRateLimit()
+	response.Success(c, gin.H{"msg": "已解除暂停"})
 }
 
 // UpdateSettings 更新系统设置
@@ -392,39 +678,70 @@ func (h *AdminHandler) UpdateSettings(c *gin.Context) {
 		return
 	}
 
-	// 验证参数
-	if req.CrawlInterval < 5 || req.CrawlInterval > 1440 {
-		response.Error(c, http.StatusBadRequest, "爬取间隔必须在5-1440分钟之间")
+	settings := model.RuntimeSettings{
+		CrawlInterval: req.CrawlInterval,
+		FetchCount:    req.FetchCount,
+		Timeout:       req.Timeout,
+	}
+	if err := service.ValidateSettings(settings); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if req.FetchCount < 5 || req.FetchCount > 50 {
-		response.Error(c, http.StatusBadRequest, "获取文章数量必须在5-50之间")
+	// 保存到数据库，爬虫服务和调度器收到变更通知后立即生效
+	if _, err := h.settings.Update(c.Request.Context(), middleware.GetUsername(c), settings); err != nil {
+		logger.Error("更新系统设置失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if req.Timeout < 30 || req.Timeout > 300 {
-		response.Error(c, http.StatusBadRequest, "超时时间必须在30-300秒之间")
+	response.Success(c, gin.H{"msg": "设置已保存并生效", "jobs": h.scheduler.Jobs()})
+}
+
+// ResetSettings 恢复为配置文件中的默认设置
+func (h *AdminHandler) ResetSettings(c *gin.Context) {
+	settings, err := h.settings.Reset(c.Request.Context(), middleware.GetUsername(c))
+	if err != nil {
+		logger.Error("恢复默认设置失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// 更新配置（这里只是演示，实际应该写入配置文件）
-	viper.Set("crawler.interval", req.CrawlInterval)
-	viper.Set("crawler.timeout", req.Timeout)
+	response.Success(c, gin.H{"msg": "已恢复默认设置", "settings": settings})
+}
 
-	logger.Info("更新系统设置",
-		zap.String("operator", middleware.GetUsername(c)),
-		zap.Int("interval", req.CrawlInterval),
-		zap.Int("timeout", req.Timeout))
+// GetSettings 获取当前生效的设置、配置文件默认值和覆盖项
+func (h *AdminHandler) GetSettings(c *gin.Context) {
+	overrides, updatedBy, updatedAt := h.settings.Overrides()
+	response.Success(c, gin.H{
+		"current":    h.settings.Current(),
+		"defaults":   h.settings.Defaults(),
+		"overrides":  overrides,
+		"updated_by": updatedBy,
+		"updated_at": updatedAt,
+	})
+}
+
+// GetSettingsHistory 获取设置修改记录
+func (h *AdminHandler) GetSettingsHistory(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	pageSize, _ := strconv.ParseInt(c.DefaultQuery("page_size", "20"), 10, 64)
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
 
-	// 写入配置文件
-	if err := viper.WriteConfig(); err != nil {
-		logger.Error("写入配置文件失败", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "保存配置失败，但内存配置已更新")
+	changes, total, err := h.settings.History(c.Request.Context(), page, pageSize)
+	if err != nil {
+		logger.Error("获取设置修改记录失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "获取设置修改记录失败")
 		return
 	}
 
-	response.Success(c, gin.H{"msg": "设置已保存，重启服务后生效"})
+	response.SuccessWithPage(c, changes, total, page, pageSize)
 }
 
 // GetLogs 获取日志内容
@@ -547,11 +864,12 @@ func (h *AdminHandler) SaveFeishuConfig(c *gin.Context) {
 	ctx := context.Background()
 
 	var req struct {
-		WebhookURL   string `json:"webhook_url"`
-		Enabled      bool   `json:"enabled"`
-		NotifyTime   string `json:"notify_time"`
-		NotifyTitle  string `json:"notify_title"`
-		NotifyPeriod string `json:"notify_period"`
+		WebhookURL    string `json:"webhook_url"`
+		Enabled       bool   `json:"enabled"`
+		NotifyTime    string `json:"notify_time"`
+		NotifyTitle   string `json:"notify_title"`
+		NotifyPeriod  string `json:"notify_period"`
+		NotifyRemoved bool   `json:"notify_removed"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -564,14 +882,19 @@ func (h *AdminHandler) SaveFeishuConfig(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "启用通知时必须填写Webhook地址")
 		return
 	}
+	if _, err := scheduler.FeishuCronExpr(req.NotifyPeriod, req.NotifyTime); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// 构建配置对象
 	config := &model.FeishuConfig{
-		WebhookURL:   req.WebhookURL,
-		Enabled:      req.Enabled,
-		NotifyTime:   req.NotifyTime,
-		NotifyTitle:  req.NotifyTitle,
-		NotifyPeriod: req.NotifyPeriod,
+		WebhookURL:    req.WebhookURL,
+		Enabled:       req.Enabled,
+		NotifyTime:    req.NotifyTime,
+		NotifyTitle:   req.NotifyTitle,
+		NotifyPeriod:  req.NotifyPeriod,
+		NotifyRemoved: req.NotifyRemoved,
 	}
 
 	if err := h.feishuService.SaveConfig(ctx, config); err != nil {
@@ -584,6 +907,13 @@ func (h *AdminHandler) SaveFeishuConfig(c *gin.Context) {
 		zap.String("operator", middleware.GetUsername(c)),
 		zap.Bool("enabled", req.Enabled))
 
+	// 按新的周期和时间替换通知任务
+	if err := h.scheduler.ReloadFeishuTask(); err != nil {
+		logger.Error("重新加载飞书通知任务失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "配置已保存，但更新通知任务失败: "+err.Error())
+		return
+	}
+
 	response.Success(c, gin.H{"msg": "保存成功"})
 }
 
@@ -601,6 +931,137 @@ func (h *AdminHandler) TestFeishuNotification(c *gin.Context) {
 	response.Success(c, gin.H{"msg": "测试通知已发送"})
 }
 
+// GetArticles 获取文章列表，支持按当前用户的阅读状态、星标和标签筛选
+func (h *AdminHandler) GetArticles(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	pageSize, _ := strconv.ParseInt(c.DefaultQuery("page_size", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	missingContent, _ := strconv.ParseBool(c.DefaultQuery("missing_content", "false"))
+	starred, _ := strconv.ParseBool(c.DefaultQuery("starred", "false"))
+	articles, total, err := h.crawlerService.GetArticleListWithFilter(c.Request.Context(), model.ArticleFilter{
+		AccountID:      c.Query("account_id"),
+		MissingContent: missingContent,
+		Status:         c.Query("status"),
+		Tag:            c.Query("tag"),
+		User:           middleware.GetUsername(c),
+		Read:           c.Query("read"),
+		Starred:        starred,
+	}, page, pageSize)
+	if err != nil {
+		logger.Error("获取文章列表失败", zap.Error(err))
+		response.InternalServerError(c, "获取列表失败")
+		return
+	}
+
+	// 只返回当前管理员自己的已读和星标状态
+	user := middleware.GetUsername(c)
+	list := make([]adminArticle, len(articles))
+	for i, article := range articles {
+		list[i] = adminArticle{
+			Article: article,
+			Read:    article.IsReadBy(user),
+			Starred: article.IsStarredBy(user),
+		}
+	}
+	response.SuccessWithPage(c, list, total, page, pageSize)
+}
+
+// adminArticle 管理后台文章列表项，附带当前管理员的已读和星标状态
+type adminArticle struct {
+	*model.Article
+	Read    bool `json:"read"`
+	Starred bool `json:"starred"`
+}
+
+// GetArticleTags 获取所有标签及使用的文章数
+func (h *AdminHandler) GetArticleTags(c *gin.Context) {
+	tags, err := h.crawlerService.ListArticleTags(c.Request.Context())
+	if err != nil {
+		logger.Error("获取标签失败", zap.Error(err))
+		response.InternalServerError(c, "获取标签失败")
+		return
+	}
+	response.Success(c, tags)
+}
+
+// MarkArticlesRead 把选中的文章标记为当前用户已读或未读
+func (h *AdminHandler) MarkArticlesRead(c *gin.Context) {
+	var req struct {
+		IDs  []string `json:"ids"`
+		Read *bool    `json:"read"` // 为空时标记为已读
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+	read := req.Read == nil || *req.Read
+
+	count, err := h.crawlerService.MarkArticlesRead(c.Request.Context(), middleware.GetUsername(c), req.IDs, read)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"count": count})
+}
+
+// MarkAccountRead 把公众号的全部文章标记为当前用户已读
+func (h *AdminHandler) MarkAccountRead(c *gin.Context) {
+	count, err := h.crawlerService.MarkAccountRead(c.Request.Context(), middleware.GetUsername(c), c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	logger.Info("公众号文章全部标记为已读",
+		zap.String("operator", middleware.GetUsername(c)),
+		zap.String("id", c.Param("id")),
+		zap.Int64("count", count))
+	response.Success(c, gin.H{"msg": fmt.Sprintf("已将 %d 篇文章标记为已读", count), "count": count})
+}
+
+// StarArticles 设置或取消当前用户对选中文章的星标
+func (h *AdminHandler) StarArticles(c *gin.Context) {
+	var req struct {
+		IDs     []string `json:"ids"`
+		Starred bool     `json:"starred"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	if err := h.crawlerService.StarArticles(c.Request.Context(), middleware.GetUsername(c), req.IDs, req.Starred); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"msg": "已更新星标"})
+}
+
+// TagArticles 给选中的文章添加和移除标签
+func (h *AdminHandler) TagArticles(c *gin.Context) {
+	var req struct {
+		IDs    []string `json:"ids"`
+		Add    []string `json:"add"`
+		Remove []string `json:"remove"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	if err := h.crawlerService.TagArticles(c.Request.Context(), req.IDs, req.Add, req.Remove); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Success(c, gin.H{"msg": "已更新标签"})
+}
+
 // getProjectRoot 获取项目根目录（包含go.mod的目录）
 func getProjectRoot() string {
 	// 尝试从当前工作目录开始查找
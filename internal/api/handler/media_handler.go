@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"wechat-crawler/internal/service"
+	"wechat-crawler/pkg/blobstore"
+	"wechat-crawler/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MediaHandler 归档文件处理器
+type MediaHandler struct {
+	mediaService *service.MediaService
+}
+
+// NewMediaHandler 创建归档文件处理器实例
+func NewMediaHandler(mediaService *service.MediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+// Serve 输出归档的文件
+// @Summary 获取归档文件
+// @Description 输出归档到文件存储中的图片和页面快照，文件按内容摘要命名，内容不会变化，允许长期缓存
+// @Tags 归档文件
+// @Param key path string true "文件路径"
+// @Router /media/{key} [get]
+func (h *MediaHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if blobstore.ValidateKey(key) != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	reader, info, err := h.mediaService.Open(c.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, blobstore.ErrNotFound) {
+			logger.Warn("读取归档文件失败", zap.String("key", key), zap.Error(err))
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", info.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logger.Debug("输出归档文件中断", zap.String("key", key), zap.Error(err))
+	}
+}
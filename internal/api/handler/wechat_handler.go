@@ -1,9 +1,15 @@
 package handler
 
 import (
-	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/scheduler"
 	"wechat-crawler/internal/service"
 	"wechat-crawler/pkg/logger"
 	"wechat-crawler/pkg/response"
@@ -15,12 +21,14 @@ import (
 // WeChatHandler 微信公众号处理器
 type WeChatHandler struct {
 	crawlerService *service.CrawlerService
+	scheduler      *scheduler.Scheduler
 }
 
 // NewWeChatHandler 创建处理器实例
-func NewWeChatHandler(crawlerService *service.CrawlerService) *WeChatHandler {
+func NewWeChatHandler(crawlerService *service.CrawlerService, cronScheduler *scheduler.Scheduler) *WeChatHandler {
 	return &WeChatHandler{
 		crawlerService: crawlerService,
+		scheduler:      cronScheduler,
 	}
 }
 
@@ -100,6 +108,44 @@ func (h *WeChatHandler) GetAccount(c *gin.Context) {
 	response.Success(c, account)
 }
 
+// UpdateScheduleRequest 更新爬取计划请求
+type UpdateScheduleRequest struct {
+	Interval int    `json:"interval"` // 爬取间隔（分钟），0表示使用全局间隔
+	Cron     string `json:"cron"`     // cron表达式（分 时 日 月 周），优先于interval
+	Priority int    `json:"priority"` // 优先级（0-100），数值越大越先爬取
+}
+
+// UpdateSchedule 更新公众号爬取计划
+// @Summary 更新爬取计划
+// @Description 设置公众号自己的爬取间隔或cron表达式以及优先级
+// @Tags 公众号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "公众号ID"
+// @Param body body UpdateScheduleRequest true "爬取计划"
+// @Success 200 {object} response.Response
+// @Router /api/wechat/:id/schedule [put]
+func (h *WeChatHandler) UpdateSchedule(c *gin.Context) {
+	var req UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	account, err := h.crawlerService.UpdateSchedule(c.Request.Context(), c.Param("id"), model.CrawlSchedule{
+		Interval: req.Interval,
+		Cron:     strings.TrimSpace(req.Cron),
+		Priority: req.Priority,
+	})
+	if err != nil {
+		logger.Warn("更新爬取计划失败", zap.String("id", c.Param("id")), zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, account)
+}
+
 // DeleteAccount 删除公众号订阅
 // @Summary 删除公众号订阅
 // @Description 取消订阅指定的公众号
@@ -127,16 +173,22 @@ func (h *WeChatHandler) DeleteAccount(c *gin.Context) {
 
 // GetArticleList 获取文章列表
 // @Summary 获取文章列表
-// @Description 获取文章列表，支持分页和按公众号筛选
+// @Description 获取文章列表，支持分页、按公众号、在线状态和标签筛选，以及只看正文缺失的文章
 // @Tags 文章管理
 // @Produce json
 // @Param account_id query string false "公众号ID"
+// @Param missing_content query bool false "只看正文缺失的文章"
+// @Param status query string false "在线状态：live、deleted、blocked，removed 表示已删除或已屏蔽"
+// @Param tag query string false "标签"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
 // @Success 200 {object} response.Response
 // @Router /api/article/list [get]
 func (h *WeChatHandler) GetArticleList(c *gin.Context) {
 	accountID := c.Query("account_id")
+	missingContent, _ := strconv.ParseBool(c.DefaultQuery("missing_content", "false"))
+	status := c.Query("status")
+	tag := c.Query("tag")
 
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	pageSize, _ := strconv.ParseInt(c.DefaultQuery("page_size", "20"), 10, 64)
@@ -148,7 +200,19 @@ func (h *WeChatHandler) GetArticleList(c *gin.Context) {
 		pageSize = 20
 	}
 
-	articles, total, err := h.crawlerService.GetArticleList(c.Request.Context(), accountID, page, pageSize)
+	var articles []*model.Article
+	var total int64
+	var err error
+	if missingContent || status != "" || tag != "" {
+		articles, total, err = h.crawlerService.GetArticleListWithFilter(c.Request.Context(), model.ArticleFilter{
+			AccountID:      accountID,
+			MissingContent: missingContent,
+			Status:         status,
+			Tag:            tag,
+		}, page, pageSize)
+	} else {
+		articles, total, err = h.crawlerService.GetArticleList(c.Request.Context(), accountID, page, pageSize)
+	}
 	if err != nil {
 		logger.Error("获取文章列表失败", zap.Error(err))
 		response.InternalServerError(c, "获取列表失败")
@@ -158,6 +222,178 @@ func (h *WeChatHandler) GetArticleList(c *gin.Context) {
 	response.SuccessWithPage(c, articles, total, page, pageSize)
 }
 
+// SearchArticles 全文搜索文章
+// @Summary 全文搜索文章
+// @Description 在标题、作者、摘要和正文中搜索，中文按字和相邻两字切分检索词；空格分隔的关键词需同时包含，"双引号" 表示短语，-关键词 表示排除，OR 或 | 表示满足其一
+// @Tags 文章管理
+// @Produce json
+// @Param q query string true "搜索条件"
+// @Param account_id query string false "公众号ID"
+// @Param start_time query string false "发布日期起（YYYY-MM-DD）"
+// @Param end_time query string false "发布日期止（YYYY-MM-DD，包含当天）"
+// @Param status query string false "在线状态：live、deleted、blocked，removed 表示已删除或已屏蔽"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/article/search [get]
+func (h *WeChatHandler) SearchArticles(c *gin.Context) {
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		response.BadRequest(c, "请输入搜索关键词")
+		return
+	}
+
+	filter := model.ArticleFilter{
+		AccountID: c.Query("account_id"),
+		Keyword:   keyword,
+		Status:    c.Query("status"),
+	}
+	if value := c.Query("start_time"); value != "" {
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "开始日期格式错误，应为YYYY-MM-DD")
+			return
+		}
+		filter.StartTime = t.Unix()
+	}
+	if value := c.Query("end_time"); value != "" {
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "结束日期格式错误，应为YYYY-MM-DD")
+			return
+		}
+		filter.EndTime = t.Add(24*time.Hour - time.Second).Unix()
+	}
+
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	pageSize, _ := strconv.ParseInt(c.DefaultQuery("page_size", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	hits, total, err := h.crawlerService.SearchArticles(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearch) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("搜索文章失败", zap.String("q", keyword), zap.Error(err))
+		response.InternalServerError(c, "搜索失败")
+		return
+	}
+
+	response.SuccessWithPage(c, hits, total, page, pageSize)
+}
+
+// GetArticle 获取文章详情
+// @Summary 获取文章详情
+// @Description 获取文章详情，包含原始正文HTML、清洗后的HTML、Markdown、纯文本和统计信息；指定 format 时直接返回对应格式的正文
+// @Tags 文章管理
+// @Produce json
+// @Param id path string true "文章ID"
+// @Param format query string false "正文格式：markdown、text、html（清洗后的HTML）"
+// @Success 200 {object} response.Response
+// @Router /api/article/:id [get]
+func (h *WeChatHandler) GetArticle(c *gin.Context) {
+	id := c.Param("id")
+
+	article, err := h.crawlerService.GetArticle(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("获取文章失败", zap.String("id", id), zap.Error(err))
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	switch c.Query("format") {
+	case "":
+		response.Success(c, article)
+	case "markdown":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(article.Markdown))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(article.Text))
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(article.CleanHTML))
+	default:
+		response.BadRequest(c, "不支持的格式")
+	}
+}
+
+// GetArticleRevisions 获取文章正文的修改记录
+// @Summary 文章修改记录
+// @Description 获取文章正文的所有版本（不含正文），复查发现正文被修改时新增版本，未修改过的文章返回空列表
+// @Tags 文章管理
+// @Produce json
+// @Param id path string true "文章ID"
+// @Success 200 {object} response.Response
+// @Router /api/article/:id/revisions [get]
+func (h *WeChatHandler) GetArticleRevisions(c *gin.Context) {
+	id := c.Param("id")
+
+	_, revisions, err := h.crawlerService.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("获取文章版本失败", zap.String("id", id), zap.Error(err))
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, revisions)
+}
+
+// GetArticleRevision 获取文章的指定版本
+// @Summary 文章版本详情
+// @Description 获取文章指定版本的正文HTML和规范化文本
+// @Tags 文章管理
+// @Produce json
+// @Param id path string true "文章ID"
+// @Param revision path int true "版本号"
+// @Success 200 {object} response.Response
+// @Router /api/article/:id/revisions/:revision [get]
+func (h *WeChatHandler) GetArticleRevision(c *gin.Context) {
+	id := c.Param("id")
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || number < 1 {
+		response.BadRequest(c, "版本号无效")
+		return
+	}
+
+	revision, err := h.crawlerService.GetRevision(c.Request.Context(), id, number)
+	if err != nil {
+		logger.Error("获取文章版本失败", zap.String("id", id), zap.Int("revision", number), zap.Error(err))
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, revision)
+}
+
+// CompareArticleRevisions 并排对比文章的两个版本
+// @Summary 对比文章版本
+// @Description 按段落并排对比两个版本的规范化文本，默认对比最新版本和上一个版本
+// @Tags 文章管理
+// @Produce json
+// @Param id path string true "文章ID"
+// @Param from query int false "旧版本号，默认为 to 的上一个版本"
+// @Param to query int false "新版本号，默认为最新版本"
+// @Success 200 {object} response.Response
+// @Router /api/article/:id/revisions/diff [get]
+func (h *WeChatHandler) CompareArticleRevisions(c *gin.Context) {
+	id := c.Param("id")
+	from, _ := strconv.Atoi(c.Query("from"))
+	to, _ := strconv.Atoi(c.Query("to"))
+
+	diff, err := h.crawlerService.CompareRevisions(c.Request.Context(), id, from, to)
+	if err != nil {
+		logger.Error("对比文章版本失败", zap.String("id", id), zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, diff)
+}
+
 // TriggerFetch 手动触发爬取任务
 // @Summary 手动触发爬取
 // @Description 立即执行一次所有公众号的爬取任务
@@ -168,14 +404,201 @@ func (h *WeChatHandler) GetArticleList(c *gin.Context) {
 func (h *WeChatHandler) TriggerFetch(c *gin.Context) {
 	logger.Info("手动触发爬取任务")
 
-	// 使用独立的context，不依赖HTTP请求的生命周期
-	// 避免HTTP响应后context被取消导致爬取任务失败
-	go func() {
-		ctx := context.Background()
-		if err := h.crawlerService.FetchAllAccounts(ctx); err != nil {
-			logger.Error("爬取任务执行失败", zap.Error(err))
+	if rejectIfUnavailable(c, h.crawlerService) {
+		return
+	}
+
+	progress, ok := startCrawl(c, h.crawlerService, h.scheduler, model.CrawlTriggerAPI, "")
+	if !ok {
+		return
+	}
+
+	response.SuccessWithMsg(c, "爬取任务已启动", progress)
+}
+
+// GetCrawlProgress 获取当前爬取任务的执行进度
+// @Summary 爬取任务进度
+// @Description 获取当前爬取任务的进度（第N个/共M个公众号、当前公众号、已执行时长）
+// @Tags 爬取任务
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/crawler/progress [get]
+func (h *WeChatHandler) GetCrawlProgress(c *gin.Context) {
+	response.Success(c, h.crawlerService.CrawlProgress())
+}
+
+// GetCrawlRuns 获取爬取任务执行记录
+// @Summary 爬取任务执行记录
+// @Description 分页获取爬取任务执行记录（按开始时间倒序），可按触发来源筛选
+// @Tags 爬取任务
+// @Produce json
+// @Param trigger query string false "触发来源：cron/manual/api"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response
+// @Router /api/crawler/runs [get]
+func (h *WeChatHandler) GetCrawlRuns(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	pageSize, _ := strconv.ParseInt(c.DefaultQuery("page_size", "20"), 10, 64)
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	runs, total, err := h.crawlerService.GetCrawlRuns(c.Request.Context(), c.Query("trigger"), page, pageSize)
+	if err != nil {
+		logger.Error("获取爬取任务记录失败", zap.Error(err))
+		response.InternalServerError(c, "获取列表失败")
+		return
+	}
+
+	response.SuccessWithPage(c, runs, total, page, pageSize)
+}
+
+// GetCrawlRun 获取单次爬取任务的执行记录
+// @Summary 爬取任务执行详情
+// @Description 获取单次爬取任务的执行记录，包含各公众号的结果
+// @Tags 爬取任务
+// @Produce json
+// @Param id path string true "执行记录ID"
+// @Success 200 {object} response.Response
+// @Router /api/crawler/runs/:id [get]
+func (h *WeChatHandler) GetCrawlRun(c *gin.Context) {
+	id := c.Param("id")
+
+	run, err := h.crawlerService.GetCrawlRun(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("获取爬取任务记录失败", zap.String("id", id), zap.Error(err))
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, run)
+}
+
+// BackfillRequest 历史文章回溯请求
+type BackfillRequest struct {
+	Until    string `json:"until"`     // 截止日期（YYYY-MM-DD），早于该日期的文章不再回溯
+	MaxCount int    `json:"max_count"` // 最多回溯的文章数
+	Reset    bool   `json:"reset"`     // 是否从第一页重新开始
+}
+
+// toOptions 转换为服务层的回溯参数
+func (r *BackfillRequest) toOptions() (service.BackfillOptions, error) {
+	opts := service.BackfillOptions{
+		MaxCount: r.MaxCount,
+		Reset:    r.Reset,
+	}
+	if r.Until != "" {
+		t, err := time.ParseInLocation("2006-01-02", r.Until, time.Local)
+		if err != nil {
+			return opts, err
+		}
+		opts.UntilTime = t.Unix()
+	}
+	return opts, nil
+}
+
+// startCrawl 通过协调器启动爬取任务，已有任务在执行时返回409和当前进度（供API和管理后台共用）
+// 多实例部署时协调器只能保证本实例内不重复爬取，本实例不是主实例时返回409和主实例信息
+func startCrawl(c *gin.Context, crawlerService *service.CrawlerService, cronScheduler *scheduler.Scheduler, trigger, operator string) (service.CrawlProgress, bool) {
+	if leader, ok := cronScheduler.CheckLeader(c.Request.Context()); !ok {
+		logger.Warn("非主实例，拒绝手动触发爬取",
+			zap.String("trigger", trigger),
+			zap.String("instance", leader.Instance),
+			zap.String("leader", leader.Leader))
+		msg := "本实例不是主实例，请稍后重试"
+		if leader.Leader != "" {
+			msg = fmt.Sprintf("本实例不是主实例，请在主实例（%s）上触发爬取", leader.Leader)
+		}
+		response.ErrorWithData(c, 409, msg, leader)
+		return service.CrawlProgress{}, false
+	}
+
+	progress, err := crawlerService.StartCrawl(trigger, operator)
+	if errors.Is(err, service.ErrCrawlRunning) {
+		response.ErrorWithData(c, 409, "已有爬取任务正在执行，请等待完成或取消后再试", progress)
+		return progress, false
+	}
+	if err != nil {
+		response.InternalServerError(c, err.Error())
+		return progress, false
+	}
+	return progress, true
+}
+
+// rejectIfUnavailable 登录态失效或处于频率限制冷却期时拒绝触发爬取（供API和管理后台共用）
+func rejectIfUnavailable(c *gin.Context, crawlerService *service.CrawlerService) bool {
+	if session := crawlerService.SessionStatus(); session.Expired {
+		response.ErrorWithData(c, 401, "公众号登录态已失效，请在管理后台重新扫码登录", session)
+		return true
+	}
+
+	status := crawlerService.RateLimitStatus()
+	if !status.Paused {
+		return false
+	}
+
+	response.ErrorWithData(c, 429,
+		fmt.Sprintf("触发微信频率限制，爬虫暂停至 %s", status.PausedUntil.Format("2006-01-02 15:04:05")),
+		status)
+	return true
+}
+
+// startBackfill 解析请求并触发回溯（供API和管理后台共用）
+func startBackfill(c *gin.Context, crawlerService *service.CrawlerService) (*model.BackfillState, bool) {
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "ID不能为空")
+		return nil, false
+	}
+
+	var req BackfillRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "请求参数错误: "+err.Error())
+			return nil, false
 		}
-	}()
+	}
+
+	opts, err := req.toOptions()
+	if err != nil {
+		response.BadRequest(c, "截止日期格式错误，应为YYYY-MM-DD")
+		return nil, false
+	}
+	if opts.MaxCount < 0 {
+		response.BadRequest(c, "回溯数量不能为负数")
+		return nil, false
+	}
+
+	state, err := crawlerService.StartBackfill(c.Request.Context(), id, opts)
+	if err != nil {
+		logger.Error("触发历史文章回溯失败", zap.String("id", id), zap.Error(err))
+		response.InternalServerError(c, err.Error())
+		return nil, false
+	}
+
+	return state, true
+}
+
+// TriggerBackfill 触发公众号历史文章回溯
+// @Summary 回溯历史文章
+// @Description 按页回溯指定公众号的历史文章，支持截止日期、数量上限和断点续爬
+// @Tags 爬取任务
+// @Accept json
+// @Produce json
+// @Param id path string true "公众号ID"
+// @Param body body BackfillRequest false "回溯参数"
+// @Success 200 {object} response.Response
+// @Router /api/crawler/backfill/:id [post]
+func (h *WeChatHandler) TriggerBackfill(c *gin.Context) {
+	state, ok := startBackfill(c, h.crawlerService)
+	if !ok {
+		return
+	}
 
-	response.SuccessWithMsg(c, "爬取任务已启动", nil)
+	response.SuccessWithMsg(c, "回溯任务已启动", state)
 }
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/internal/scheduler"
+	"wechat-crawler/internal/service"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "handler-test")
+	if err := logger.Init(filepath.Join(dir, "test.log"), "error"); err != nil {
+		panic(err)
+	}
+	gin.SetMode(gin.TestMode)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 验证多实例部署时备用实例拒绝手动触发爬取，返回409和主实例ID
+func TestStartCrawlRejectsStandby(t *testing.T) {
+	db, err := database.OpenSQL(database.SQLConfig{
+		Driver:  database.DriverSQLite,
+		DSN:     filepath.Join(t.TempDir(), "test.db"),
+		Timeout: 5,
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if _, err := database.MigrateSQL(context.Background(), db, repository.SQLMigrations()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	prev := database.SQL
+	database.SQL = db
+	t.Cleanup(func() {
+		database.SQL = prev
+		db.Close()
+	})
+
+	// 其他实例持有租约
+	leases := repository.NewLeaseRepo()
+	if ok, err := leases.TryAcquire(context.Background(), "scheduler", "other", time.Minute); !ok || err != nil {
+		t.Fatalf("获取租约失败: %v, %v", ok, err)
+	}
+
+	settings := service.NewSettingsService(model.RuntimeSettings{CrawlInterval: 60, FetchCount: 10, Timeout: 30})
+	standby := scheduler.NewScheduler(nil, nil, settings, 0, 0)
+	standby.UseLease(scheduler.LeaseConfig{Owner: "self", TTL: time.Minute}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/crawler/trigger", nil)
+
+	// 备用实例在访问爬虫服务之前拒绝
+	if _, ok := startCrawl(c, nil, standby, model.CrawlTriggerAPI, ""); ok {
+		t.Fatal("备用实例不应启动爬取任务")
+	}
+	var resp struct {
+		Code int                    `json:"code"`
+		Msg  string                 `json:"msg"`
+		Data scheduler.LeaderStatus `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Code != 409 || resp.Data.Leader != "other" || resp.Data.Instance != "self" || resp.Data.IsLeader {
+		t.Errorf("响应: got %+v", resp)
+	}
+
+	// 主实例释放租约后本实例可以接管
+	if err := leases.Release(context.Background(), "scheduler", "other"); err != nil {
+		t.Fatalf("释放租约失败: %v", err)
+	}
+	if _, ok := standby.CheckLeader(context.Background()); !ok {
+		t.Error("租约释放后本实例应成为主实例")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"wechat-crawler/internal/api/handler"
 	"wechat-crawler/internal/middleware"
+	"wechat-crawler/internal/scheduler"
 	"wechat-crawler/internal/service"
 	"wechat-crawler/pkg/logger"
 	"wechat-crawler/pkg/session"
@@ -18,8 +19,8 @@ import (
 	"go.uber.org/zap"
 )
 
-// SetupRouter 配置路由
-func SetupRouter(crawlerService *service.CrawlerService) *gin.Engine {
+// SetupRouter 配置路由，mediaService 为nil时表示未启用图片归档和页面快照
+func SetupRouter(crawlerService *service.CrawlerService, settingsService *service.SettingsService, cronScheduler *scheduler.Scheduler, mediaService *service.MediaService) *gin.Engine {
 	// 设置Gin模式
 	mode := viper.GetString("server.mode")
 	if mode == "release" {
@@ -54,9 +55,9 @@ func SetupRouter(crawlerService *service.CrawlerService) *gin.Engine {
 	middleware.InitSession(sessionStore)
 
 	// 创建处理器
-	wechatHandler := handler.NewWeChatHandler(crawlerService)
+	wechatHandler := handler.NewWeChatHandler(crawlerService, cronScheduler)
 	feishuService := service.NewFeishuService()
-	adminHandler := handler.NewAdminHandler(crawlerService, feishuService, sessionStore)
+	adminHandler := handler.NewAdminHandler(crawlerService, feishuService, settingsService, cronScheduler, sessionStore)
 
 	// 管理后台路由
 	admin := r.Group("/admin")
@@ -70,25 +71,46 @@ func SetupRouter(crawlerService *service.CrawlerService) *gin.Engine {
 		adminAuth := admin.Group("")
 		adminAuth.Use(middleware.AuthRequired())
 		{
-			adminAuth.GET("", adminHandler.ShowDashboard)                    // 仪表板
-			adminAuth.GET("/", adminHandler.ShowDashboard)                   // 仪表板
-			adminAuth.GET("/accounts", adminHandler.ShowAccounts)            // 公众号管理
-			adminAuth.GET("/accounts/:id", adminHandler.ShowAccountArticles) // 公众号文章列表
-			adminAuth.GET("/articles", adminHandler.ShowArticles)            // 文章管理
-			adminAuth.GET("/tasks", adminHandler.ShowTasks)                  // 任务管理
-			adminAuth.GET("/settings", adminHandler.ShowSettings)            // 系统设置
-			adminAuth.GET("/logout", adminHandler.Logout)                    // 退出登录
+			adminAuth.GET("", adminHandler.ShowDashboard)                               // 仪表板
+			adminAuth.GET("/", adminHandler.ShowDashboard)                              // 仪表板
+			adminAuth.GET("/accounts", adminHandler.ShowAccounts)                       // 公众号管理
+			adminAuth.GET("/accounts/:id", adminHandler.ShowAccountArticles)            // 公众号文章列表
+			adminAuth.GET("/articles", adminHandler.ShowArticles)                       // 文章管理
+			adminAuth.GET("/articles/:id/revisions", adminHandler.ShowArticleRevisions) // 文章修改记录
+			adminAuth.GET("/tasks", adminHandler.ShowTasks)                             // 任务管理
+			adminAuth.GET("/settings", adminHandler.ShowSettings)                       // 系统设置
+			adminAuth.GET("/login-wechat", adminHandler.ShowWeChatLogin)                // 公众号扫码登录
+			adminAuth.GET("/logout", adminHandler.Logout)                               // 退出登录
 		}
 
 		// 管理后台API（需要认证）
 		adminAPI := admin.Group("/api")
 		adminAPI.Use(middleware.AuthRequired())
 		{
-			adminAPI.POST("/tasks/trigger", adminHandler.TriggerCrawl)             // 手动触发爬取
-			adminAPI.POST("/settings/update", adminHandler.UpdateSettings)         // 更新设置
-			adminAPI.GET("/logs", adminHandler.GetLogs)                            // 获取日志
-			adminAPI.POST("/feishu/save", adminHandler.SaveFeishuConfig)           // 保存飞书配置
-			adminAPI.POST("/feishu/test", adminHandler.TestFeishuNotification)     // 测试飞书通知
+			adminAPI.POST("/tasks/trigger", adminHandler.TriggerCrawl)              // 手动触发爬取
+			adminAPI.GET("/tasks/progress", adminHandler.GetCrawlProgress)          // 爬取任务进度
+			adminAPI.POST("/tasks/cancel", adminHandler.CancelCrawl)                // 取消爬取任务
+			adminAPI.GET("/tasks/schedule", adminHandler.GetTaskSchedule)           // 定时任务下次执行时间
+			adminAPI.POST("/accounts/:id/backfill", adminHandler.TriggerBackfill)   // 回溯历史文章
+			adminAPI.POST("/accounts/:id/read", adminHandler.MarkAccountRead)       // 公众号文章全部标记为已读
+			adminAPI.GET("/articles", adminHandler.GetArticles)                     // 文章列表（含阅读状态、星标、标签筛选）
+			adminAPI.GET("/articles/tags", adminHandler.GetArticleTags)             // 所有标签
+			adminAPI.POST("/articles/read", adminHandler.MarkArticlesRead)          // 标记已读或未读
+			adminAPI.POST("/articles/star", adminHandler.StarArticles)              // 设置或取消星标
+			adminAPI.POST("/articles/tags", adminHandler.TagArticles)               // 添加或移除标签
+			adminAPI.GET("/ratelimit", adminHandler.GetRateLimitStatus)             // 限流状态
+			adminAPI.POST("/ratelimit/resume", adminHandler.ResumeRateLimit)        // 解除频率限制暂停
+			adminAPI.GET("/sessions", adminHandler.GetSessions)                     // 运营者会话健康状况
+			adminAPI.POST("/wechat-login/start", adminHandler.StartWeChatLogin)     // 发起扫码登录
+			adminAPI.GET("/wechat-login/status", adminHandler.GetWeChatLoginStatus) // 扫码登录进度
+			adminAPI.GET("/wechat-login/qrcode", adminHandler.GetWeChatLoginQRCode) // 登录二维码图片
+			adminAPI.GET("/settings", adminHandler.GetSettings)                     // 当前设置和默认值
+			adminAPI.POST("/settings/update", adminHandler.UpdateSettings)          // 更新设置
+			adminAPI.POST("/settings/reset", adminHandler.ResetSettings)            // 恢复默认设置
+			adminAPI.GET("/settings/history", adminHandler.GetSettingsHistory)      // 设置修改记录
+			adminAPI.GET("/logs", adminHandler.GetLogs)                             // 获取日志
+			adminAPI.POST("/feishu/save", adminHandler.SaveFeishuConfig)            // 保存飞书配置
+			adminAPI.POST("/feishu/test", adminHandler.TestFeishuNotification)      // 测试飞书通知
 		}
 	}
 
@@ -98,25 +120,40 @@ func SetupRouter(crawlerService *service.CrawlerService) *gin.Engine {
 		// 公众号管理
 		wechat := api.Group("/wechat")
 		{
-			wechat.POST("/add", wechatHandler.AddAccount)      // 添加公众号
-			wechat.GET("/list", wechatHandler.GetAccountList)  // 获取公众号列表
-			wechat.GET("/:id", wechatHandler.GetAccount)       // 获取公众号详情
-			wechat.DELETE("/:id", wechatHandler.DeleteAccount) // 删除公众号
+			wechat.POST("/add", wechatHandler.AddAccount)             // 添加公众号
+			wechat.GET("/list", wechatHandler.GetAccountList)         // 获取公众号列表
+			wechat.GET("/:id", wechatHandler.GetAccount)              // 获取公众号详情
+			wechat.DELETE("/:id", wechatHandler.DeleteAccount)        // 删除公众号
+			wechat.PUT("/:id/schedule", wechatHandler.UpdateSchedule) // 更新爬取计划
 		}
 
 		// 文章管理
 		article := api.Group("/article")
 		{
-			article.GET("/list", wechatHandler.GetArticleList) // 获取文章列表
+			article.GET("/list", wechatHandler.GetArticleList)                        // 获取文章列表
+			article.GET("/search", wechatHandler.SearchArticles)                      // 全文搜索文章
+			article.GET("/:id", wechatHandler.GetArticle)                             // 获取文章详情
+			article.GET("/:id/revisions", wechatHandler.GetArticleRevisions)          // 文章修改记录
+			article.GET("/:id/revisions/diff", wechatHandler.CompareArticleRevisions) // 对比文章版本
+			article.GET("/:id/revisions/:revision", wechatHandler.GetArticleRevision) // 文章版本详情
 		}
 
 		// 爬虫任务
 		crawler := api.Group("/crawler")
 		{
-			crawler.POST("/trigger", wechatHandler.TriggerFetch) // 手动触发爬取
+			crawler.POST("/trigger", wechatHandler.TriggerFetch)         // 手动触发爬取
+			crawler.POST("/backfill/:id", wechatHandler.TriggerBackfill) // 回溯历史文章
+			crawler.GET("/progress", wechatHandler.GetCrawlProgress)     // 当前爬取任务进度
+			crawler.GET("/runs", wechatHandler.GetCrawlRuns)             // 爬取任务执行记录
+			crawler.GET("/runs/:id", wechatHandler.GetCrawlRun)          // 爬取任务执行详情
 		}
 	}
 
+	// 归档的图片和页面快照
+	if mediaService != nil {
+		r.GET(strings.TrimSuffix(service.MediaRoute, "/")+"/*key", handler.NewMediaHandler(mediaService).Serve)
+	}
+
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
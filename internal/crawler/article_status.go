@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrArticleDeleted 文章已被发布者删除或链接不存在
+	ErrArticleDeleted = errors.New("文章已删除或不存在")
+	// ErrArticleBlocked 文章因违规、投诉等原因被平台屏蔽，无法查看
+	ErrArticleBlocked = errors.New("文章已被屏蔽，无法查看")
+)
+
+// blockedPageKeywords 文章被平台屏蔽时页面标题中的关键词
+var blockedPageKeywords = []string{"违规", "投诉", "侵权", "暂时无法查看", "已被屏蔽"}
+
+// deletedPageKeywords 文章被删除或不存在时页面标题中的关键词
+var deletedPageKeywords = []string{"404", "页面不存在", "已删除", "已被删除", "被发布者删除", "此内容发送失败无法查看", "微信公众平台"}
+
+// checkArticlePage 根据文章页标题判断文章是否已删除或被屏蔽，正常页面返回nil
+// 返回的错误可用 errors.Is 与 ErrArticleDeleted、ErrArticleBlocked 比较
+func checkArticlePage(title string) error {
+	for _, keyword := range blockedPageKeywords {
+		if strings.Contains(title, keyword) {
+			return fmt.Errorf("%w: %s", ErrArticleBlocked, title)
+		}
+	}
+	for _, keyword := range deletedPageKeywords {
+		if strings.Contains(title, keyword) {
+			return fmt.Errorf("%w: %s", ErrArticleDeleted, title)
+		}
+	}
+	return nil
+}
@@ -1,12 +1,13 @@
 package crawler
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"wechat-crawler/internal/model"
@@ -14,36 +15,71 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"go.uber.org/zap"
 )
 
+// qrCodeSelector 公众号平台登录页二维码元素
+const qrCodeSelector = ".login__type__container__scan__qrcode"
+
+// BrowserConfig 浏览器配置
+type BrowserConfig struct {
+	CookieFile       string // 会话数据（Cookies + Token）保存路径
+	UserDataDir      string // Chrome用户数据目录，多会话时每个会话使用独立目录
+	MPURL            string // 微信公众号平台地址
+	Timeout          int    // 单次操作超时时间（秒）
+	LoginScanTimeout int    // 扫码登录的最长等待时间（秒）
+	DebugMode        bool   // debug模式，为true时浏览器不自动关闭
+	Headless         bool   // 无头模式，无显示器的服务器/容器中使用
+	NoSandbox        bool   // 禁用Chrome沙箱（容器中以root运行时需要）
+	QRCodeASCII      bool   // 扫码登录时在日志中输出字符画二维码
+}
+
 // Browser 浏览器封装
 type Browser struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
 	cookieManager *CookieManager
+	client        *MPClient    // 公众号后台JSON接口客户端，搜索和文章列表直接走HTTP
+	limiter       *RateLimiter // 当前会话的限流器
 	mpURL         string
-	timeout       time.Duration
-	token         string     // 微信公众号平台的token，用于API请求
-	debugMode     bool       // debug模式，为true时浏览器不自动关闭
-	mu            sync.Mutex // 互斥锁，保护浏览器操作避免并发导致封控
+	timeout       atomic.Int64  // 单次操作超时时间，可在运行时修改
+	token         atomic.Value  // 微信公众号平台的token（string），扫码登录在后台goroutine中写入
+	debugMode     bool          // debug模式，为true时浏览器不自动关闭
+	qrCodeASCII   bool          // 扫码登录时在日志中输出字符画二维码
+	loginTimeout  time.Duration // 扫码登录的最长等待时间
+	mu            sync.Mutex    // 互斥锁，保护浏览器操作避免并发导致封控
+
+	loginMu    sync.Mutex
+	loginState QRLoginState // 管理后台扫码登录进度
 }
 
 // NewBrowser 创建浏览器实例
-func NewBrowser(cookieFile, mpURL string, timeout int, debugMode bool) (*Browser, error) {
+func NewBrowser(cfg BrowserConfig, limiter *RateLimiter) (*Browser, error) {
 	// 创建chromedp上下文
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", false), // 首次登录需要显示浏览器窗口
+		chromedp.Flag("headless", cfg.Headless), // 非无头模式下显示浏览器窗口，便于扫码和调试
 		chromedp.Flag("disable-blink-features", "AutomationControlled"),
 		chromedp.Flag("disable-dev-shm-usage", true), // 提高稳定性
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(defaultUserAgent),
 	)
+	if cfg.Headless {
+		// 无头模式下固定窗口大小，保证登录页二维码完整渲染
+		opts = append(opts, chromedp.WindowSize(1280, 900))
+		logger.Info("浏览器以无头模式运行，扫码登录请使用管理后台或日志中的二维码")
+	}
+	if cfg.NoSandbox {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	if cfg.UserDataDir != "" {
+		opts = append(opts, chromedp.UserDataDir(cfg.UserDataDir))
+	}
 
 	var allocCtx context.Context
 	var cancel context.CancelFunc
 
-	if debugMode {
+	if cfg.DebugMode {
 		// Debug模式：使用独立的context，不受程序生命周期影响
 		logger.Info("🔍 Debug模式已启用：浏览器将保持打开直到手动关闭")
 		allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
@@ -57,15 +93,43 @@ func NewBrowser(cookieFile, mpURL string, timeout int, debugMode bool) (*Browser
 	browser := &Browser{
 		ctx:           ctx,
 		cancel:        cancel,
-		cookieManager: NewCookieManager(cookieFile),
-		mpURL:         mpURL,
-		timeout:       time.Duration(timeout) * time.Second,
-		debugMode:     debugMode,
+		cookieManager: NewCookieManager(cfg.CookieFile),
+		client:        NewMPClient(cfg.MPURL, time.Duration(cfg.Timeout)*time.Second, limiter),
+		limiter:       limiter,
+		mpURL:         cfg.MPURL,
+		debugMode:     cfg.DebugMode,
+		qrCodeASCII:   cfg.QRCodeASCII,
+		loginTimeout:  time.Duration(cfg.LoginScanTimeout) * time.Second,
+	}
+
+	browser.timeout.Store(int64(time.Duration(cfg.Timeout) * time.Second))
+
+	// 加载已保存的会话，接口请求无需先打开浏览器
+	if sessionData, err := browser.cookieManager.LoadSession(); err == nil && sessionData != nil && sessionData.Token != "" {
+		browser.token.Store(sessionData.Token)
+		browser.client.SetSession(sessionData)
 	}
 
 	return browser, nil
 }
 
+// SetTimeout 修改单次操作的超时时间，对之后的页面操作和接口请求立即生效
+func (b *Browser) SetTimeout(timeout time.Duration) {
+	b.timeout.Store(int64(timeout))
+	b.client.SetTimeout(timeout)
+}
+
+// Timeout 当前的单次操作超时时间
+func (b *Browser) Timeout() time.Duration {
+	return time.Duration(b.timeout.Load())
+}
+
+// currentToken 当前持有的token，未登录时为空
+func (b *Browser) currentToken() string {
+	token, _ := b.token.Load().(string)
+	return token
+}
+
 // Close 关闭浏览器
 func (b *Browser) Close() {
 	if b.debugMode {
@@ -95,16 +159,94 @@ func (b *Browser) Login() error {
 	if err == nil && sessionData != nil && len(sessionData.Cookies) > 0 {
 		logger.Info("尝试使用已保存的会话数据登录")
 		if err := b.loginWithCookies(sessionData.Cookies); err == nil {
-			// Cookie有效，保存token
-			b.token = sessionData.Token
-			logger.Info("使用已保存的token", zap.String("token", b.token))
+			// Cookie有效，保存token（loginWithCookies 可能已从URL中提取到新token）
+			if sessionData.Token != "" {
+				b.token.Store(sessionData.Token)
+			}
+			token := b.currentToken()
+			b.client.SetSession(&SessionData{Cookies: sessionData.Cookies, Token: token})
+			logger.Info("使用已保存的token", zap.String("token", token))
 			return nil
 		}
 		logger.Warn("Cookie登录失败，需要重新扫码登录")
 	}
 
-	// Cookie不存在或失效，需要扫码登录
-	return b.loginWithQRCode()
+	// Cookie不存在或失效，需要扫码登录，Debug模式下不限时，允许用户慢慢扫码和调试
+	timeout := b.loginTimeout
+	if b.debugMode {
+		timeout = 0
+		logger.Info("🔍 Debug模式：扫码登录不限时，可以随时调试")
+	}
+	return b.loginWithQRCode(nil, timeout)
+}
+
+// IsLoggedIn 是否已登录（持有token且未检测到失效）
+func (b *Browser) IsLoggedIn() bool {
+	return b.currentToken() != "" && !b.client.SessionStatus().Expired
+}
+
+// SessionStatus 获取登录态
+func (b *Browser) SessionStatus() SessionStatus {
+	return b.client.SessionStatus()
+}
+
+// StartQRLogin 在后台打开登录页等待扫码（供管理后台重新登录使用）
+// 等待期间定时截取登录页，通过 QRLoginState 获取截图；登录成功后自动保存会话数据
+// 登录期间独占浏览器，Debug模式下同样最多等待 wechat.login_scan_timeout，避免长期阻塞文章抓取
+func (b *Browser) StartQRLogin() error {
+	b.loginMu.Lock()
+	if b.loginState.Status == QRLoginWaiting {
+		b.loginMu.Unlock()
+		return fmt.Errorf("扫码登录进行中，请勿重复发起")
+	}
+	now := time.Now()
+	b.loginState = QRLoginState{Status: QRLoginWaiting, StartedAt: now, UpdatedAt: now}
+	b.loginMu.Unlock()
+
+	go func() {
+		// 登录期间独占浏览器，避免文章抓取导航打断登录页
+		b.mu.Lock()
+		err := b.loginWithQRCode(b.updateLoginQRCode, b.loginTimeout)
+		b.mu.Unlock()
+
+		b.loginMu.Lock()
+		defer b.loginMu.Unlock()
+		b.loginState.UpdatedAt = time.Now()
+		if err != nil {
+			b.loginState.Status = QRLoginFailed
+			b.loginState.Error = err.Error()
+			logger.Error("管理后台扫码登录失败", zap.Error(err))
+			return
+		}
+		b.loginState.Status = QRLoginSuccess
+		b.loginState.QRCode = nil
+	}()
+
+	return nil
+}
+
+// QRLoginState 获取扫码登录进度
+func (b *Browser) QRLoginState() QRLoginState {
+	b.loginMu.Lock()
+	defer b.loginMu.Unlock()
+
+	state := b.loginState
+	if state.Status == "" {
+		state.Status = QRLoginIdle
+	}
+	return state
+}
+
+// updateLoginQRCode 更新登录二维码截图，二维码未变化时不更新
+func (b *Browser) updateLoginQRCode(png []byte) {
+	b.loginMu.Lock()
+	defer b.loginMu.Unlock()
+
+	if bytes.Equal(png, b.loginState.QRCode) {
+		return
+	}
+	b.loginState.QRCode = png
+	b.loginState.UpdatedAt = time.Now()
 }
 
 // loginWithCookies 使用Cookie登录
@@ -119,7 +261,7 @@ func (b *Browser) loginWithCookies(cookies []*Cookie) error {
 		cancel = func() {} // 空函数，不执行任何操作
 	} else {
 		// 正常模式：使用带超时的context
-		ctx, cancel = context.WithTimeout(b.ctx, b.timeout)
+		ctx, cancel = context.WithTimeout(b.ctx, b.Timeout())
 	}
 	defer cancel()
 
@@ -171,19 +313,19 @@ func (b *Browser) loginWithCookies(cookies []*Cookie) error {
 		logger.Info("Cookie登录成功", zap.String("url", currentURL))
 
 		// 如果当前token为空，尝试从URL中提取token
-		if b.token == "" {
+		if b.currentToken() == "" {
 			u, err := url.Parse(currentURL)
 			if err == nil {
 				query := u.Query()
 				token := query.Get("token")
 				if token != "" {
-					b.token = token
-					logger.Info("从URL中提取token", zap.String("token", b.token))
+					b.token.Store(token)
+					logger.Info("从URL中提取token", zap.String("token", token))
 
 					// 更新保存的会话数据
 					sessionData, _ := b.cookieManager.LoadSession()
 					if sessionData != nil {
-						sessionData.Token = b.token
+						sessionData.Token = token
 						b.cookieManager.SaveSession(sessionData)
 					}
 				}
@@ -196,20 +338,20 @@ func (b *Browser) loginWithCookies(cookies []*Cookie) error {
 	return fmt.Errorf("cookie已失效")
 }
 
-// loginWithQRCode 扫码登录
-func (b *Browser) loginWithQRCode() error {
+// loginWithQRCode 扫码登录，timeout 为最长等待时间，0表示不限时
+// onQRCode 不为空时，等待扫码期间会定时回传二维码截图（二维码会过期刷新）；
+// 开启 qrCodeASCII 时二维码变化后会以字符画输出到日志
+func (b *Browser) loginWithQRCode(onQRCode func(png []byte), timeout time.Duration) error {
 	logger.Info("请使用微信扫码登录公众号平台")
 
-	// Debug模式下不使用超时，允许用户慢慢扫码和调试
 	var ctx context.Context
 	var cancel context.CancelFunc
 
-	if b.debugMode {
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(b.ctx, timeout)
+	} else {
 		ctx = b.ctx
 		cancel = func() {}
-		logger.Info("🔍 Debug模式：扫码登录不限时，可以随时调试")
-	} else {
-		ctx, cancel = context.WithTimeout(b.ctx, 120*time.Second)
 	}
 	defer cancel()
 
@@ -229,20 +371,28 @@ func (b *Browser) loginWithQRCode() error {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
-	var timeoutChan <-chan time.Time
-	if b.debugMode {
-		// Debug模式：不设置超时
-		timeoutChan = make(<-chan time.Time) // 永不触发的channel
-	} else {
-		// 正常模式：120秒超时
-		timeoutChan = time.After(120 * time.Second)
-	}
+	capture := onQRCode != nil || b.qrCodeASCII
+	var lastQRCode []byte
 
 	var currentURL string
-	for {
+	for tick := 0; ; tick++ {
+		if capture && tick%3 == 0 {
+			if qrCode, err := b.captureQRCode(ctx); err != nil {
+				logger.Warn("截取登录二维码失败", zap.Error(err))
+			} else {
+				if onQRCode != nil {
+					onQRCode(qrCode)
+				}
+				if b.qrCodeASCII && !bytes.Equal(qrCode, lastQRCode) {
+					logQRCodeASCII(qrCode)
+				}
+				lastQRCode = qrCode
+			}
+		}
+
 		select {
-		case <-timeoutChan:
-			return fmt.Errorf("扫码登录超时")
+		case <-ctx.Done():
+			return fmt.Errorf("扫码登录超时: %w", ctx.Err())
 		case <-ticker.C:
 			err := chromedp.Run(ctx, chromedp.Location(&currentURL))
 			if err == nil && (strings.Contains(currentURL, "home") || strings.Contains(currentURL, "cgi-bin")) {
@@ -279,8 +429,9 @@ LoginSuccess:
 		logger.Warn("未能从URL中获取token", zap.String("url", currentURL))
 	} else {
 		logger.Info("成功获取token", zap.String("token", token))
-		b.token = token // 保存到Browser实例
+		b.token.Store(token) // 保存到Browser实例
 	}
+	token = b.currentToken()
 
 	// 转换并保存Cookie和Token
 	simpleCookies := make([]*Cookie, 0, len(cookies))
@@ -300,165 +451,76 @@ LoginSuccess:
 	// 保存会话数据（Cookies + Token）
 	sessionData := &SessionData{
 		Cookies: simpleCookies,
-		Token:   b.token,
+		Token:   token,
 	}
 
 	if err := b.cookieManager.SaveSession(sessionData); err != nil {
 		logger.Warn("保存会话数据失败", zap.Error(err))
 	}
+	b.client.SetSession(sessionData)
 
-	logger.Info("扫码登录成功", zap.String("token", b.token))
+	logger.Info("扫码登录成功", zap.String("token", token))
 	return nil
 }
 
-// SearchAccount 搜索公众号并获取FakeID
-func (b *Browser) SearchAccount(accountName string) (string, error) {
-	// 加锁保护，避免并发请求导致封控
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	logger.Info("搜索公众号", zap.String("name", accountName), zap.String("token", b.token))
-
-	// 检查token是否存在
-	if b.token == "" {
-		return "", fmt.Errorf("token为空，请先登录")
-	}
-
-	// Debug模式下不使用超时，方便调试
-	var ctx context.Context
-	var cancel context.CancelFunc
+// captureQRCode 截取登录二维码元素（PNG），找不到二维码元素时退化为整页截图
+func (b *Browser) captureQRCode(ctx context.Context) ([]byte, error) {
+	var buf []byte
 
-	if b.debugMode {
-		ctx = b.ctx
-		cancel = func() {}
-		logger.Debug("Debug模式：SearchAccount 不使用超时限制")
-	} else {
-		ctx, cancel = context.WithTimeout(b.ctx, b.timeout)
-	}
+	elementCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
-
-	// 构造搜索URL，使用保存的token
-	searchURL := fmt.Sprintf("%s/cgi-bin/searchbiz?action=search_biz&begin=0&count=5&query=%s&token=%s&lang=zh_CN&f=json&ajax=1",
-		b.mpURL, url.QueryEscape(accountName), b.token)
-
-	logger.Info("搜索URL", zap.String("url", searchURL))
-
-	var responseText string
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(searchURL),
-		chromedp.Sleep(2*time.Second),
-		chromedp.Text("body", &responseText, chromedp.ByQuery),
-	)
-
-	if err != nil {
-		logger.Error("搜索公众号失败", zap.Error(err))
-		return "", err
+	if err := chromedp.Run(elementCtx, chromedp.Screenshot(qrCodeSelector, &buf, chromedp.NodeVisible, chromedp.ByQuery)); err == nil && len(buf) > 0 {
+		return buf, nil
 	}
 
-	logger.Info("搜索公众号响应", zap.String("responseText", responseText))
-
-	// 解析响应（这里需要根据实际返回的JSON结构来解析）
-	var result map[string]interface{}
-	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		logger.Error("解析搜索结果失败", zap.Error(err))
-		return "", err
-	}
-
-	// 检查是否有错误信息
-	if baseResp, ok := result["base_resp"].(map[string]interface{}); ok {
-		if ret, ok := baseResp["ret"].(float64); ok && ret != 0 {
-			errMsg := baseResp["err_msg"].(string)
-			logger.Error("搜索失败", zap.Float64("ret", ret), zap.String("err_msg", errMsg))
-			return "", fmt.Errorf("搜索失败: %s (ret=%v)", errMsg, ret)
-		}
-	}
-
-	// 提取fakeID（实际需要根据微信返回的数据结构调整）
-	if list, ok := result["list"].([]interface{}); ok && len(list) > 0 {
-		if item, ok := list[0].(map[string]interface{}); ok {
-			if fakeID, ok := item["fakeid"].(string); ok {
-				logger.Info("找到公众号", zap.String("fakeID", fakeID))
-				return fakeID, nil
-			}
-		}
+	if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+		return nil, err
 	}
-
-	return "", fmt.Errorf("未找到公众号: %s", accountName)
+	return buf, nil
 }
 
-// FetchArticles 获取公众号文章列表
-func (b *Browser) FetchArticles(fakeID string, count int) ([]*model.ArticleListItem, error) {
-	// 加锁保护，避免并发请求导致封控
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	logger.Info("获取文章列表", zap.String("fakeID", fakeID), zap.Int("count", count), zap.String("token", b.token))
-
-	// 检查token是否存在
-	if b.token == "" {
-		return nil, fmt.Errorf("token为空，请先登录")
-	}
-
-	// Debug模式下不使用超时，方便调试
-	var ctx context.Context
-	var cancel context.CancelFunc
-
-	if b.debugMode {
-		ctx = b.ctx
-		cancel = func() {}
-		logger.Debug("Debug模式：FetchArticles 不使用超时限制")
-	} else {
-		ctx, cancel = context.WithTimeout(b.ctx, b.timeout)
-	}
-	defer cancel()
-
-	// 构造文章列表URL，使用保存的token
-	articleURL := fmt.Sprintf("%s/cgi-bin/appmsg?action=list_ex&begin=0&count=%d&fakeid=%s&type=9&token=%s",
-		b.mpURL, count, fakeID, b.token)
-
-	logger.Info("文章列表URL", zap.String("url", articleURL))
-
-	var responseText string
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(articleURL),
-		chromedp.Sleep(2*time.Second),
-		// 使用 JavaScript 获取纯文本内容，避免 HTML 标签干扰
-		chromedp.Evaluate(`document.body.textContent || document.body.innerText`, &responseText),
-	)
+// SearchAccount 搜索公众号并获取FakeID
+// 直接请求 searchbiz JSON接口，复用登录后保存的Cookie和token
+func (b *Browser) SearchAccount(ctx context.Context, accountName string) (string, error) {
+	logger.Info("搜索公众号", zap.String("name", accountName))
+	return b.client.SearchAccount(ctx, accountName)
+}
 
+// FetchArticles 获取公众号最新的文章列表
+func (b *Browser) FetchArticles(ctx context.Context, fakeID string, count int) ([]*model.ArticleListItem, error) {
+	page, err := b.FetchArticlePage(ctx, fakeID, 0, count)
 	if err != nil {
-		logger.Error("获取文章列表失败", zap.Error(err))
 		return nil, err
 	}
+	return page.Items, nil
+}
 
-	logger.Info("文章列表响应", zap.String("responseText", responseText))
+// FetchArticlePage 分页获取公众号文章列表（begin为偏移量）
+// 直接请求 appmsg JSON接口，复用登录后保存的Cookie和token
+func (b *Browser) FetchArticlePage(ctx context.Context, fakeID string, begin, count int) (*ArticlePage, error) {
+	logger.Info("获取文章列表", zap.String("fakeID", fakeID), zap.Int("begin", begin), zap.Int("count", count))
+	return b.client.FetchArticlePage(ctx, fakeID, begin, count)
+}
 
-	// 解析响应
-	var result struct {
-		BaseResp   map[string]interface{}   `json:"base_resp"`
-		AppMsgList []*model.ArticleListItem `json:"app_msg_list"`
-	}
+// FetchArticleContent 获取文章详细内容
+func (b *Browser) FetchArticleContent(ctx context.Context, articleURL string) (string, error) {
+	content, _, err := b.fetchArticle(ctx, articleURL, nil)
+	return content, err
+}
 
-	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		logger.Error("解析文章列表失败", zap.Error(err))
-		return nil, err
-	}
+// FetchArticleSnapshot 获取文章详细内容，并在同一次页面加载中保存页面快照
+func (b *Browser) FetchArticleSnapshot(ctx context.Context, articleURL string, formats []string) (string, []*Snapshot, error) {
+	return b.fetchArticle(ctx, articleURL, formats)
+}
 
-	// 检查是否有错误信息
-	if result.BaseResp != nil {
-		if ret, ok := result.BaseResp["ret"].(float64); ok && ret != 0 {
-			errMsg, _ := result.BaseResp["err_msg"].(string)
-			logger.Error("获取文章列表失败", zap.Float64("ret", ret), zap.String("err_msg", errMsg))
-			return nil, fmt.Errorf("获取文章列表失败: %s (ret=%v)", errMsg, ret)
-		}
+// fetchArticle 打开文章页面获取正文，formats 不为空时同时保存页面快照
+// 页面操作使用浏览器自身的context，reqCtx 取消时（如管理后台取消爬取）同时中止限流等待和当前页面的加载
+func (b *Browser) fetchArticle(reqCtx context.Context, articleURL string, formats []string) (string, []*Snapshot, error) {
+	// 按文章页面的频率预算等待
+	if err := b.limiter.Wait(reqCtx, EndpointArticle); err != nil {
+		return "", nil, err
 	}
 
-	logger.Info("获取文章列表成功", zap.Int("count", len(result.AppMsgList)))
-	return result.AppMsgList, nil
-}
-
-// FetchArticleContent 获取文章详细内容
-func (b *Browser) FetchArticleContent(articleURL string) (string, error) {
 	// 加锁保护，避免并发请求导致封控
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -470,41 +532,51 @@ func (b *Browser) FetchArticleContent(articleURL string) (string, error) {
 	var cancel context.CancelFunc
 
 	if b.debugMode {
-		ctx = b.ctx
-		cancel = func() {}
+		ctx, cancel = context.WithCancel(b.ctx)
 	} else {
-		ctx, cancel = context.WithTimeout(b.ctx, b.timeout)
+		ctx, cancel = context.WithTimeout(b.ctx, b.Timeout())
 	}
 	defer cancel()
+	// 请求被取消时中止页面操作，只取消本次操作，不关闭浏览器标签页
+	stop := context.AfterFunc(reqCtx, cancel)
+	defer stop()
 
 	// 首先导航到文章页面
 	err := chromedp.Run(ctx, chromedp.Navigate(articleURL))
 	if err != nil {
+		if reqCtx.Err() != nil {
+			return "", nil, reqCtx.Err()
+		}
 		logger.Error("导航到文章页面失败", zap.String("url", articleURL), zap.Error(err))
-		return "", fmt.Errorf("导航失败: %w", err)
+		return "", nil, fmt.Errorf("导航失败: %w", err)
 	}
 
 	// 等待页面加载
-	time.Sleep(2 * time.Second)
+	select {
+	case <-ctx.Done():
+		if reqCtx.Err() != nil {
+			return "", nil, reqCtx.Err()
+		}
+		return "", nil, fmt.Errorf("等待页面加载超时: %w", ctx.Err())
+	case <-time.After(2 * time.Second):
+	}
 
 	// 检查页面标题，判断文章是否存在
 	var pageTitle string
 	err = chromedp.Run(ctx, chromedp.Title(&pageTitle))
 	if err != nil {
 		logger.Warn("无法获取页面标题", zap.Error(err))
+	} else if isFreqControlPage(pageTitle) {
+		reason := fmt.Sprintf("文章页面触发访问限制: %s", pageTitle)
+		b.limiter.ReportFreqControl(reason)
+		return "", nil, fmt.Errorf("%w: %s", ErrCrawlerPaused, reason)
 	} else {
-		// 检查是否是404或文章已删除的页面
-		if strings.Contains(pageTitle, "404") ||
-			strings.Contains(pageTitle, "页面不存在") ||
-			strings.Contains(pageTitle, "已删除") ||
-			strings.Contains(pageTitle, "此内容发送失败无法查看") ||
-			strings.Contains(pageTitle, "微信公众平台") ||
-			strings.Contains(pageTitle, "该内容暂时无法查看") ||
-			strings.Contains(pageTitle, "已被删除") {
-			logger.Warn("文章已删除或不存在",
+		// 检查是否是404、文章已删除或被屏蔽的页面
+		if err := checkArticlePage(pageTitle); err != nil {
+			logger.Warn("文章已删除或不可访问",
 				zap.String("url", articleURL),
 				zap.String("title", pageTitle))
-			return "", fmt.Errorf("文章已删除或不存在: %s", pageTitle)
+			return "", nil, err
 		}
 	}
 
@@ -513,12 +585,12 @@ func (b *Browser) FetchArticleContent(articleURL string) (string, error) {
 	err = chromedp.Run(ctx, chromedp.Nodes("#js_content", &nodes, chromedp.ByID))
 	if err != nil {
 		logger.Error("查找文章内容元素失败", zap.String("url", articleURL), zap.Error(err))
-		return "", fmt.Errorf("查找内容元素失败: %w", err)
+		return "", nil, fmt.Errorf("查找内容元素失败: %w", err)
 	}
 
 	if len(nodes) == 0 {
 		logger.Warn("文章内容元素不存在", zap.String("url", articleURL))
-		return "", fmt.Errorf("文章内容元素不存在，可能文章已删除或页面结构变化")
+		return "", nil, fmt.Errorf("文章内容元素不存在，可能文章已删除或页面结构变化")
 	}
 
 	// 获取文章内容
@@ -526,20 +598,73 @@ func (b *Browser) FetchArticleContent(articleURL string) (string, error) {
 	err = chromedp.Run(ctx, chromedp.OuterHTML("#js_content", &content, chromedp.ByID))
 	if err != nil {
 		logger.Error("获取文章HTML内容失败", zap.String("url", articleURL), zap.Error(err))
-		return "", fmt.Errorf("获取HTML内容失败: %w", err)
+		return "", nil, fmt.Errorf("获取HTML内容失败: %w", err)
 	}
 
 	// 检查内容是否为空
 	if strings.TrimSpace(content) == "" {
 		logger.Warn("文章内容为空", zap.String("url", articleURL))
-		return "", fmt.Errorf("文章内容为空")
+		return "", nil, fmt.Errorf("文章内容为空")
 	}
 
 	logger.Info("成功获取文章内容",
 		zap.String("url", articleURL),
 		zap.Int("content_length", len(content)))
+	b.limiter.ReportSuccess()
+
+	var snapshots []*Snapshot
+	if len(formats) > 0 {
+		snapshots = b.captureSnapshots(ctx, articleURL, formats)
+	}
+	return content, snapshots, nil
+}
+
+// lazyImagesScript 把懒加载图片的 data-src 换到 src，保证快照中的图片完整
+const lazyImagesScript = `document.querySelectorAll('img[data-src]').forEach(function (img) { img.src = img.getAttribute('data-src'); }); true`
+
+// captureSnapshots 保存当前页面的快照，单个格式失败时只记录日志
+func (b *Browser) captureSnapshots(ctx context.Context, articleURL string, formats []string) []*Snapshot {
+	var loaded bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(lazyImagesScript, &loaded)); err != nil {
+		logger.Warn("加载懒加载图片失败", zap.String("url", articleURL), zap.Error(err))
+	} else {
+		// 等待图片加载
+		time.Sleep(2 * time.Second)
+	}
+
+	var snapshots []*Snapshot
+	for _, format := range formats {
+		var data []byte
+		var err error
+		switch format {
+		case SnapshotMHTML:
+			var mhtml string
+			err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				mhtml, err = page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+				return err
+			}))
+			data = []byte(mhtml)
+		case SnapshotPDF:
+			err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				data, _, err = page.PrintToPDF().WithPrintBackground(true).Do(ctx)
+				return err
+			}))
+		case SnapshotScreenshot:
+			err = chromedp.Run(ctx, chromedp.FullScreenshot(&data, 100))
+		default:
+			err = fmt.Errorf("不支持的快照格式: %s", format)
+		}
+		if err != nil {
+			logger.Warn("保存页面快照失败", zap.String("url", articleURL), zap.String("format", format), zap.Error(err))
+			continue
+		}
+		snapshots = append(snapshots, &Snapshot{Format: format, Data: data})
+	}
 
-	return content, nil
+	logger.Info("已保存页面快照", zap.String("url", articleURL), zap.Int("count", len(snapshots)))
+	return snapshots
 }
 
 // GetToken 从当前页面提取token（用于API请求）
@@ -0,0 +1,356 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// FakeMPServer 本地模拟的微信公众号后台
+// 实现 searchbiz、appmsg 两个JSON接口以及文章详情页，返回结构与 mp.weixin.qq.com 保持一致，
+// 用于单元测试和离线演示环境
+type FakeMPServer struct {
+	mu       sync.RWMutex
+	token    string
+	accounts []*FakeAccount
+	nextMid  int64
+	server   *http.Server
+
+	freqControl bool // 为true时接口返回频率限制（ret=200013），用于演示和测试风控处理
+}
+
+// FakeAccount 模拟公众号
+type FakeAccount struct {
+	FakeID   string
+	Nickname string
+	Alias    string
+	Articles []*FakeArticle // 按发布时间倒序
+}
+
+// FakeArticle 模拟文章
+type FakeArticle struct {
+	Mid        int64
+	Title      string
+	Digest     string
+	Author     string
+	Cover      string
+	Content    string // 正文HTML（#js_content 内部）
+	CreateTime int64
+	Deleted    bool // 为true时文章页返回“已删除”
+	Blocked    bool // 为true时文章页返回“因违规无法查看”
+}
+
+// NewFakeMPServer 创建模拟后台，token 为请求接口时需要携带的token
+func NewFakeMPServer(token string) *FakeMPServer {
+	return &FakeMPServer{
+		token:   token,
+		nextMid: 2247480000,
+	}
+}
+
+// AddAccount 添加模拟公众号，返回创建的公众号
+func (s *FakeMPServer) AddAccount(nickname, alias string) *FakeAccount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := &FakeAccount{
+		FakeID:   base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%010d", 3000000000+len(s.accounts)))),
+		Nickname: nickname,
+		Alias:    alias,
+	}
+	s.accounts = append(s.accounts, account)
+	return account
+}
+
+// AddArticle 为指定公众号发布一篇模拟文章
+func (s *FakeMPServer) AddArticle(fakeID string, article *FakeArticle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.findAccount(fakeID)
+	if account == nil {
+		return fmt.Errorf("模拟公众号不存在: %s", fakeID)
+	}
+
+	s.nextMid++
+	article.Mid = s.nextMid
+	if article.CreateTime == 0 {
+		article.CreateTime = time.Now().Unix()
+	}
+
+	account.Articles = append(account.Articles, article)
+	sort.SliceStable(account.Articles, func(i, j int) bool {
+		return account.Articles[i].CreateTime > account.Articles[j].CreateTime
+	})
+	return nil
+}
+
+// SetArticleState 修改模拟文章的状态（删除/屏蔽），用于演示文章状态复查
+func (s *FakeMPServer) SetArticleState(mid int64, deleted, blocked bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, account := range s.accounts {
+		for _, article := range account.Articles {
+			if article.Mid == mid {
+				article.Deleted = deleted
+				article.Blocked = blocked
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("模拟文章不存在: %d", mid)
+}
+
+// SetFreqControl 开启/关闭模拟的频率限制
+func (s *FakeMPServer) SetFreqControl(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.freqControl = on
+}
+
+// SeedDemoData 填充演示数据
+func (s *FakeMPServer) SeedDemoData() {
+	now := time.Now()
+	demo := []struct {
+		nickname string
+		alias    string
+		count    int
+	}{
+		{"Go语言中文网", "studygolang", 12},
+		{"架构师之路", "archnotes", 8},
+		{"离线演示号", "demo", 3},
+	}
+
+	for _, d := range demo {
+		account := s.AddAccount(d.nickname, d.alias)
+		for i := 0; i < d.count; i++ {
+			publishTime := now.Add(-time.Duration(i*26+3) * time.Hour)
+			title := fmt.Sprintf("%s 第%d期", d.nickname, d.count-i)
+			_ = s.AddArticle(account.FakeID, &FakeArticle{
+				Title:      title,
+				Digest:     fmt.Sprintf("%s 的演示摘要", title),
+				Author:     d.nickname + "编辑部",
+				Cover:      "https://mmbiz.qpic.cn/mmbiz_jpg/demo/0?wx_fmt=jpeg",
+				Content:    fmt.Sprintf("<p>这是《%s》的正文内容。</p><p>发布于 %s。</p>", title, publishTime.Format("2006-01-02 15:04")),
+				CreateTime: publishTime.Unix(),
+			})
+		}
+	}
+
+	logger.Info("模拟公众号后台已填充演示数据", zap.Int("accounts", len(demo)))
+}
+
+// Start 在指定地址启动模拟后台（非阻塞）
+func (s *FakeMPServer) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听模拟后台地址失败: %w", err)
+	}
+
+	s.server = &http.Server{Handler: s}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("模拟公众号后台异常退出", zap.Error(err))
+		}
+	}()
+
+	logger.Info("模拟公众号后台已启动", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+// Close 关闭模拟后台
+func (s *FakeMPServer) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// ServeHTTP 处理模拟请求
+func (s *FakeMPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/cgi-bin/searchbiz":
+		s.handleSearchBiz(w, r)
+	case "/cgi-bin/appmsg":
+		s.handleAppMsg(w, r)
+	case "/s":
+		s.handleArticle(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleSearchBiz 模拟 /cgi-bin/searchbiz
+func (s *FakeMPServer) handleSearchBiz(w http.ResponseWriter, r *http.Request) {
+	if !s.checkToken(w, r) {
+		return
+	}
+
+	query := r.URL.Query().Get("query")
+	count := queryInt(r, "count", 5)
+
+	s.mu.RLock()
+	list := make([]map[string]interface{}, 0)
+	for _, account := range s.accounts {
+		if len(list) >= count {
+			break
+		}
+		if query != "" && (strings.Contains(account.Nickname, query) || account.Alias == query) {
+			list = append(list, map[string]interface{}{
+				"fakeid":   account.FakeID,
+				"nickname": account.Nickname,
+				"alias":    account.Alias,
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	writeFakeJSON(w, map[string]interface{}{
+		"base_resp": map[string]interface{}{"ret": 0, "err_msg": "ok"},
+		"list":      list,
+		"total":     len(list),
+	})
+}
+
+// handleAppMsg 模拟 /cgi-bin/appmsg?action=list_ex
+func (s *FakeMPServer) handleAppMsg(w http.ResponseWriter, r *http.Request) {
+	if !s.checkToken(w, r) {
+		return
+	}
+
+	begin := queryInt(r, "begin", 0)
+	count := queryInt(r, "count", 5)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account := s.findAccount(r.URL.Query().Get("fakeid"))
+	if account == nil {
+		writeFakeJSON(w, map[string]interface{}{
+			"base_resp": map[string]interface{}{"ret": 200002, "err_msg": "invalid args"},
+		})
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, count)
+	for i := begin; i < len(account.Articles) && i < begin+count; i++ {
+		a := account.Articles[i]
+		list = append(list, map[string]interface{}{
+			"aid":         fmt.Sprintf("%d_1", a.Mid),
+			"title":       a.Title,
+			"digest":      a.Digest,
+			"cover":       a.Cover,
+			"link":        s.articleLink(r, account, a),
+			"create_time": a.CreateTime,
+			"update_time": a.CreateTime,
+			"author":      a.Author,
+		})
+	}
+
+	writeFakeJSON(w, map[string]interface{}{
+		"base_resp":    map[string]interface{}{"ret": 0, "err_msg": "ok"},
+		"app_msg_cnt":  len(account.Articles),
+		"app_msg_list": list,
+	})
+}
+
+// handleArticle 模拟文章详情页 /s?__biz=...&mid=...
+func (s *FakeMPServer) handleArticle(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mid, _ := strconv.ParseInt(query.Get("mid"), 10, 64)
+
+	s.mu.RLock()
+	var article *FakeArticle
+	if account := s.findAccount(query.Get("__biz")); account != nil {
+		for _, a := range account.Articles {
+			if a.Mid == mid {
+				article = a
+				break
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if article == nil || article.Deleted {
+		fmt.Fprint(w, `<html><head><title>该内容已被发布者删除</title></head><body><p>该内容已被发布者删除</p></body></html>`)
+		return
+	}
+	if article.Blocked {
+		fmt.Fprint(w, `<html><head><title>此内容因违规无法查看</title></head><body><p>此内容因违规无法查看</p></body></html>`)
+		return
+	}
+
+	fmt.Fprintf(w, `<html><head><title>%s</title></head><body><h1 id="activity-name">%s</h1><div class="rich_media_content" id="js_content">%s</div></body></html>`,
+		template.HTMLEscapeString(article.Title),
+		template.HTMLEscapeString(article.Title),
+		article.Content)
+}
+
+// checkToken 校验token和模拟的频率限制，失败时返回与微信后台一致的错误响应
+func (s *FakeMPServer) checkToken(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Query().Get("token") != s.token {
+		writeFakeJSON(w, map[string]interface{}{
+			"base_resp": map[string]interface{}{"ret": 200003, "err_msg": "invalid session"},
+		})
+		return false
+	}
+
+	s.mu.RLock()
+	freqControl := s.freqControl
+	s.mu.RUnlock()
+	if freqControl {
+		writeFakeJSON(w, map[string]interface{}{
+			"base_resp": map[string]interface{}{"ret": 200013, "err_msg": "freq control"},
+		})
+		return false
+	}
+	return true
+}
+
+// findAccount 根据FakeID查找模拟公众号（调用方需持有锁）
+func (s *FakeMPServer) findAccount(fakeID string) *FakeAccount {
+	for _, account := range s.accounts {
+		if account.FakeID == fakeID {
+			return account
+		}
+	}
+	return nil
+}
+
+// articleLink 生成与微信格式一致的文章链接
+func (s *FakeMPServer) articleLink(r *http.Request, account *FakeAccount, article *FakeArticle) string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s:%d", account.FakeID, article.Mid)))
+	return fmt.Sprintf("http://%s/s?__biz=%s&mid=%d&idx=1&sn=%s",
+		r.Host, account.FakeID, article.Mid, hex.EncodeToString(sum[:]))
+}
+
+// queryInt 读取整型查询参数
+func queryInt(r *http.Request, key string, defaultValue int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// writeFakeJSON 输出JSON响应
+func writeFakeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(data)
+}
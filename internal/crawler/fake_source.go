@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"wechat-crawler/internal/model"
+
+	"golang.org/x/net/html"
+)
+
+// FakeSource 基于本地模拟后台（FakeMPServer）的数据源
+// 搜索和文章列表与浏览器数据源共用 MPClient，不依赖Chrome和真实的公众号登录态
+type FakeSource struct {
+	mp      *MPClient
+	client  *http.Client
+	limiter *RateLimiter
+}
+
+// SetTimeout 修改单次请求的超时时间，立即生效
+func (f *FakeSource) SetTimeout(timeout time.Duration) {
+	f.mp.SetTimeout(timeout)
+}
+
+// NewFakeSource 创建模拟数据源，limiter 为空时不限流
+func NewFakeSource(baseURL, token string, timeout int, limiter *RateLimiter) *FakeSource {
+	mp := NewMPClient(baseURL, time.Duration(timeout)*time.Second, limiter)
+	mp.SetSession(&SessionData{Token: token})
+
+	return &FakeSource{
+		mp:      mp,
+		client:  &http.Client{},
+		limiter: limiter,
+	}
+}
+
+// SearchAccount 搜索公众号并获取FakeID
+func (f *FakeSource) SearchAccount(ctx context.Context, accountName string) (string, error) {
+	return f.mp.SearchAccount(ctx, accountName)
+}
+
+// FetchArticles 获取公众号最新的文章列表
+func (f *FakeSource) FetchArticles(ctx context.Context, fakeID string, count int) ([]*model.ArticleListItem, error) {
+	page, err := f.FetchArticlePage(ctx, fakeID, 0, count)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// FetchArticlePage 分页获取公众号文章列表
+func (f *FakeSource) FetchArticlePage(ctx context.Context, fakeID string, begin, count int) (*ArticlePage, error) {
+	return f.mp.FetchArticlePage(ctx, fakeID, begin, count)
+}
+
+// FetchArticleContent 获取文章详细内容
+func (f *FakeSource) FetchArticleContent(ctx context.Context, articleURL string) (string, error) {
+	if err := f.limiter.Wait(ctx, EndpointArticle); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.mp.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, articleURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("构造请求失败: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求文章页面失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 5xx 等错误页面中没有正文，不能当作文章已删除处理，返回错误由调用方稍后重试
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("文章页面返回错误状态码: %d", resp.StatusCode)
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("解析文章页面失败: %w", err)
+	}
+
+	if title := nodeText(findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "title"
+	})); isFreqControlPage(title) {
+		reason := fmt.Sprintf("文章页面触发访问限制: %s", title)
+		f.limiter.ReportFreqControl(reason)
+		return "", fmt.Errorf("%w: %s", ErrCrawlerPaused, reason)
+	} else if err := checkArticlePage(title); err != nil {
+		return "", err
+	}
+
+	content := findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && nodeAttr(n, "id") == "js_content"
+	})
+	if content == nil {
+		return "", fmt.Errorf("文章内容元素不存在，可能文章已删除或页面结构变化")
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, content); err != nil {
+		return "", fmt.Errorf("获取HTML内容失败: %w", err)
+	}
+
+	f.limiter.ReportSuccess()
+	return buf.String(), nil
+}
+
+// IsLoggedIn 配置了token时视为已登录，模拟后台校验token失败后由 SessionStatus 标记为过期
+func (f *FakeSource) IsLoggedIn() bool {
+	return f.mp.Token() != ""
+}
+
+// SessionStatus 获取登录态
+func (f *FakeSource) SessionStatus() SessionStatus {
+	return f.mp.SessionStatus()
+}
+
+// Close 模拟数据源无需释放资源
+func (f *FakeSource) Close() {}
+
+// findNode 深度优先查找第一个满足条件的节点
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n == nil {
+		return nil
+	}
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeAttr 读取节点属性
+func nodeAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// nodeText 拼接节点下的所有文本
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wechat-crawler/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "crawler-test")
+	if err := logger.Init(filepath.Join(dir, "test.log"), "error"); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 验证模拟数据源能完成 搜索 -> 文章列表 -> 正文 的完整流程
+func TestFakeSourcePipeline(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	for _, title := range []string{"第一篇", "第二篇"} {
+		if err := fakeServer.AddArticle(account.FakeID, &FakeArticle{Title: title, Content: "<p>" + title + "正文</p>"}); err != nil {
+			t.Fatalf("添加模拟文章失败: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	source := NewFakeSource(server.URL, "test-token", 5, nil)
+
+	fakeID, err := source.SearchAccount(context.Background(), "测试公众号")
+	if err != nil {
+		t.Fatalf("搜索公众号失败: %v", err)
+	}
+	if fakeID != account.FakeID {
+		t.Fatalf("FakeID不一致: got %s, want %s", fakeID, account.FakeID)
+	}
+
+	items, err := source.FetchArticles(context.Background(), fakeID, 10)
+	if err != nil {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("文章数量不正确: got %d, want 2", len(items))
+	}
+
+	content, err := source.FetchArticleContent(context.Background(), items[0].ContentURL)
+	if err != nil {
+		t.Fatalf("获取文章内容失败: %v", err)
+	}
+	if !strings.Contains(content, `id="js_content"`) || !strings.Contains(content, "正文") {
+		t.Fatalf("文章内容不正确: %s", content)
+	}
+}
+
+// 验证token错误时返回 invalid session 并标记会话过期
+func TestFakeSourceInvalidToken(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	fakeServer.AddAccount("测试公众号", "test")
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	source := NewFakeSource(server.URL, "wrong-token", 5, nil)
+	if _, err := source.SearchAccount(context.Background(), "测试公众号"); err == nil || !strings.Contains(err.Error(), "200003") {
+		t.Fatalf("期望返回 invalid session 错误, got %v", err)
+	}
+
+	// 检测到失效后标记会话过期，后续请求不再发往后台
+	if status := source.SessionStatus(); !status.Expired {
+		t.Fatalf("期望会话被标记为过期: %+v", status)
+	}
+	if _, err := source.SearchAccount(context.Background(), "测试公众号"); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("期望返回 ErrSessionExpired, got %v", err)
+	}
+
+	// 重新设置会话后恢复
+	source.mp.SetSession(&SessionData{Token: "test-token"})
+	if _, err := source.SearchAccount(context.Background(), "测试公众号"); err != nil {
+		t.Fatalf("重新登录后搜索失败: %v", err)
+	}
+}
+
+// 验证文章被删除或屏蔽后返回可区分的错误
+func TestFakeSourceArticleState(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	article := &FakeArticle{Title: "会被删除的文章", Content: "<p>正文</p>"}
+	if err := fakeServer.AddArticle(account.FakeID, article); err != nil {
+		t.Fatalf("添加模拟文章失败: %v", err)
+	}
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	source := NewFakeSource(server.URL, "test-token", 5, nil)
+	items, err := source.FetchArticles(context.Background(), account.FakeID, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+
+	cases := []struct {
+		deleted, blocked bool
+		want             error
+	}{
+		{false, true, ErrArticleBlocked},
+		{true, false, ErrArticleDeleted},
+	}
+	for _, tc := range cases {
+		if err := fakeServer.SetArticleState(article.Mid, tc.deleted, tc.blocked); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := source.FetchArticleContent(context.Background(), items[0].ContentURL); !errors.Is(err, tc.want) {
+			t.Errorf("deleted=%v blocked=%v: got %v, want %v", tc.deleted, tc.blocked, err, tc.want)
+		}
+	}
+}
+
+// 验证文章页面返回错误状态码时返回错误，而不是当作已删除
+func TestFakeSourceArticleHTTPError(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	if err := fakeServer.AddArticle(account.FakeID, &FakeArticle{Title: "文章", Content: "<p>正文</p>"}); err != nil {
+		t.Fatalf("添加模拟文章失败: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/s" {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		fakeServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	source := NewFakeSource(server.URL, "test-token", 5, nil)
+	items, err := source.FetchArticles(context.Background(), account.FakeID, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+
+	_, err = source.FetchArticleContent(context.Background(), items[0].ContentURL)
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("got %v, want 502 error", err)
+	}
+	if errors.Is(err, ErrArticleDeleted) || errors.Is(err, ErrArticleBlocked) {
+		t.Errorf("错误状态码不应视为文章已删除: %v", err)
+	}
+}
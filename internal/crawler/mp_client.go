@@ -0,0 +1,283 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// defaultUserAgent 与浏览器保持一致的UA，避免接口请求特征与页面访问不一致
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// MPClient 公众号后台JSON接口客户端
+// 复用 CookieManager 持久化的 Cookie 和 token，直接调用 searchbiz / appmsg 接口，
+// 不再通过浏览器导航和抓取页面文本
+type MPClient struct {
+	baseURL string
+	client  *http.Client
+	timeout atomic.Int64 // 单次请求超时时间，可在运行时修改
+	limiter *RateLimiter
+	mu      sync.RWMutex
+	token   string
+	cookies []*Cookie
+	status  SessionStatus
+}
+
+// 公众号后台 base_resp.ret 错误码
+const (
+	RetInvalidSession = 200003 // 登录态失效
+	RetFreqControl    = 200013 // 请求过于频繁
+)
+
+// baseResp 公众号后台接口通用的返回状态
+type baseResp struct {
+	Ret    int    `json:"ret"`
+	ErrMsg string `json:"err_msg"`
+}
+
+// MPError 公众号后台接口返回的业务错误（base_resp.ret != 0）
+type MPError struct {
+	Op     string
+	Ret    int
+	ErrMsg string
+}
+
+func (e *MPError) Error() string {
+	return fmt.Sprintf("%s: %s (ret=%d)", e.Op, e.ErrMsg, e.Ret)
+}
+
+// NewMPClient 创建接口客户端，limiter 为空时不限流
+func NewMPClient(baseURL string, timeout time.Duration, limiter *RateLimiter) *MPClient {
+	c := &MPClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{},
+		limiter: limiter,
+	}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout 修改单次请求的超时时间，对之后的请求立即生效
+func (c *MPClient) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+// Timeout 当前的单次请求超时时间
+func (c *MPClient) Timeout() time.Duration {
+	return time.Duration(c.timeout.Load())
+}
+
+// SetSession 设置请求使用的会话数据（Cookies + Token）
+func (c *MPClient) SetSession(session *SessionData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status = SessionStatus{UpdatedAt: time.Now()}
+	if session == nil {
+		c.token = ""
+		c.cookies = nil
+		return
+	}
+	c.token = session.Token
+	c.cookies = session.Cookies
+	c.status.LoggedIn = session.Token != ""
+}
+
+// SessionStatus 当前登录态
+func (c *MPClient) SessionStatus() SessionStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// markExpired 标记登录态失效，之后的请求直接返回 ErrSessionExpired，直到重新设置会话
+func (c *MPClient) markExpired(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status.Expired {
+		return
+	}
+	c.status.Expired = true
+	c.status.ExpiredAt = time.Now()
+	c.status.Reason = reason
+
+	logger.Warn("公众号登录态已失效，需要重新扫码登录", zap.String("reason", reason))
+}
+
+// Token 当前使用的token
+func (c *MPClient) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SearchAccount 调用 searchbiz 接口搜索公众号并返回FakeID
+func (c *MPClient) SearchAccount(ctx context.Context, accountName string) (string, error) {
+	token := c.Token()
+	if token == "" {
+		return "", fmt.Errorf("token为空，请先登录")
+	}
+
+	params := url.Values{}
+	params.Set("action", "search_biz")
+	params.Set("begin", "0")
+	params.Set("count", "5")
+	params.Set("query", accountName)
+	params.Set("token", token)
+	params.Set("lang", "zh_CN")
+	params.Set("f", "json")
+	params.Set("ajax", "1")
+
+	var result struct {
+		List []struct {
+			FakeID   string `json:"fakeid"`
+			Nickname string `json:"nickname"`
+		} `json:"list"`
+	}
+	if err := c.get(ctx, EndpointSearch, "搜索失败", "/cgi-bin/searchbiz", params, &result); err != nil {
+		logger.Error("搜索公众号失败", zap.String("name", accountName), zap.Error(err))
+		return "", err
+	}
+
+	if len(result.List) == 0 || result.List[0].FakeID == "" {
+		return "", fmt.Errorf("未找到公众号: %s", accountName)
+	}
+
+	logger.Info("找到公众号", zap.String("name", accountName), zap.String("fakeID", result.List[0].FakeID))
+	return result.List[0].FakeID, nil
+}
+
+// FetchArticlePage 调用 appmsg 接口分页获取文章列表
+func (c *MPClient) FetchArticlePage(ctx context.Context, fakeID string, begin, count int) (*ArticlePage, error) {
+	token := c.Token()
+	if token == "" {
+		return nil, fmt.Errorf("token为空，请先登录")
+	}
+
+	params := url.Values{}
+	params.Set("action", "list_ex")
+	params.Set("begin", fmt.Sprintf("%d", begin))
+	params.Set("count", fmt.Sprintf("%d", count))
+	params.Set("fakeid", fakeID)
+	params.Set("type", "9")
+	params.Set("token", token)
+	params.Set("lang", "zh_CN")
+	params.Set("f", "json")
+	params.Set("ajax", "1")
+
+	var result struct {
+		AppMsgCnt  int                      `json:"app_msg_cnt"`
+		AppMsgList []*model.ArticleListItem `json:"app_msg_list"`
+	}
+	if err := c.get(ctx, EndpointList, "获取文章列表失败", "/cgi-bin/appmsg", params, &result); err != nil {
+		logger.Error("获取文章列表失败", zap.String("fakeID", fakeID), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("获取文章列表成功",
+		zap.String("fakeID", fakeID),
+		zap.Int("begin", begin),
+		zap.Int("count", len(result.AppMsgList)),
+		zap.Int("total", result.AppMsgCnt))
+
+	return &ArticlePage{
+		Items: result.AppMsgList,
+		Total: result.AppMsgCnt,
+	}, nil
+}
+
+// get 经限流器放行后发送GET请求并解析JSON响应
+// base_resp.ret 非0时返回 *MPError，识别到频率限制时通知限流器暂停，识别到登录态失效时标记会话过期
+// ctx 被取消时停止等待限流器并中断请求
+func (c *MPClient) get(ctx context.Context, endpoint Endpoint, op, path string, params url.Values, v interface{}) error {
+	if c.SessionStatus().Expired {
+		return ErrSessionExpired
+	}
+
+	if err := c.limiter.Wait(ctx, endpoint); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("构造请求失败: %w", err)
+	}
+
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Referer", c.baseURL+"/")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	if cookie := c.cookieHeader(); cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("接口返回错误状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var status struct {
+		BaseResp baseResp `json:"base_resp"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if status.BaseResp.Ret != 0 {
+		mpErr := &MPError{Op: op, Ret: status.BaseResp.Ret, ErrMsg: status.BaseResp.ErrMsg}
+		switch mpErr.Ret {
+		case RetFreqControl:
+			c.limiter.ReportFreqControl(mpErr.Error())
+		case RetInvalidSession:
+			c.markExpired(mpErr.Error())
+		}
+		return mpErr
+	}
+	c.limiter.ReportSuccess()
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// cookieHeader 拼接未过期的Cookie
+func (c *MPClient) cookieHeader() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := float64(time.Now().Unix())
+	parts := make([]string, 0, len(c.cookies))
+	for _, cookie := range c.cookies {
+		// Expires<=0 为会话Cookie
+		if cookie.Expires > 0 && cookie.Expires < now {
+			continue
+		}
+		parts = append(parts, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(parts, "; ")
+}
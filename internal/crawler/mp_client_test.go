@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 验证接口请求携带未过期的Cookie和token
+func TestMPClientSendsSession(t *testing.T) {
+	var gotCookie, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		gotToken = r.URL.Query().Get("token")
+		w.Write([]byte(`{"base_resp":{"ret":0,"err_msg":"ok"},"list":[{"fakeid":"fake-1","nickname":"测试"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewMPClient(server.URL, 5*time.Second, nil)
+	client.SetSession(&SessionData{
+		Token: "t-123",
+		Cookies: []*Cookie{
+			{Name: "slave_sid", Value: "abc"},
+			{Name: "expired", Value: "old", Expires: float64(time.Now().Add(-time.Hour).Unix())},
+			{Name: "data_ticket", Value: "xyz", Expires: float64(time.Now().Add(time.Hour).Unix())},
+		},
+	})
+
+	fakeID, err := client.SearchAccount(context.Background(), "测试")
+	if err != nil {
+		t.Fatalf("搜索公众号失败: %v", err)
+	}
+	if fakeID != "fake-1" {
+		t.Fatalf("FakeID不正确: %s", fakeID)
+	}
+	if gotToken != "t-123" {
+		t.Fatalf("token不正确: %s", gotToken)
+	}
+	if gotCookie != "slave_sid=abc; data_ticket=xyz" {
+		t.Fatalf("Cookie不正确: %s", gotCookie)
+	}
+}
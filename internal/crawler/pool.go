@@ -0,0 +1,389 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 会话分配策略
+const (
+	PoolStrategyShard      = "shard"       // 按公众号固定分配到同一会话，会话不可用时顺延
+	PoolStrategyRoundRobin = "round_robin" // 轮询所有可用会话
+)
+
+// PoolSession 会话池中的单个运营者登录会话
+// 每个会话拥有独立的Cookie文件、浏览器实例和限流器，互不影响
+type PoolSession struct {
+	Name    string
+	Source  ArticleSource
+	Limiter *RateLimiter
+
+	mu         sync.Mutex
+	requests   int
+	failures   int
+	lastError  string
+	lastUsedAt time.Time
+}
+
+// SessionHealth 会话健康状况（用于后台展示）
+type SessionHealth struct {
+	Name          string           `json:"name"`
+	LoggedIn      bool             `json:"logged_in"`
+	Expired       bool             `json:"expired"`      // 登录态已失效
+	Banned        bool             `json:"banned"`       // 触发频率限制，冷却中
+	PausedUntil   time.Time        `json:"paused_until"` // 冷却结束时间
+	Available     bool             `json:"available"`    // 当前是否可以分配请求
+	Requests      int              `json:"requests"`
+	Failures      int              `json:"failures"`
+	LastError     string           `json:"last_error"`
+	LastUsedAt    time.Time        `json:"last_used_at"`
+	Quota         []EndpointStatus `json:"quota"`          // 各接口最近一小时用量
+	SupportsLogin bool             `json:"supports_login"` // 是否支持管理后台扫码登录
+}
+
+// NewPoolSession 创建会话池成员
+func NewPoolSession(name string, source ArticleSource, limiter *RateLimiter) *PoolSession {
+	return &PoolSession{
+		Name:    name,
+		Source:  source,
+		Limiter: limiter,
+	}
+}
+
+// available 会话已登录、未失效且不在冷却期
+func (s *PoolSession) available() bool {
+	return s.Source.IsLoggedIn() && !s.Source.SessionStatus().Expired && s.Limiter.PausedUntil().IsZero()
+}
+
+// record 记录一次请求结果
+func (s *PoolSession) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.lastUsedAt = time.Now()
+	if err != nil {
+		s.failures++
+		s.lastError = err.Error()
+	}
+}
+
+// Health 获取会话健康状况
+func (s *PoolSession) Health() SessionHealth {
+	status := s.Source.SessionStatus()
+	rateLimit := s.Limiter.Status()
+	_, supportsLogin := s.Source.(QRLoginer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SessionHealth{
+		Name:          s.Name,
+		LoggedIn:      s.Source.IsLoggedIn(),
+		Expired:       status.Expired,
+		Banned:        rateLimit.Paused,
+		PausedUntil:   rateLimit.PausedUntil,
+		Available:     s.Source.IsLoggedIn() && !status.Expired && !rateLimit.Paused,
+		Requests:      s.requests,
+		Failures:      s.failures,
+		LastError:     s.lastError,
+		LastUsedAt:    s.lastUsedAt,
+		Quota:         rateLimit.Endpoints,
+		SupportsLogin: supportsLogin,
+	}
+}
+
+// SessionPool 多个运营者会话组成的数据源
+// 按策略为每个请求选择会话，跳过已失效或冷却中的会话；
+// 请求触发频率限制或登录态失效时自动切换到下一个会话重试
+type SessionPool struct {
+	sessions []*PoolSession
+	strategy string
+	next     atomic.Uint32
+}
+
+// NewSessionPool 创建会话池
+func NewSessionPool(strategy string, sessions ...*PoolSession) *SessionPool {
+	if strategy != PoolStrategyRoundRobin {
+		strategy = PoolStrategyShard
+	}
+	return &SessionPool{
+		sessions: sessions,
+		strategy: strategy,
+	}
+}
+
+// Session 根据名称获取会话，名称为空时返回第一个会话
+func (p *SessionPool) Session(name string) (*PoolSession, error) {
+	if len(p.sessions) == 0 {
+		return nil, fmt.Errorf("会话池为空")
+	}
+	if name == "" {
+		return p.sessions[0], nil
+	}
+	for _, s := range p.sessions {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("会话不存在: %s", name)
+}
+
+// Sessions 获取所有会话的健康状况
+func (p *SessionPool) Sessions() []SessionHealth {
+	health := make([]SessionHealth, 0, len(p.sessions))
+	for _, s := range p.sessions {
+		health = append(health, s.Health())
+	}
+	return health
+}
+
+// SearchAccount 搜索公众号并获取FakeID（轮询会话）
+func (p *SessionPool) SearchAccount(ctx context.Context, accountName string) (string, error) {
+	var fakeID string
+	err := p.do("", func(s *PoolSession) error {
+		var err error
+		fakeID, err = s.Source.SearchAccount(ctx, accountName)
+		return err
+	})
+	return fakeID, err
+}
+
+// FetchArticles 获取公众号最新的文章列表
+func (p *SessionPool) FetchArticles(ctx context.Context, fakeID string, count int) ([]*model.ArticleListItem, error) {
+	var items []*model.ArticleListItem
+	err := p.do(fakeID, func(s *PoolSession) error {
+		var err error
+		items, err = s.Source.FetchArticles(ctx, fakeID, count)
+		return err
+	})
+	return items, err
+}
+
+// FetchArticlePage 分页获取公众号文章列表
+func (p *SessionPool) FetchArticlePage(ctx context.Context, fakeID string, begin, count int) (*ArticlePage, error) {
+	var page *ArticlePage
+	err := p.do(fakeID, func(s *PoolSession) error {
+		var err error
+		page, err = s.Source.FetchArticlePage(ctx, fakeID, begin, count)
+		return err
+	})
+	return page, err
+}
+
+// FetchArticleContent 获取文章正文（轮询会话，分摊各浏览器的请求量）
+func (p *SessionPool) FetchArticleContent(ctx context.Context, articleURL string) (string, error) {
+	var content string
+	err := p.do("", func(s *PoolSession) error {
+		var err error
+		content, err = s.Source.FetchArticleContent(ctx, articleURL)
+		return err
+	})
+	return content, err
+}
+
+// FetchArticleSnapshot 获取文章正文并保存页面快照，会话的数据源不支持快照时只获取正文
+func (p *SessionPool) FetchArticleSnapshot(ctx context.Context, articleURL string, formats []string) (string, []*Snapshot, error) {
+	var content string
+	var snapshots []*Snapshot
+	err := p.do("", func(s *PoolSession) error {
+		var err error
+		if snapshotter, ok := s.Source.(Snapshotter); ok && len(formats) > 0 {
+			content, snapshots, err = snapshotter.FetchArticleSnapshot(ctx, articleURL, formats)
+		} else {
+			content, err = s.Source.FetchArticleContent(ctx, articleURL)
+		}
+		return err
+	})
+	return content, snapshots, err
+}
+
+// IsLoggedIn 是否至少有一个可用的已登录会话
+func (p *SessionPool) IsLoggedIn() bool {
+	for _, s := range p.sessions {
+		if s.Source.IsLoggedIn() {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionStatus 汇总登录态：没有任何有效会话且存在已失效会话时视为失效
+func (p *SessionPool) SessionStatus() SessionStatus {
+	var status SessionStatus
+	var reasons []string
+	valid := false
+
+	for _, s := range p.sessions {
+		st := s.Source.SessionStatus()
+		if st.Expired {
+			if st.ExpiredAt.After(status.ExpiredAt) {
+				status.ExpiredAt = st.ExpiredAt
+			}
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", s.Name, st.Reason))
+		} else if s.Source.IsLoggedIn() {
+			valid = true
+		}
+		if st.LoggedIn {
+			status.LoggedIn = true
+		}
+		if st.UpdatedAt.After(status.UpdatedAt) {
+			status.UpdatedAt = st.UpdatedAt
+		}
+	}
+
+	status.Expired = !valid && len(reasons) > 0
+	if status.Expired {
+		status.Reason = strings.Join(reasons, "; ")
+	} else {
+		status.ExpiredAt = time.Time{}
+	}
+	return status
+}
+
+// RateLimitStatus 汇总限流状态：所有已登录会话都在冷却中时视为暂停
+func (p *SessionPool) RateLimitStatus() RateLimitStatus {
+	status := RateLimitStatus{Backoff: 1}
+	endpoints := make(map[Endpoint]*EndpointStatus)
+	var order []Endpoint
+
+	active, paused := 0, 0
+	for _, s := range p.sessions {
+		st := s.Limiter.Status()
+		status.FreqControlCount += st.FreqControlCount
+		if st.LastFreqControlAt.After(status.LastFreqControlAt) {
+			status.LastFreqControlAt = st.LastFreqControlAt
+		}
+		if st.Backoff > status.Backoff {
+			status.Backoff = st.Backoff
+		}
+		for _, e := range st.Endpoints {
+			if _, ok := endpoints[e.Endpoint]; !ok {
+				endpoints[e.Endpoint] = &EndpointStatus{Endpoint: e.Endpoint}
+				order = append(order, e.Endpoint)
+			}
+			endpoints[e.Endpoint].UsedLastHour += e.UsedLastHour
+			endpoints[e.Endpoint].MaxPerHour += e.MaxPerHour
+		}
+
+		if !s.Source.IsLoggedIn() || s.Source.SessionStatus().Expired {
+			continue
+		}
+		active++
+		if st.Paused {
+			paused++
+			if status.PausedUntil.IsZero() || st.PausedUntil.Before(status.PausedUntil) {
+				status.PausedUntil = st.PausedUntil
+				status.Reason = fmt.Sprintf("[%s] %s", s.Name, st.Reason)
+			}
+		}
+	}
+
+	status.Paused = active > 0 && paused == active
+	if !status.Paused {
+		status.PausedUntil = time.Time{}
+		status.Reason = ""
+	}
+	for _, e := range order {
+		status.Endpoints = append(status.Endpoints, *endpoints[e])
+	}
+	return status
+}
+
+// PausedUntil 所有会话都在冷却中时返回最早恢复的时间，否则返回零值
+func (p *SessionPool) PausedUntil() time.Time {
+	return p.RateLimitStatus().PausedUntil
+}
+
+// Resume 手动解除所有会话的频率限制暂停
+func (p *SessionPool) Resume() {
+	for _, s := range p.sessions {
+		s.Limiter.Resume()
+	}
+}
+
+// SetTimeout 修改所有会话的单次请求超时时间
+func (p *SessionPool) SetTimeout(timeout time.Duration) {
+	for _, s := range p.sessions {
+		s.Source.SetTimeout(timeout)
+	}
+}
+
+// Close 关闭所有会话
+func (p *SessionPool) Close() {
+	for _, s := range p.sessions {
+		s.Source.Close()
+	}
+}
+
+// do 按分配策略依次尝试可用会话
+// 触发频率限制或登录态失效时切换到下一个会话，其他错误直接返回
+func (p *SessionPool) do(key string, fn func(s *PoolSession) error) error {
+	var lastErr error
+	tried := 0
+
+	for _, s := range p.order(key) {
+		if !s.available() {
+			continue
+		}
+		tried++
+
+		err := fn(s)
+		s.record(err)
+		if err == nil {
+			return nil
+		}
+		if !IsFreqControl(err) && !IsSessionExpired(err) {
+			return err
+		}
+
+		lastErr = err
+		logger.Warn("会话不可用，切换到下一个会话", zap.String("session", s.Name), zap.Error(err))
+	}
+
+	if tried == 0 {
+		return p.unavailableError()
+	}
+	return lastErr
+}
+
+// order 根据分配策略返回会话的尝试顺序
+func (p *SessionPool) order(key string) []*PoolSession {
+	n := len(p.sessions)
+	if n == 0 {
+		return nil
+	}
+
+	var start int
+	if p.strategy == PoolStrategyShard && key != "" {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		start = int(h.Sum32() % uint32(n))
+	} else {
+		start = int(p.next.Add(1) % uint32(n))
+	}
+
+	ordered := make([]*PoolSession, 0, n)
+	for i := 0; i < n; i++ {
+		ordered = append(ordered, p.sessions[(start+i)%n])
+	}
+	return ordered
+}
+
+// unavailableError 没有可用会话时的错误：有会话在冷却中时返回暂停错误，否则视为登录态失效
+func (p *SessionPool) unavailableError() error {
+	if until := p.PausedUntil(); !until.IsZero() {
+		return fmt.Errorf("%w，所有会话均在冷却中，预计 %s 恢复", ErrCrawlerPaused, until.Format("15:04:05"))
+	}
+	return fmt.Errorf("%w（没有可用的会话）", ErrSessionExpired)
+}
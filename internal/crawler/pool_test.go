@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// 验证会话失效时自动切换到其他会话，并在健康状况中标记
+func TestSessionPoolSkipsExpiredSession(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	if err := fakeServer.AddArticle(account.FakeID, &FakeArticle{Title: "第一篇", Content: "<p>正文</p>"}); err != nil {
+		t.Fatalf("添加模拟文章失败: %v", err)
+	}
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	pool := NewSessionPool(PoolStrategyRoundRobin,
+		NewPoolSession("bad", NewFakeSource(server.URL, "wrong-token", 5, nil), nil),
+		NewPoolSession("good", NewFakeSource(server.URL, "test-token", 5, nil), nil),
+	)
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.FetchArticles(context.Background(), account.FakeID, 10); err != nil {
+			t.Fatalf("第%d次获取文章列表失败: %v", i+1, err)
+		}
+	}
+
+	health := pool.Sessions()
+	if !health[0].Expired || health[0].Available || health[0].Requests != 1 {
+		t.Fatalf("失效会话状态不正确: %+v", health[0])
+	}
+	if !health[1].Available || health[1].Requests != 4 {
+		t.Fatalf("有效会话状态不正确: %+v", health[1])
+	}
+	if status := pool.SessionStatus(); status.Expired {
+		t.Fatalf("仍有可用会话时不应视为失效: %+v", status)
+	}
+}
+
+// 验证所有会话都触发频率限制后整体暂停，解除后恢复
+func TestSessionPoolAllSessionsPaused(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	fakeServer.SetFreqControl(true)
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	var sessions []*PoolSession
+	for _, name := range []string{"a", "b"} {
+		limiter := NewRateLimiter(RateLimitConfig{})
+		sessions = append(sessions, NewPoolSession(name, NewFakeSource(server.URL, "test-token", 5, limiter), limiter))
+	}
+	pool := NewSessionPool(PoolStrategyShard, sessions...)
+
+	if _, err := pool.FetchArticles(context.Background(), account.FakeID, 10); !IsFreqControl(err) {
+		t.Fatalf("期望返回频率限制错误, got %v", err)
+	}
+	if status := pool.RateLimitStatus(); !status.Paused || status.FreqControlCount != 2 {
+		t.Fatalf("所有会话冷却时应整体暂停: %+v", status)
+	}
+	if _, err := pool.FetchArticles(context.Background(), account.FakeID, 10); !errors.Is(err, ErrCrawlerPaused) {
+		t.Fatalf("期望返回 ErrCrawlerPaused, got %v", err)
+	}
+
+	fakeServer.SetFreqControl(false)
+	pool.Resume()
+	if _, err := pool.FetchArticles(context.Background(), account.FakeID, 10); err != nil {
+		t.Fatalf("解除暂停后获取文章列表失败: %v", err)
+	}
+}
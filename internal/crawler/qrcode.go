@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// qrQuietZone 字符画二维码四周留白的模块数
+const qrQuietZone = 2
+
+// logQRCodeASCII 将二维码截图转换为字符画输出到日志，便于在无显示器的服务器上扫码
+func logQRCodeASCII(data []byte) {
+	art, err := renderQRCodeASCII(data)
+	if err != nil {
+		logger.Warn("二维码转换为字符画失败，请在管理后台扫码登录", zap.Error(err))
+		return
+	}
+	logger.Info("请使用微信扫描以下二维码登录公众号平台\n" + art)
+}
+
+// renderQRCodeASCII 将二维码PNG截图还原为模块矩阵并渲染为字符画
+// 使用半高方块字符，每行字符表示两行模块；亮色模块绘制为方块，适合深色背景的终端
+func renderQRCodeASCII(data []byte) (string, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("解析二维码图片失败: %w", err)
+	}
+
+	modules, err := sampleQRModules(img)
+	if err != nil {
+		return "", err
+	}
+
+	size := len(modules)
+	total := size + qrQuietZone*2
+	light := func(row, col int) bool {
+		row -= qrQuietZone
+		col -= qrQuietZone
+		if row < 0 || col < 0 || row >= size || col >= size {
+			return true
+		}
+		return !modules[row][col]
+	}
+
+	var sb strings.Builder
+	for row := 0; row < total; row += 2 {
+		for col := 0; col < total; col++ {
+			top, bottom := light(row, col), light(row+1, col)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// sampleQRModules 根据左上角定位图案（7个模块宽）估算模块大小，并在每个模块中心取样
+func sampleQRModules(img image.Image) ([][]bool, error) {
+	bounds := img.Bounds()
+	dark := func(x, y int) bool {
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y < 128
+	}
+
+	// 暗色像素的包围盒即二维码区域
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, -1, -1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if dark(x, y) {
+				minX = min(minX, x)
+				minY = min(minY, y)
+				maxX = max(maxX, x)
+				maxY = max(maxY, y)
+			}
+		}
+	}
+	if maxX < 0 {
+		return nil, fmt.Errorf("图片中未识别到二维码")
+	}
+
+	run := 0
+	for x := minX; x <= maxX && dark(x, minY); x++ {
+		run++
+	}
+	moduleSize := float64(run) / 7
+	if moduleSize < 1 {
+		return nil, fmt.Errorf("未识别到二维码定位图案")
+	}
+
+	size := int(math.Round(float64(maxX-minX+1) / moduleSize))
+	if size < 21 || size > 177 {
+		return nil, fmt.Errorf("二维码尺寸异常: %d", size)
+	}
+
+	modules := make([][]bool, size)
+	for row := range modules {
+		modules[row] = make([]bool, size)
+		for col := range modules[row] {
+			x := minX + int((float64(col)+0.5)*moduleSize)
+			y := minY + int((float64(row)+0.5)*moduleSize)
+			modules[row][col] = dark(x, y)
+		}
+	}
+	return modules, nil
+}
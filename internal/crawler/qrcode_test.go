@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// 验证二维码截图能还原为模块矩阵并渲染为字符画
+func TestRenderQRCodeASCII(t *testing.T) {
+	const size, scale, margin = 21, 6, 30
+
+	// 构造带三个定位图案和若干数据模块的矩阵
+	modules := make([][]bool, size)
+	for i := range modules {
+		modules[i] = make([]bool, size)
+	}
+	finder := func(top, left int) {
+		for r := 0; r < 7; r++ {
+			for c := 0; c < 7; c++ {
+				ring := r == 0 || r == 6 || c == 0 || c == 6
+				center := r >= 2 && r <= 4 && c >= 2 && c <= 4
+				modules[top+r][left+c] = ring || center
+			}
+		}
+	}
+	finder(0, 0)
+	finder(0, size-7)
+	finder(size-7, 0)
+	for i := 8; i < size; i += 3 {
+		modules[i][i] = true
+		modules[10][i-1] = true
+	}
+
+	img := image.NewGray(image.Rect(0, 0, size*scale+margin*2, size*scale+margin*2))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			if !modules[r][c] {
+				continue
+			}
+			for y := 0; y < scale; y++ {
+				for x := 0; x < scale; x++ {
+					img.SetGray(margin+c*scale+x, margin+r*scale+y, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("生成测试图片失败: %v", err)
+	}
+
+	art, err := renderQRCodeASCII(buf.Bytes())
+	if err != nil {
+		t.Fatalf("渲染字符画失败: %v", err)
+	}
+
+	total := size + qrQuietZone*2
+	lines := strings.Split(strings.TrimSuffix(art, "\n"), "\n")
+	if len(lines) != (total+1)/2 {
+		t.Fatalf("行数不正确: got %d, want %d", len(lines), (total+1)/2)
+	}
+
+	// 从字符画反推模块，与原矩阵比对
+	for i, line := range lines {
+		cells := []rune(line)
+		if len(cells) != total {
+			t.Fatalf("第%d行宽度不正确: got %d, want %d", i, len(cells), total)
+		}
+		for col, ch := range cells {
+			top := ch == '█' || ch == '▀'
+			bottom := ch == '█' || ch == '▄'
+			for k, isLight := range []bool{top, bottom} {
+				r, c := i*2+k-qrQuietZone, col-qrQuietZone
+				want := true
+				if r >= 0 && c >= 0 && r < size && c < size {
+					want = !modules[r][c]
+				}
+				if i*2+k < total && isLight != want {
+					t.Fatalf("模块(%d,%d)不一致", r, c)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Endpoint 请求的接口类别，每类接口单独计算频率预算
+type Endpoint string
+
+const (
+	EndpointSearch  Endpoint = "search"  // 搜索公众号 searchbiz
+	EndpointList    Endpoint = "list"    // 文章列表 appmsg
+	EndpointArticle Endpoint = "article" // 文章正文页面
+)
+
+// ErrCrawlerPaused 触发微信频率限制后处于冷却期，所有请求直接失败
+var ErrCrawlerPaused = errors.New("触发微信频率限制，爬虫暂停中")
+
+// EndpointBudget 单类接口的频率预算
+type EndpointBudget struct {
+	Interval   time.Duration // 两次请求的最小间隔
+	Jitter     time.Duration // 在最小间隔上追加的随机抖动上限
+	MaxPerHour int           // 每小时最多请求次数，0表示不限制
+}
+
+// RateLimitConfig 限流器配置
+type RateLimitConfig struct {
+	Budgets    map[Endpoint]EndpointBudget
+	Cooldown   time.Duration // 触发频率限制后整体暂停的时长
+	MaxBackoff float64       // 自适应放慢的最大倍数
+}
+
+// RateLimitStatus 限流器当前状态（用于后台展示）
+type RateLimitStatus struct {
+	Paused            bool             `json:"paused"`
+	PausedUntil       time.Time        `json:"paused_until"`
+	Reason            string           `json:"reason"`
+	Backoff           float64          `json:"backoff"`            // 当前请求间隔放慢倍数
+	FreqControlCount  int              `json:"freq_control_count"` // 累计触发频率限制次数
+	LastFreqControlAt time.Time        `json:"last_freq_control_at"`
+	Endpoints         []EndpointStatus `json:"endpoints"`
+}
+
+// EndpointStatus 单类接口最近一小时的用量
+type EndpointStatus struct {
+	Endpoint     Endpoint `json:"endpoint"`
+	UsedLastHour int      `json:"used_last_hour"`
+	MaxPerHour   int      `json:"max_per_hour"`
+}
+
+// RateLimiter 所有微信请求共享的自适应限流器
+// 每类接口独立的间隔+随机抖动+小时预算；识别到频率限制时整体暂停一段冷却期，
+// 并放慢后续请求节奏，请求成功后逐步恢复
+type RateLimiter struct {
+	cfg         RateLimitConfig
+	mu          sync.Mutex
+	endpoints   map[Endpoint]*endpointState
+	backoff     float64
+	pausedUntil time.Time
+	reason      string
+	freqCount   int
+	lastFreqAt  time.Time
+}
+
+// endpointState 单类接口的请求记录
+type endpointState struct {
+	next    time.Time   // 下一次允许请求的时间
+	history []time.Time // 最近一小时的请求时间
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.MaxBackoff < 1 {
+		cfg.MaxBackoff = 1
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 10 * time.Minute
+	}
+	return &RateLimiter{
+		cfg:       cfg,
+		endpoints: make(map[Endpoint]*endpointState),
+		backoff:   1,
+	}
+}
+
+// Wait 等待直到允许请求指定接口
+// 处于冷却期时立即返回 ErrCrawlerPaused，不会阻塞到冷却结束
+func (l *RateLimiter) Wait(ctx context.Context, endpoint Endpoint) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if now.Before(l.pausedUntil) {
+			until := l.pausedUntil
+			l.mu.Unlock()
+			return fmt.Errorf("%w，预计 %s 恢复", ErrCrawlerPaused, until.Format("15:04:05"))
+		}
+
+		budget := l.cfg.Budgets[endpoint]
+		state := l.state(endpoint, now)
+
+		var wait time.Duration
+		if state.next.After(now) {
+			wait = state.next.Sub(now)
+		}
+		if budget.MaxPerHour > 0 && len(state.history) >= budget.MaxPerHour {
+			if d := state.history[0].Add(time.Hour).Sub(now); d > wait {
+				wait = d
+			}
+		}
+
+		if wait <= 0 {
+			state.history = append(state.history, now)
+			interval := time.Duration(float64(budget.Interval) * l.backoff)
+			if budget.Jitter > 0 {
+				interval += time.Duration(rand.Int63n(int64(budget.Jitter)))
+			}
+			state.next = now.Add(interval)
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ReportSuccess 记录一次成功请求，逐步恢复请求节奏
+func (l *RateLimiter) ReportSuccess() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.backoff > 1 {
+		l.backoff *= 0.9
+		if l.backoff < 1 {
+			l.backoff = 1
+		}
+	}
+}
+
+// ReportFreqControl 记录触发频率限制：整体暂停一个冷却期，并放慢后续请求
+func (l *RateLimiter) ReportFreqControl(reason string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.pausedUntil = now.Add(l.cfg.Cooldown)
+	l.reason = reason
+	l.freqCount++
+	l.lastFreqAt = now
+	l.backoff *= 2
+	if l.backoff > l.cfg.MaxBackoff {
+		l.backoff = l.cfg.MaxBackoff
+	}
+
+	logger.Warn("触发微信频率限制，爬虫暂停",
+		zap.String("reason", reason),
+		zap.Time("paused_until", l.pausedUntil),
+		zap.Float64("backoff", l.backoff))
+}
+
+// Resume 手动解除暂停
+func (l *RateLimiter) Resume() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pausedUntil = time.Time{}
+	l.reason = ""
+	logger.Info("已手动解除频率限制暂停")
+}
+
+// PausedUntil 冷却结束时间，未暂停时返回零值
+func (l *RateLimiter) PausedUntil() time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Now().Before(l.pausedUntil) {
+		return l.pausedUntil
+	}
+	return time.Time{}
+}
+
+// Status 获取限流器当前状态
+func (l *RateLimiter) Status() RateLimitStatus {
+	if l == nil {
+		return RateLimitStatus{Backoff: 1}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	status := RateLimitStatus{
+		Paused:            now.Before(l.pausedUntil),
+		Backoff:           l.backoff,
+		FreqControlCount:  l.freqCount,
+		LastFreqControlAt: l.lastFreqAt,
+	}
+	if status.Paused {
+		status.PausedUntil = l.pausedUntil
+		status.Reason = l.reason
+	}
+
+	for _, endpoint := range []Endpoint{EndpointSearch, EndpointList, EndpointArticle} {
+		status.Endpoints = append(status.Endpoints, EndpointStatus{
+			Endpoint:     endpoint,
+			UsedLastHour: len(l.state(endpoint, now).history),
+			MaxPerHour:   l.cfg.Budgets[endpoint].MaxPerHour,
+		})
+	}
+	return status
+}
+
+// state 获取接口的请求记录并清理一小时前的历史（调用方需持有锁）
+func (l *RateLimiter) state(endpoint Endpoint, now time.Time) *endpointState {
+	state, ok := l.endpoints[endpoint]
+	if !ok {
+		state = &endpointState{}
+		l.endpoints[endpoint] = state
+	}
+
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(state.history) && !state.history[i].After(cutoff) {
+		i++
+	}
+	state.history = state.history[i:]
+	return state
+}
+
+// isFreqControlPage 根据页面标题判断文章页是否被频率限制拦截（环境异常/访问过于频繁）
+func isFreqControlPage(title string) bool {
+	return strings.Contains(title, "环境异常") || strings.Contains(title, "访问过于频繁")
+}
+
+// IsFreqControl 判断错误是否由微信频率限制引起
+func IsFreqControl(err error) bool {
+	if errors.Is(err, ErrCrawlerPaused) {
+		return true
+	}
+	var mpErr *MPError
+	return errors.As(err, &mpErr) && mpErr.Ret == RetFreqControl
+}
@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 验证同一接口的请求间隔不小于预算
+func TestRateLimiterInterval(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{
+		Budgets: map[Endpoint]EndpointBudget{
+			EndpointList: {Interval: 50 * time.Millisecond},
+		},
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background(), EndpointList); err != nil {
+			t.Fatalf("等待失败: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("请求间隔过短: %v", elapsed)
+	}
+
+	// 其他接口不受影响
+	start = time.Now()
+	if err := limiter.Wait(context.Background(), EndpointSearch); err != nil {
+		t.Fatalf("等待失败: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("不同接口的预算互相影响: %v", elapsed)
+	}
+}
+
+// 验证模拟后台返回 freq control 后整个爬虫进入冷却期
+func TestFreqControlPausesCrawler(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	fakeServer.SetFreqControl(true)
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimitConfig{Cooldown: time.Minute, MaxBackoff: 4})
+	source := NewFakeSource(server.URL, "test-token", 5, limiter)
+
+	_, err := source.FetchArticlePage(context.Background(), account.FakeID, 0, 5)
+	var mpErr *MPError
+	if !errors.As(err, &mpErr) || mpErr.Ret != RetFreqControl || !IsFreqControl(err) {
+		t.Fatalf("期望返回频率限制错误, got %v", err)
+	}
+
+	status := limiter.Status()
+	if !status.Paused || status.FreqControlCount != 1 || status.Backoff != 2 {
+		t.Fatalf("限流器状态不正确: %+v", status)
+	}
+
+	// 冷却期内的请求不再发往后台
+	fakeServer.SetFreqControl(false)
+	if _, err := source.SearchAccount(context.Background(), "测试公众号"); !errors.Is(err, ErrCrawlerPaused) {
+		t.Fatalf("冷却期内期望返回 ErrCrawlerPaused, got %v", err)
+	}
+
+	limiter.Resume()
+	if _, err := source.SearchAccount(context.Background(), "测试公众号"); err != nil {
+		t.Fatalf("解除暂停后搜索失败: %v", err)
+	}
+}
+
+// 验证取消爬取任务的context会中断限流器等待
+func TestFetchCancelsRateLimitWait(t *testing.T) {
+	fakeServer := NewFakeMPServer("test-token")
+	account := fakeServer.AddAccount("测试公众号", "test")
+	if err := fakeServer.AddArticle(account.FakeID, &FakeArticle{Title: "文章", Content: "<p>正文</p>"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimitConfig{
+		Budgets: map[Endpoint]EndpointBudget{
+			EndpointList:    {Interval: time.Hour},
+			EndpointArticle: {Interval: time.Hour},
+		},
+	})
+	source := NewFakeSource(server.URL, "test-token", 5, limiter)
+
+	items, err := source.FetchArticles(context.Background(), account.FakeID, 5)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+	if _, err := source.FetchArticleContent(context.Background(), items[0].ContentURL); err != nil {
+		t.Fatalf("获取文章正文失败: %v", err)
+	}
+
+	// 下一次请求需要等待一小时，取消后立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := source.FetchArticleContent(ctx, items[0].ContentURL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望返回 context.DeadlineExceeded, got %v", err)
+	}
+	if _, err := source.FetchArticlePage(ctx, account.FakeID, 0, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望返回 context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("取消后仍在等待: %v", elapsed)
+	}
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSessionExpired 公众号后台登录态已失效（ret=200003），需要重新扫码登录
+var ErrSessionExpired = errors.New("公众号登录态已失效，请重新扫码登录")
+
+// SessionStatus 公众号后台登录态
+type SessionStatus struct {
+	LoggedIn  bool      `json:"logged_in"`  // 是否持有token
+	Expired   bool      `json:"expired"`    // 是否已检测到登录态失效
+	ExpiredAt time.Time `json:"expired_at"` // 检测到失效的时间
+	Reason    string    `json:"reason"`     // 失效原因
+	UpdatedAt time.Time `json:"updated_at"` // 最近一次设置会话的时间
+}
+
+// 网页扫码登录状态
+const (
+	QRLoginIdle    = "idle"    // 未开始
+	QRLoginWaiting = "waiting" // 等待扫码
+	QRLoginSuccess = "success" // 登录成功
+	QRLoginFailed  = "failed"  // 登录失败或超时
+)
+
+// QRLoginState 网页扫码登录进度
+type QRLoginState struct {
+	Status    string    `json:"status"`
+	QRCode    []byte    `json:"-"` // 登录二维码截图（PNG），找不到二维码元素时为整页截图
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QRLoginer 支持在管理后台扫码重新登录的数据源
+type QRLoginer interface {
+	// StartQRLogin 打开登录页并在后台等待扫码，立即返回
+	StartQRLogin() error
+	// QRLoginState 获取当前扫码登录进度
+	QRLoginState() QRLoginState
+}
+
+// IsSessionExpired 判断错误是否由登录态失效引起
+func IsSessionExpired(err error) bool {
+	if errors.Is(err, ErrSessionExpired) {
+		return true
+	}
+	var mpErr *MPError
+	return errors.As(err, &mpErr) && mpErr.Ret == RetInvalidSession
+}
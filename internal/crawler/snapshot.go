@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// 页面快照格式
+const (
+	SnapshotMHTML      = "mhtml"      // 单文件网页（Page.captureSnapshot），包含页面引用的图片和样式
+	SnapshotPDF        = "pdf"        // 打印为PDF（Page.printToPDF）
+	SnapshotScreenshot = "screenshot" // 整页截图（PNG）
+)
+
+// snapshotTypes 各快照格式的文件类型和扩展名
+var snapshotTypes = map[string]struct{ contentType, ext string }{
+	SnapshotMHTML:      {"multipart/related", ".mhtml"},
+	SnapshotPDF:        {"application/pdf", ".pdf"},
+	SnapshotScreenshot: {"image/png", ".png"},
+}
+
+// Snapshot 文章页面快照
+type Snapshot struct {
+	Format string // mhtml/pdf/screenshot
+	Data   []byte
+}
+
+// ContentType 快照的文件类型
+func (s *Snapshot) ContentType() string {
+	return snapshotTypes[s.Format].contentType
+}
+
+// Ext 快照文件的扩展名
+func (s *Snapshot) Ext() string {
+	return snapshotTypes[s.Format].ext
+}
+
+// Snapshotter 支持在获取正文的同时保存页面快照的数据源
+type Snapshotter interface {
+	// FetchArticleSnapshot 获取文章正文并按 formats 保存页面快照
+	// 快照失败不影响正文，只返回成功的快照
+	FetchArticleSnapshot(ctx context.Context, articleURL string, formats []string) (string, []*Snapshot, error)
+}
+
+// ParseSnapshotFormats 检查快照格式并去重
+func ParseSnapshotFormats(formats []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" || seen[format] {
+			continue
+		}
+		if _, ok := snapshotTypes[format]; !ok {
+			return nil, fmt.Errorf("不支持的快照格式: %s（可选 mhtml、pdf、screenshot）", format)
+		}
+		seen[format] = true
+		result = append(result, format)
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 验证快照格式忽略大小写和重复项，不支持的格式报错
+func TestParseSnapshotFormats(t *testing.T) {
+	got, err := ParseSnapshotFormats([]string{"PDF", " mhtml ", "pdf", "", "screenshot"})
+	if err != nil {
+		t.Fatalf("ParseSnapshotFormats: %v", err)
+	}
+	want := []string{SnapshotPDF, SnapshotMHTML, SnapshotScreenshot}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := ParseSnapshotFormats([]string{"png"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+
+	snapshot := &Snapshot{Format: SnapshotScreenshot}
+	if snapshot.ContentType() != "image/png" || snapshot.Ext() != ".png" {
+		t.Errorf("screenshot: got %q %q", snapshot.ContentType(), snapshot.Ext())
+	}
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"wechat-crawler/internal/model"
+)
+
+// ArticleSource 文章数据源接口
+// 屏蔽底层采集方式（chromedp浏览器 / 本地模拟后台），便于业务层测试和离线演示
+// 请求类方法的 ctx 用于取消限流等待和请求本身，爬取任务被取消时立即返回
+type ArticleSource interface {
+	// SearchAccount 搜索公众号并返回FakeID
+	SearchAccount(ctx context.Context, accountName string) (string, error)
+
+	// FetchArticles 获取公众号最新的文章列表
+	FetchArticles(ctx context.Context, fakeID string, count int) ([]*model.ArticleListItem, error)
+
+	// FetchArticlePage 分页获取公众号文章列表，用于历史文章回溯
+	FetchArticlePage(ctx context.Context, fakeID string, begin, count int) (*ArticlePage, error)
+
+	// FetchArticleContent 获取文章正文HTML（#js_content）
+	FetchArticleContent(ctx context.Context, articleURL string) (string, error)
+
+	// IsLoggedIn 是否已登录公众号平台（持有可用的token）
+	IsLoggedIn() bool
+
+	// SessionStatus 获取登录态（是否已检测到失效）
+	SessionStatus() SessionStatus
+
+	// SetTimeout 修改单次请求的超时时间，立即生效
+	SetTimeout(timeout time.Duration)
+
+	// Close 释放数据源占用的资源
+	Close()
+}
+
+// ArticlePage 文章列表分页结果
+type ArticlePage struct {
+	Items []*model.ArticleListItem // 当前页文章
+	Total int                      // 公众号文章总数（app_msg_cnt）
+}
+
+// 编译期检查各实现是否满足接口
+var (
+	_ ArticleSource = (*Browser)(nil)
+	_ ArticleSource = (*FakeSource)(nil)
+	_ QRLoginer     = (*Browser)(nil)
+	_ Snapshotter   = (*Browser)(nil)
+)
@@ -6,6 +6,82 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// 文章正文获取状态
+const (
+	ArticleFetchSuccess = "success" // 正文已获取
+	ArticleFetchPending = "pending" // 获取失败（如网络超时），等待后台重试
+	ArticleFetchFailed  = "failed"  // 重试次数用尽或文章已删除，不再重试
+)
+
+// 文章在线状态，采集后由复查任务定期检查
+const (
+	ArticleStatusLive    = "live"    // 正常可访问（未复查过的文章状态为空，视为正常）
+	ArticleStatusDeleted = "deleted" // 已被发布者删除
+	ArticleStatusBlocked = "blocked" // 因违规、投诉等被平台屏蔽
+	// ArticleStatusRemoved 仅用于筛选，表示已删除或已屏蔽
+	ArticleStatusRemoved = "removed"
+)
+
+// 文章阅读状态筛选，按当前用户区分
+const (
+	ArticleRead   = "read"   // 已读
+	ArticleUnread = "unread" // 未读
+)
+
+// ArticleStatusChange 文章在线状态的一次变化
+type ArticleStatusChange struct {
+	From   string    `bson:"from" json:"from"`
+	To     string    `bson:"to" json:"to"`
+	Reason string    `bson:"reason" json:"reason"` // 检查时页面返回的提示
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// ArticleSnapshot 采集时保存的页面快照，文件保存在文件存储中
+type ArticleSnapshot struct {
+	Format      string    `bson:"format" json:"format"`             // mhtml/pdf/screenshot
+	Key         string    `bson:"key" json:"key"`                   // 在文件存储中的路径
+	URL         string    `bson:"url" json:"url"`                   // 下载地址（/media/...）
+	ContentType string    `bson:"content_type" json:"content_type"` // 文件类型
+	Size        int64     `bson:"size" json:"size"`                 // 文件大小（字节）
+	CapturedAt  time.Time `bson:"captured_at" json:"captured_at"`   // 保存时间
+}
+
+// ArticleLink 正文中的链接
+type ArticleLink struct {
+	Text string `bson:"text" json:"text"`
+	URL  string `bson:"url" json:"url"`
+}
+
+// ArticleHeading 正文中的标题
+type ArticleHeading struct {
+	Level int    `bson:"level" json:"level"` // 1-6
+	Text  string `bson:"text" json:"text"`
+}
+
+// ArticleMeta 从正文提取的统计信息
+type ArticleMeta struct {
+	WordCount      int              `bson:"word_count" json:"word_count"`           // 字数，中文按字、英文按单词计
+	ImageCount     int              `bson:"image_count" json:"image_count"`         // 图片数量
+	Images         []string         `bson:"images" json:"images"`                   // 图片地址
+	Links          []ArticleLink    `bson:"links" json:"links"`                     // 链接
+	Headings       []ArticleHeading `bson:"headings" json:"headings"`               // 标题
+	ReadingMinutes int              `bson:"reading_minutes" json:"reading_minutes"` // 预计阅读时长（分钟）
+}
+
+// ArticleExtract 由正文HTML生成的内容，正文变化时重新生成
+type ArticleExtract struct {
+	CleanHTML string       `bson:"clean_html,omitempty" json:"clean_html,omitempty"` // 清洗后的正文HTML（去掉内联样式，图片使用真实地址）
+	Markdown  string       `bson:"markdown,omitempty" json:"markdown,omitempty"`     // 正文Markdown
+	Text      string       `bson:"text,omitempty" json:"text,omitempty"`             // 正文纯文本
+	Meta      *ArticleMeta `bson:"meta,omitempty" json:"meta,omitempty"`             // 正文统计信息，正文缺失或尚未处理时为空
+
+	LocalCover      string    `bson:"local_cover,omitempty" json:"local_cover,omitempty"`             // 归档后的封面地址（/media/...），未启用图片归档或归档失败时为空
+	MediaArchivedAt time.Time `bson:"media_archived_at,omitempty" json:"media_archived_at,omitempty"` // 最近一次归档图片的时间
+	MediaFailed     int       `bson:"media_failed,omitempty" json:"media_failed,omitempty"`           // 归档失败的图片数（含封面），失败的图片保留原地址
+
+	SearchTokens []string `bson:"search_tokens,omitempty" json:"-"` // 标题、作者、摘要和正文的检索词，用于全文搜索
+}
+
 // Article 微信公众号文章
 type Article struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -14,12 +90,87 @@ type Article struct {
 	Title       string             `bson:"title" json:"title"`               // 文章标题
 	Author      string             `bson:"author" json:"author"`             // 作者
 	Digest      string             `bson:"digest" json:"digest"`             // 文章摘要
-	Content     string             `bson:"content" json:"content"`           // 文章内容（HTML）
+	Content     string             `bson:"content" json:"content"`           // 文章内容（原始HTML）
 	ContentURL  string             `bson:"content_url" json:"content_url"`   // 文章原始URL
+	URLKey      string             `bson:"url_key,omitempty" json:"-"`       // 规范化的文章链接，用于去重，见 ArticleURLKey
 	Cover       string             `bson:"cover" json:"cover"`               // 封面图片URL
 	SourceURL   string             `bson:"source_url" json:"source_url"`     // 原文链接
 	PublishTime int64              `bson:"publish_time" json:"publish_time"` // 发布时间（时间戳）
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`     // 采集时间
+
+	FetchStatus   string    `bson:"fetch_status" json:"fetch_status"`     // 正文获取状态：success/pending/failed
+	FetchAttempts int       `bson:"fetch_attempts" json:"fetch_attempts"` // 正文获取次数
+	FetchError    string    `bson:"fetch_error" json:"fetch_error"`       // 最近一次获取失败的原因
+	NextFetchAt   time.Time `bson:"next_fetch_at" json:"next_fetch_at"`   // 下次重试时间
+
+	Status          string                `bson:"status,omitempty" json:"status"`                       // 在线状态：live/deleted/blocked，为空表示未复查过
+	StatusChangedAt time.Time             `bson:"status_changed_at,omitempty" json:"status_changed_at"` // 最近一次状态变化的时间
+	LastCheckedAt   time.Time             `bson:"last_checked_at,omitempty" json:"last_checked_at"`     // 最近一次复查时间
+	StatusHistory   []ArticleStatusChange `bson:"status_history,omitempty" json:"status_history"`       // 状态变化记录
+
+	Revisions        int       `bson:"revisions,omitempty" json:"revisions"`                   // 正文版本数，0表示未发现修改
+	ContentChangedAt time.Time `bson:"content_changed_at,omitempty" json:"content_changed_at"` // 最近一次发现正文修改的时间
+
+	Snapshots []ArticleSnapshot `bson:"snapshots,omitempty" json:"snapshots,omitempty"` // 页面快照（MHTML、PDF、截图），未启用快照时为空
+
+	ReadBy    []string `bson:"read_by,omitempty" json:"-"`           // 已读的管理员用户名，不对外输出
+	StarredBy []string `bson:"starred_by,omitempty" json:"-"`        // 加星标的管理员用户名，不对外输出
+	Tags      []string `bson:"tags,omitempty" json:"tags,omitempty"` // 标签，所有管理员共用
+
+	ArticleExtract `bson:",inline"` // 由正文生成的清洗HTML、Markdown、纯文本和统计信息
+}
+
+// CurrentStatus 文章当前的在线状态，未复查过的文章视为正常
+func (a *Article) CurrentStatus() string {
+	if a.Status == "" {
+		return ArticleStatusLive
+	}
+	return a.Status
+}
+
+// IsGone 文章是否已删除或被屏蔽
+func (a *Article) IsGone() bool {
+	return a.Status == ArticleStatusDeleted || a.Status == ArticleStatusBlocked
+}
+
+// IsReadBy 用户是否已读
+func (a *Article) IsReadBy(user string) bool {
+	return containsString(a.ReadBy, user)
+}
+
+// IsStarredBy 用户是否加了星标
+func (a *Article) IsStarredBy(user string) bool {
+	return containsString(a.StarredBy, user)
+}
+
+// containsString 切片中是否包含 s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ArticleFilter 文章列表的筛选条件，零值表示不限制
+type ArticleFilter struct {
+	AccountID      string // 公众号ID
+	Keyword        string // 全文搜索条件（标题、作者、摘要和正文），语法见 pkg/search
+	StartTime      int64  // 发布时间起（时间戳）
+	EndTime        int64  // 发布时间止（时间戳）
+	MissingContent bool   // 只看正文缺失的文章
+	Status         string // 在线状态，live 包含未复查过的文章，removed 表示已删除或已屏蔽
+	Tag            string // 标签
+	User           string // 当前用户，Read 和 Starred 按该用户筛选，为空时忽略这两项
+	Read           string // 阅读状态：read 已读、unread 未读
+	Starred        bool   // 只看加了星标的文章
+}
+
+// TagCount 标签及使用该标签的文章数
+type TagCount struct {
+	Tag   string `bson:"_id" json:"tag"`
+	Count int64  `bson:"count" json:"count"`
 }
 
 // TableName 返回集合名称
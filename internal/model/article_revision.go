@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 文章版本来源
+const (
+	RevisionSourceCrawl  = "crawl"  // 首次采集的正文
+	RevisionSourceVerify = "verify" // 复查时发现正文被修改
+)
+
+// ArticleRevision 文章正文的一个版本
+// 复查时发现正文的规范化文本与最新版本不同则新增一个版本，首次发现修改时同时保存采集时的正文作为第1版
+type ArticleRevision struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ArticleID primitive.ObjectID `bson:"article_id" json:"article_id"` // 所属文章ID
+	Revision  int                `bson:"revision" json:"revision"`     // 版本号，从1开始
+	Content   string             `bson:"content" json:"content"`       // 正文HTML
+	Text      string             `bson:"text" json:"text"`             // 规范化文本，每段一行
+	TextHash  string             `bson:"text_hash" json:"text_hash"`   // 规范化文本的摘要
+	Source    string             `bson:"source" json:"source"`         // 版本来源：crawl/verify
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"` // 获取到该版本的时间
+}
+
+// TableName 返回集合名称
+func (ArticleRevision) TableName() string {
+	return "article_revisions"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 验证各管理员的已读和星标名单不会随文章输出
+func TestArticleJSONHidesUserMarks(t *testing.T) {
+	article := &Article{Title: "标题", ReadBy: []string{"alice"}, StarredBy: []string{"bob"}, Tags: []string{"待审"}}
+	data, err := json.Marshal(article)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	for _, hidden := range []string{"read_by", "starred_by", "alice", "bob"} {
+		if strings.Contains(string(data), hidden) {
+			t.Errorf("输出包含 %q: %s", hidden, data)
+		}
+	}
+	if !article.IsReadBy("alice") || article.IsReadBy("bob") || !article.IsStarredBy("bob") {
+		t.Errorf("IsReadBy/IsStarredBy 结果不正确")
+	}
+}
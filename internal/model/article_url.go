@@ -0,0 +1,55 @@
+package model
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// ArticleURLKey 文章链接的规范化标识，用于去重
+// 同一篇文章的链接可能带有不同的协议、HTML转义、参数顺序和 chksm、scene 等附加参数：
+//   - mp.weixin.qq.com/s?__biz=...&mid=...&idx=...&sn=... 只保留这四个参数，按固定顺序拼接
+//   - mp.weixin.qq.com/s/<短链接> 只保留短链接部分
+//   - 其他链接去掉协议和 # 之后的部分
+func ArticleURLKey(rawURL string) string {
+	s := strings.TrimSpace(html.UnescapeString(rawURL))
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+
+	q := u.Query()
+	// __biz 是 Base64 编码，未转义的 + 会被解析为空格
+	biz := strings.ReplaceAll(q.Get("__biz"), " ", "+")
+	mid := firstParam(q, "mid", "appmsgid")
+	idx := firstParam(q, "idx", "itemidx")
+	sn := firstParam(q, "sn", "sign")
+	if biz != "" && mid != "" && sn != "" {
+		if idx == "" {
+			idx = "1"
+		}
+		return "__biz=" + biz + "&mid=" + mid + "&idx=" + idx + "&sn=" + sn
+	}
+
+	if path := strings.TrimSuffix(u.Path, "/"); strings.HasPrefix(path, "/s/") {
+		return "s/" + path[len("/s/"):]
+	}
+
+	u.Scheme = ""
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	return strings.TrimPrefix(u.String(), "//")
+}
+
+// firstParam 依次查找参数，返回第一个非空的值
+func firstParam(q url.Values, names ...string) string {
+	for _, name := range names {
+		if v := q.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+// 验证同一篇文章的不同链接形式得到相同的标识
+func TestArticleURLKey(t *testing.T) {
+	const key = "__biz=MzA5MDAwMDAwMA==&mid=2650000001&idx=1&sn=abcdef"
+	same := []string{
+		"https://mp.weixin.qq.com/s?__biz=MzA5MDAwMDAwMA==&mid=2650000001&idx=1&sn=abcdef",
+		"http://mp.weixin.qq.com/s?__biz=MzA5MDAwMDAwMA%3D%3D&mid=2650000001&idx=1&sn=abcdef&chksm=123#rd",
+		"https://mp.weixin.qq.com/s?sn=abcdef&amp;idx=1&amp;mid=2650000001&amp;__biz=MzA5MDAwMDAwMA==&amp;scene=27",
+		"https://mp.weixin.qq.com/mp/appmsg/show?__biz=MzA5MDAwMDAwMA==&appmsgid=2650000001&itemidx=1&sign=abcdef",
+		"http://127.0.0.1:8090/s?__biz=MzA5MDAwMDAwMA==&mid=2650000001&sn=abcdef",
+	}
+	for _, u := range same {
+		if got := ArticleURLKey(u); got != key {
+			t.Errorf("ArticleURLKey(%q) = %q, want %q", u, got, key)
+		}
+	}
+
+	cases := map[string]string{
+		"https://mp.weixin.qq.com/s/AbC-123_x": "s/AbC-123_x",
+		"http://mp.weixin.qq.com/s/AbC-123_x/": "s/AbC-123_x",
+		"https://Example.com/post?id=1#top":    "example.com/post?id=1",
+		"  ":                                   "",
+	}
+	for u, want := range cases {
+		if got := ArticleURLKey(u); got != want {
+			t.Errorf("ArticleURLKey(%q) = %q, want %q", u, got, want)
+		}
+	}
+
+	if ArticleURLKey(same[0]) == ArticleURLKey("https://mp.weixin.qq.com/s?__biz=MzA5MDAwMDAwMA==&mid=2650000001&idx=2&sn=fedcba") {
+		t.Error("articles at different positions share a key")
+	}
+}
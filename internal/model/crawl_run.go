@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 爬取任务触发来源
+const (
+	CrawlTriggerCron   = "cron"   // 定时任务
+	CrawlTriggerManual = "manual" // 管理后台手动触发
+	CrawlTriggerAPI    = "api"    // 开放API触发
+)
+
+// 爬取任务状态
+const (
+	CrawlRunRunning     = "running"     // 执行中
+	CrawlRunSuccess     = "success"     // 所有公众号均爬取成功
+	CrawlRunPartial     = "partial"     // 部分公众号失败或被跳过
+	CrawlRunFailed      = "failed"      // 任务失败
+	CrawlRunCancelled   = "cancelled"   // 管理后台取消
+	CrawlRunInterrupted = "interrupted" // 服务重启导致任务中断
+)
+
+// 单个公众号的爬取结果
+const (
+	CrawlAccountSuccess = "success" // 成功
+	CrawlAccountFailed  = "failed"  // 失败
+	CrawlAccountSkipped = "skipped" // 因登录态失效或频率限制被跳过
+)
+
+// CrawlRun 一次爬取任务的执行记录
+type CrawlRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Trigger     string             `bson:"trigger" json:"trigger"`           // 触发来源：cron/manual/api
+	Operator    string             `bson:"operator" json:"operator"`         // 手动触发的管理员
+	Status      string             `bson:"status" json:"status"`             // 任务状态
+	Error       string             `bson:"error" json:"error"`               // 任务级错误信息
+	Total       int                `bson:"total" json:"total"`               // 待爬取的公众号数量
+	NewArticles int                `bson:"new_articles" json:"new_articles"` // 新增文章总数
+	Accounts    []CrawlRunAccount  `bson:"accounts" json:"accounts"`         // 各公众号的爬取结果
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`     // 开始时间
+	FinishedAt  time.Time          `bson:"finished_at" json:"finished_at"`   // 结束时间
+}
+
+// CrawlRunAccount 单个公众号在一次爬取任务中的结果
+type CrawlRunAccount struct {
+	AccountID   primitive.ObjectID `bson:"account_id" json:"account_id"`
+	AccountName string             `bson:"account_name" json:"account_name"`
+	Status      string             `bson:"status" json:"status"`       // success/failed/skipped
+	Error       string             `bson:"error" json:"error"`         // 错误信息
+	NewCount    int                `bson:"new_count" json:"new_count"` // 新增文章数
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt  time.Time          `bson:"finished_at" json:"finished_at"`
+}
+
+// TableName 返回集合名称
+func (CrawlRun) TableName() string {
+	return "crawl_runs"
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Lease 多实例共享数据库时的租约（分布式锁），持有者需在过期前续约
+// 过期时间由数据库服务器时钟计算，避免各实例时钟不一致；过期的文档由TTL索引自动清理
+type Lease struct {
+	Name        string    `bson:"_id" json:"name"`                  // 租约名称
+	Owner       string    `bson:"owner" json:"owner"`               // 持有者（实例ID）
+	AcquiredAt  time.Time `bson:"acquired_at" json:"acquired_at"`   // 当前持有者获得租约的时间
+	HeartbeatAt time.Time `bson:"heartbeat_at" json:"heartbeat_at"` // 最后一次续约时间
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`     // 过期时间，过期后其他实例可以接管
+}
+
+// TableName 返回集合名称
+func (Lease) TableName() string {
+	return "leases"
+}
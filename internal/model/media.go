@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MediaFile 归档的图片，按内容摘要去重，同一张图片的不同地址共用一个文件
+type MediaFile struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Hash        string             `bson:"hash" json:"hash"`                 // 内容的 SHA-256
+	Key         string             `bson:"key" json:"key"`                   // 在文件存储中的路径
+	ContentType string             `bson:"content_type" json:"content_type"` // 文件类型
+	Size        int64              `bson:"size" json:"size"`                 // 文件大小（字节）
+	URLs        []string           `bson:"urls" json:"urls"`                 // 下载过该文件的原始地址
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`     // 首次归档时间
+}
+
+// TableName 返回集合名称
+func (MediaFile) TableName() string {
+	return "media_files"
+}
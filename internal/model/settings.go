@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RuntimeSettingsID 运行时设置文档的固定ID（只有一条记录）
+const RuntimeSettingsID = "runtime"
+
+// RuntimeSettings 可在管理后台修改、无需重启即可生效的设置
+// 保存到数据库时只记录与配置文件默认值不同的项，0 表示使用配置文件中的默认值
+type RuntimeSettings struct {
+	CrawlInterval int `bson:"crawl_interval,omitempty" json:"crawl_interval"` // 默认爬取间隔（分钟）
+	FetchCount    int `bson:"fetch_count,omitempty" json:"fetch_count"`       // 每次获取的文章数
+	Timeout       int `bson:"timeout,omitempty" json:"timeout"`               // 单次请求超时时间（秒）
+}
+
+// SettingsRecord 数据库中保存的运行时设置
+type SettingsRecord struct {
+	ID        string          `bson:"_id" json:"id"`
+	Overrides RuntimeSettings `bson:"overrides" json:"overrides"`   // 覆盖配置文件默认值的设置项
+	UpdatedBy string          `bson:"updated_by" json:"updated_by"` // 最后修改人
+	UpdatedAt time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// TableName 返回集合名称
+func (SettingsRecord) TableName() string {
+	return "settings"
+}
+
+// SettingsChange 运行时设置的修改记录
+type SettingsChange struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Operator  string             `bson:"operator" json:"operator"` // 修改人
+	Action    string             `bson:"action" json:"action"`     // update-修改，reset-恢复默认
+	Before    RuntimeSettings    `bson:"before" json:"before"`     // 修改前生效的设置
+	After     RuntimeSettings    `bson:"after" json:"after"`       // 修改后生效的设置
+	Changed   []string           `bson:"changed" json:"changed"`   // 发生变化的设置项
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// TableName 返回集合名称
+func (SettingsChange) TableName() string {
+	return "settings_history"
+}
+
+// 设置修改记录的操作类型
+const (
+	SettingsActionUpdate = "update"
+	SettingsActionReset  = "reset"
+)
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,14 +11,127 @@ import (
 // WeChatAccount 微信公众号账号信息
 type WeChatAccount struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name        string             `bson:"name" json:"name"`                 // 公众号名称
-	Alias       string             `bson:"alias" json:"alias"`               // 公众号别名（用于搜索）
-	FakeID      string             `bson:"fake_id" json:"fake_id"`           // 微信公众号的唯一标识
-	URL         string             `bson:"url" json:"url"`                   // 公众号主页URL
-	LastArticle string             `bson:"last_article" json:"last_article"` // 最后一篇文章的URL（用于判断是否有新文章）
-	Status      int                `bson:"status" json:"status"`             // 状态：1-正常 0-禁用
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`     // 创建时间
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`     // 更新时间
+	Name        string             `bson:"name" json:"name"`                             // 公众号名称
+	Alias       string             `bson:"alias" json:"alias"`                           // 公众号别名（用于搜索）
+	FakeID      string             `bson:"fake_id" json:"fake_id"`                       // 微信公众号的唯一标识
+	URL         string             `bson:"url" json:"url"`                               // 公众号主页URL
+	LastArticle string             `bson:"last_article" json:"last_article"`             // 最后一篇文章的URL（用于判断是否有新文章）
+	Status      int                `bson:"status" json:"status"`                         // 状态：1-正常 0-禁用
+	Backfill    *BackfillState     `bson:"backfill,omitempty" json:"backfill,omitempty"` // 历史文章回溯进度
+	Schedule    CrawlSchedule      `bson:"schedule" json:"schedule"`                     // 爬取计划
+	LastCrawlAt time.Time          `bson:"last_crawl_at" json:"last_crawl_at"`           // 最近一次爬取时间
+	NextCrawlAt time.Time          `bson:"next_crawl_at" json:"next_crawl_at"`           // 下次爬取时间，零值表示尽快爬取
+	Pattern     *PostingPattern    `bson:"pattern,omitempty" json:"pattern,omitempty"`   // 根据历史文章统计的发文规律
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`                 // 创建时间
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`                 // 更新时间
+}
+
+// CrawlSchedule 公众号的爬取计划
+// Cron 优先于 Interval，两者都为空时使用全局的 crawler.interval
+type CrawlSchedule struct {
+	Interval int    `bson:"interval" json:"interval"` // 爬取间隔（分钟），0表示使用全局间隔
+	Cron     string `bson:"cron" json:"cron"`         // cron表达式（分 时 日 月 周），如 "0 8-22 * * *"
+	Priority int    `bson:"priority" json:"priority"` // 优先级，数值越大越先爬取
+}
+
+// PostingPattern 根据历史文章发布时间统计的发文规律，未设置爬取计划时用于自适应调度
+type PostingPattern struct {
+	Hours          [24]int   `bson:"hours" json:"hours"`                     // 各小时（0-23时）的发文数
+	Weekdays       [7]int    `bson:"weekdays" json:"weekdays"`               // 周日至周六的发文数
+	HotHours       []int     `bson:"hot_hours" json:"hot_hours"`             // 集中发文的小时，样本不足时为空
+	ActiveWeekdays []int     `bson:"active_weekdays" json:"active_weekdays"` // 经常发文的星期（0为周日），样本不足时为空
+	Samples        int       `bson:"samples" json:"samples"`                 // 参与统计的文章数
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"`           // 统计时间
+}
+
+// Ready 样本是否足够用于调度
+func (p *PostingPattern) Ready() bool {
+	return p != nil && len(p.HotHours) > 0 && len(p.ActiveWeekdays) > 0
+}
+
+// IsHot 判断时间是否处于经常发文的时段（星期和小时都匹配）
+func (p *PostingPattern) IsHot(t time.Time) bool {
+	if !p.Ready() {
+		return false
+	}
+	return containsInt(p.ActiveWeekdays, int(t.Weekday())) && containsInt(p.HotHours, t.Hour())
+}
+
+// Summary 发文规律的简要描述，如 "周一至周五 08时、21时"
+func (p *PostingPattern) Summary() string {
+	if !p.Ready() {
+		return ""
+	}
+
+	names := []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+	var days string
+	switch {
+	case len(p.ActiveWeekdays) == 7:
+		days = "每天"
+	case len(p.ActiveWeekdays) == 5 && !containsInt(p.ActiveWeekdays, 0) && !containsInt(p.ActiveWeekdays, 6):
+		days = "周一至周五"
+	default:
+		parts := make([]string, len(p.ActiveWeekdays))
+		for i, d := range p.ActiveWeekdays {
+			parts[i] = names[d]
+		}
+		days = strings.Join(parts, "、")
+	}
+
+	hours := make([]string, len(p.HotHours))
+	for i, h := range p.HotHours {
+		hours[i] = fmt.Sprintf("%02d时", h)
+	}
+	return days + " " + strings.Join(hours, "、")
+}
+
+// HourLevels 各小时发文数相对最大值的百分比（0-100），用于在管理后台绘制分布图
+func (p *PostingPattern) HourLevels() []int {
+	max := 0
+	for _, n := range p.Hours {
+		if n > max {
+			max = n
+		}
+	}
+	levels := make([]int, 24)
+	if max == 0 {
+		return levels
+	}
+	for h, n := range p.Hours {
+		levels[h] = n * 100 / max
+	}
+	return levels
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// 历史文章回溯状态
+const (
+	BackfillStatusRunning = "running" // 回溯中（服务重启后会从游标处继续）
+	BackfillStatusDone    = "done"    // 已完成
+	BackfillStatusFailed  = "failed"  // 失败，可再次触发从游标处继续
+)
+
+// BackfillState 历史文章回溯进度（持久化游标，支持断点续爬）
+type BackfillState struct {
+	Status     string    `bson:"status" json:"status"`           // 回溯状态
+	Begin      int       `bson:"begin" json:"begin"`             // 下一页的起始偏移（游标）
+	Total      int       `bson:"total" json:"total"`             // 公众号文章总数（app_msg_cnt）
+	Scanned    int       `bson:"scanned" json:"scanned"`         // 已扫描的文章数
+	Saved      int       `bson:"saved" json:"saved"`             // 已入库的新文章数
+	UntilTime  int64     `bson:"until_time" json:"until_time"`   // 截止发布时间，早于该时间的文章不再回溯，0表示不限制
+	MaxCount   int       `bson:"max_count" json:"max_count"`     // 最多回溯的文章数，0表示不限制
+	Error      string    `bson:"error" json:"error"`             // 最近一次错误信息
+	StartedAt  time.Time `bson:"started_at" json:"started_at"`   // 开始时间
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`   // 最近更新时间
+	FinishedAt time.Time `bson:"finished_at" json:"finished_at"` // 结束时间
 }
 
 // TableName 返回集合名称
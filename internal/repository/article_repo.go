@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"wechat-crawler/internal/model"
 	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/search"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,20 +16,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// ArticleRepo 文章数据访问层
-type ArticleRepo struct {
+// MongoArticleRepo 基于MongoDB的文章数据访问层
+type MongoArticleRepo struct {
 	collection *mongo.Collection
 }
 
-// NewArticleRepo 创建文章仓库实例
-func NewArticleRepo() *ArticleRepo {
-	return &ArticleRepo{
+// NewMongoArticleRepo 创建基于MongoDB的文章仓库实例
+func NewMongoArticleRepo() *MongoArticleRepo {
+	return &MongoArticleRepo{
 		collection: database.GetCollection(model.Article{}.TableName()),
 	}
 }
 
 // Create 创建文章记录
-func (r *ArticleRepo) Create(ctx context.Context, article *model.Article) error {
+func (r *MongoArticleRepo) Create(ctx context.Context, article *model.Article) error {
 	article.CreatedAt = time.Now()
 
 	result, err := r.collection.InsertOne(ctx, article)
@@ -38,45 +41,99 @@ func (r *ArticleRepo) Create(ctx context.Context, article *model.Article) error
 	return nil
 }
 
-// BatchCreate 批量创建文章
-func (r *ArticleRepo) BatchCreate(ctx context.Context, articles []*model.Article) error {
+// BatchUpsert 按规范化链接（url_key）批量写入文章，已存在的文章保持不变
+// 重复写入同一批文章或与其他任务并发写入同一篇文章时不会产生重复记录
+// 返回新增和已存在的文章（保持传入顺序），新增文章的ID会被填充
+func (r *MongoArticleRepo) BatchUpsert(ctx context.Context, articles []*model.Article) (created, existing []*model.Article, err error) {
 	if len(articles) == 0 {
-		return nil
+		return nil, nil, nil
 	}
 
-	docs := make([]interface{}, len(articles))
-	for i, article := range articles {
-		article.CreatedAt = time.Now()
-		docs[i] = article
+	now := time.Now()
+	var models []mongo.WriteModel
+	var batch []*model.Article
+	seen := make(map[string]bool)
+	for _, article := range articles {
+		article.URLKey = model.ArticleURLKey(article.ContentURL)
+		if article.URLKey == "" {
+			return nil, nil, fmt.Errorf("文章链接为空: %s", article.Title)
+		}
+		if seen[article.URLKey] {
+			existing = append(existing, article)
+			continue
+		}
+		seen[article.URLKey] = true
+
+		article.ID = primitive.NewObjectID()
+		article.CreatedAt = now
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"url_key": article.URLKey}).
+			SetUpdate(bson.M{"$setOnInsert": article}).
+			SetUpsert(true))
+		batch = append(batch, article)
 	}
 
-	_, err := r.collection.InsertMany(ctx, docs)
-	return err
+	// 无序写入，一篇文章失败不影响其余文章；并发插入同一篇文章时唯一索引冲突的一方视为已存在
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeys(err) {
+		return nil, nil, err
+	}
+	for i, article := range batch {
+		if _, ok := result.UpsertedIDs[int64(i)]; ok {
+			created = append(created, article)
+		} else {
+			article.ID = primitive.NilObjectID
+			existing = append(existing, article)
+		}
+	}
+	return created, existing, nil
+}
+
+// onlyDuplicateKeys 批量写入的错误是否全部为唯一索引冲突
+func onlyDuplicateKeys(err error) bool {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+		return false
+	}
+	for _, we := range bwe.WriteErrors {
+		if !mongo.IsDuplicateKeyError(we) {
+			return false
+		}
+	}
+	return true
 }
 
 // FindByID 根据ID查询
-func (r *ArticleRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Article, error) {
+func (r *MongoArticleRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Article, error) {
 	var article model.Article
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&article)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &article, nil
 }
 
-// FindByContentURL 根据文章URL查询（用于去重）
-func (r *ArticleRepo) FindByContentURL(ctx context.Context, contentURL string) (*model.Article, error) {
+// urlFilter 按规范化链接查询文章的条件，同时匹配原始链接，兼容尚未生成 url_key 的历史文章
+func urlFilter(contentURL string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"url_key": model.ArticleURLKey(contentURL)},
+		bson.M{"content_url": contentURL},
+	}}
+}
+
+// FindByContentURL 根据文章URL查询（用于去重），不同形式的同一文章链接视为相同
+func (r *MongoArticleRepo) FindByContentURL(ctx context.Context, contentURL string) (*model.Article, error) {
 	var article model.Article
-	err := r.collection.FindOne(ctx, bson.M{"content_url": contentURL}).Decode(&article)
+	err := r.collection.FindOne(ctx, urlFilter(contentURL)).Decode(&article)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &article, nil
 }
 
-// ExistsByContentURL 检查文章是否已存在
-func (r *ArticleRepo) ExistsByContentURL(ctx context.Context, contentURL string) (bool, error) {
-	count, err := r.collection.CountDocuments(ctx, bson.M{"content_url": contentURL})
+// ExistsByContentURL 检查文章是否已存在，不同形式的同一文章链接视为相同
+func (r *MongoArticleRepo) ExistsByContentURL(ctx context.Context, contentURL string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, urlFilter(contentURL), options.Count().SetLimit(1))
 	if err != nil {
 		return false, err
 	}
@@ -84,7 +141,7 @@ func (r *ArticleRepo) ExistsByContentURL(ctx context.Context, contentURL string)
 }
 
 // ListByAccountID 根据公众号ID查询文章列表
-func (r *ArticleRepo) ListByAccountID(ctx context.Context, accountID primitive.ObjectID, page, pageSize int64) ([]*model.Article, int64, error) {
+func (r *MongoArticleRepo) ListByAccountID(ctx context.Context, accountID primitive.ObjectID, page, pageSize int64) ([]*model.Article, int64, error) {
 	// 计算总数
 	total, err := r.collection.CountDocuments(ctx, bson.M{"account_id": accountID})
 	if err != nil {
@@ -111,36 +168,74 @@ func (r *ArticleRepo) ListByAccountID(ctx context.Context, accountID primitive.O
 	return articles, total, nil
 }
 
-// ListWithFilter 根据条件查询文章列表（支持关键词、时间范围、公众号筛选）
-func (r *ArticleRepo) ListWithFilter(ctx context.Context, accountID string, keyword string, startTime, endTime int64, page, pageSize int64) ([]*model.Article, int64, error) {
+// ListWithFilter 根据条件查询文章列表（支持全文搜索、时间范围、公众号、正文缺失、标签、阅读状态和星标筛选）
+func (r *MongoArticleRepo) ListWithFilter(ctx context.Context, f model.ArticleFilter, page, pageSize int64) ([]*model.Article, int64, error) {
 	// 构建查询条件
 	filter := bson.M{}
 
 	// 公众号筛选
-	if accountID != "" {
-		objectID, err := primitive.ObjectIDFromHex(accountID)
+	if f.AccountID != "" {
+		objectID, err := primitive.ObjectIDFromHex(f.AccountID)
 		if err == nil {
 			filter["account_id"] = objectID
 		}
 	}
 
-	// 关键词搜索（标题）
-	if keyword != "" {
-		filter["title"] = bson.M{"$regex": keyword, "$options": "i"} // 不区分大小写
+	// 全文搜索（标题、作者、摘要和正文）
+	query, err := search.Parse(f.Keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+	if query != nil {
+		filter["$or"] = searchFilter(query)
 	}
 
 	// 时间范围筛选
-	if startTime > 0 || endTime > 0 {
+	if f.StartTime > 0 || f.EndTime > 0 {
 		timeFilter := bson.M{}
-		if startTime > 0 {
-			timeFilter["$gte"] = startTime
+		if f.StartTime > 0 {
+			timeFilter["$gte"] = f.StartTime
 		}
-		if endTime > 0 {
-			timeFilter["$lte"] = endTime
+		if f.EndTime > 0 {
+			timeFilter["$lte"] = f.EndTime
 		}
 		filter["publish_time"] = timeFilter
 	}
 
+	// 正文缺失（包括等待重试和已放弃重试的文章）
+	if f.MissingContent {
+		filter["content"] = ""
+	}
+
+	// 在线状态，未复查过的文章视为正常
+	switch f.Status {
+	case "":
+	case model.ArticleStatusLive:
+		filter["status"] = bson.M{"$nin": bson.A{model.ArticleStatusDeleted, model.ArticleStatusBlocked}}
+	case model.ArticleStatusRemoved:
+		filter["status"] = bson.M{"$in": bson.A{model.ArticleStatusDeleted, model.ArticleStatusBlocked}}
+	default:
+		filter["status"] = f.Status
+	}
+
+	// 标签
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+
+	// 阅读状态和星标，按当前用户区分
+	if f.User != "" {
+		switch f.Read {
+		case model.ArticleRead:
+			filter["read_by"] = f.User
+		case model.ArticleUnread:
+			filter["read_by"] = bson.M{"$ne": f.User}
+		}
+		if f.Starred {
+			filter["starred_by"] = f.User
+		}
+	}
+
 	// 计算总数
 	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -151,7 +246,8 @@ func (r *ArticleRepo) ListWithFilter(ctx context.Context, accountID string, keyw
 	opts := options.Find().
 		SetSort(bson.D{{Key: "publish_time", Value: -1}}).
 		SetSkip((page - 1) * pageSize).
-		SetLimit(pageSize)
+		SetLimit(pageSize).
+		SetProjection(bson.M{"search_tokens": 0})
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -167,8 +263,42 @@ func (r *ArticleRepo) ListWithFilter(ctx context.Context, accountID string, keyw
 	return articles, total, nil
 }
 
+// searchFields 全文搜索匹配的字段
+var searchFields = []string{"title", "author", "digest", "text"}
+
+// searchFilter 全文搜索条件，满足任意一组即可
+// 先用检索词索引筛选出候选文章，再按原文连续匹配关键词，排除词不能出现在任何字段中
+func searchFilter(query *search.Query) bson.A {
+	groups := bson.A{}
+	for _, g := range query.Groups {
+		var tokens []string
+		var conds bson.A
+		for _, term := range g.Include {
+			tokens = append(tokens, term.Tokens...)
+			conds = append(conds, bson.M{"$or": termMatch(term)})
+		}
+		for _, term := range g.Exclude {
+			conds = append(conds, bson.M{"$nor": termMatch(term)})
+		}
+		groups = append(groups, bson.M{
+			"search_tokens": bson.M{"$all": tokens},
+			"$and":          conds,
+		})
+	}
+	return groups
+}
+
+// termMatch 关键词在各字段中的匹配条件（不区分大小写）
+func termMatch(term search.Term) bson.A {
+	match := bson.A{}
+	for _, field := range searchFields {
+		match = append(match, bson.M{field: bson.M{"$regex": term.Pattern(), "$options": "i"}})
+	}
+	return match
+}
+
 // List 查询所有文章（分页）
-func (r *ArticleRepo) List(ctx context.Context, page, pageSize int64) ([]*model.Article, int64, error) {
+func (r *MongoArticleRepo) List(ctx context.Context, page, pageSize int64) ([]*model.Article, int64, error) {
 	// 计算总数
 	total, err := r.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
@@ -195,25 +325,316 @@ func (r *ArticleRepo) List(ctx context.Context, page, pageSize int64) ([]*model.
 	return articles, total, nil
 }
 
+// ListPendingFetch 查询已到重试时间的正文缺失文章，按重试时间先后排序
+// 没有获取状态的历史数据中正文为空的文章也一并重试
+func (r *MongoArticleRepo) ListPendingFetch(ctx context.Context, now time.Time, limit int64) ([]*model.Article, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"fetch_status": model.ArticleFetchPending, "next_fetch_at": bson.M{"$lte": now}},
+			{"fetch_status": bson.M{"$exists": false}, "content": ""},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_fetch_at", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var articles []*model.Article
+	if err := cursor.All(ctx, &articles); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// UpdateContent 保存重试获取到的正文
+func (r *MongoArticleRepo) UpdateContent(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, attempts int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, extractUpdate(extract, bson.M{
+		"content":        content,
+		"fetch_status":   model.ArticleFetchSuccess,
+		"fetch_attempts": attempts,
+		"fetch_error":    "",
+		"next_fetch_at":  time.Time{},
+	}))
+	return err
+}
+
+// AddSnapshots 追加页面快照记录
+func (r *MongoArticleRepo) AddSnapshots(ctx context.Context, id primitive.ObjectID, snapshots []model.ArticleSnapshot) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$push": bson.M{"snapshots": bson.M{"$each": snapshots}},
+	})
+	return err
+}
+
+// ListMissingExtract 按ID顺序获取 afterID 之后有正文但尚未生成Markdown、纯文本等内容，或尚未生成检索词的文章
+// （只含标题、作者、摘要、正文和封面），withMedia 为 true 时同时获取尚未归档图片或有图片归档失败的文章
+func (r *MongoArticleRepo) ListMissingExtract(ctx context.Context, afterID primitive.ObjectID, withMedia bool, limit int64) ([]*model.Article, error) {
+	missing := bson.A{bson.M{"meta": bson.M{"$exists": false}}}
+	if withMedia {
+		missing = append(missing,
+			bson.M{"media_archived_at": bson.M{"$exists": false}},
+			bson.M{"media_failed": bson.M{"$gt": 0}},
+		)
+	}
+	filter := bson.M{
+		"_id": bson.M{"$gt": afterID},
+		"$or": bson.A{
+			bson.M{"content": bson.M{"$ne": ""}, "$or": missing},
+			bson.M{"search_tokens": bson.M{"$exists": false}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"title": 1, "author": 1, "digest": 1, "content": 1, "cover": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var articles []*model.Article
+	if err := cursor.All(ctx, &articles); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// UpdateExtract 保存由正文生成的内容
+func (r *MongoArticleRepo) UpdateExtract(ctx context.Context, id primitive.ObjectID, extract model.ArticleExtract) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, extractUpdate(extract, bson.M{}))
+	return err
+}
+
+// extractUpdate 在 set 中加入由正文生成的内容，未归档图片时清除上次的归档信息
+func extractUpdate(extract model.ArticleExtract, set bson.M) bson.M {
+	set["clean_html"] = extract.CleanHTML
+	set["markdown"] = extract.Markdown
+	set["text"] = extract.Text
+	set["meta"] = extract.Meta
+	set["search_tokens"] = extract.SearchTokens
+	if extract.MediaArchivedAt.IsZero() {
+		return bson.M{
+			"$set":   set,
+			"$unset": bson.M{"local_cover": "", "media_archived_at": "", "media_failed": ""},
+		}
+	}
+	set["local_cover"] = extract.LocalCover
+	set["media_archived_at"] = extract.MediaArchivedAt
+	set["media_failed"] = extract.MediaFailed
+	return bson.M{"$set": set}
+}
+
+// UpdateFetchFailure 记录正文获取失败，status 为 pending 时在 next 之后重试
+func (r *MongoArticleRepo) UpdateFetchFailure(ctx context.Context, id primitive.ObjectID, status string, attempts int, fetchErr string, next time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"fetch_status":   status,
+			"fetch_attempts": attempts,
+			"fetch_error":    fetchErr,
+			"next_fetch_at":  next,
+		},
+	})
+	return err
+}
+
+// CountRemoved 统计已删除或被屏蔽的文章数量
+func (r *MongoArticleRepo) CountRemoved(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"status": bson.M{"$in": bson.A{model.ArticleStatusDeleted, model.ArticleStatusBlocked}},
+	})
+}
+
+// ListForVerify 获取需要复查在线状态的文章
+// 只复查 since 之后发布、未删除、且 checkedBefore 之后没有复查过的文章，从未复查过的优先
+func (r *MongoArticleRepo) ListForVerify(ctx context.Context, since int64, checkedBefore time.Time, limit int64) ([]*model.Article, error) {
+	filter := bson.M{
+		"publish_time": bson.M{"$gte": since},
+		"status":       bson.M{"$ne": model.ArticleStatusDeleted},
+		"$or": bson.A{
+			bson.M{"last_checked_at": bson.M{"$exists": false}},
+			bson.M{"last_checked_at": bson.M{"$lt": checkedBefore}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_checked_at", Value: 1}, {Key: "publish_time", Value: -1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"content": 0})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var articles []*model.Article
+	if err := cursor.All(ctx, &articles); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// UpdateChecked 记录复查结果，状态未变化时只更新复查时间
+func (r *MongoArticleRepo) UpdateChecked(ctx context.Context, id primitive.ObjectID, status string, checkedAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"last_checked_at": checkedAt,
+		},
+	})
+	return err
+}
+
+// UpdateStatus 记录文章在线状态的变化，保留已采集的正文
+func (r *MongoArticleRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, change model.ArticleStatusChange) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"status":            change.To,
+			"status_changed_at": change.At,
+			"last_checked_at":   change.At,
+		},
+		"$push": bson.M{"status_history": change},
+	})
+	return err
+}
+
+// UpdateRevision 复查发现正文被修改时更新为最新版本的正文，历史版本保存在 article_revisions 中
+func (r *MongoArticleRepo) UpdateRevision(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, revisions int, changedAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, extractUpdate(extract, bson.M{
+		"content":            content,
+		"revisions":          revisions,
+		"content_changed_at": changedAt,
+	}))
+	return err
+}
+
+// CountMissingContent 统计正文缺失的文章数量
+func (r *MongoArticleRepo) CountMissingContent(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"content": ""})
+}
+
 // GetLatestByAccountID 获取公众号最新的一篇文章
-func (r *ArticleRepo) GetLatestByAccountID(ctx context.Context, accountID primitive.ObjectID) (*model.Article, error) {
+func (r *MongoArticleRepo) GetLatestByAccountID(ctx context.Context, accountID primitive.ObjectID) (*model.Article, error) {
 	opts := options.FindOne().SetSort(bson.D{{Key: "publish_time", Value: -1}})
 
 	var article model.Article
 	err := r.collection.FindOne(ctx, bson.M{"account_id": accountID}, opts).Decode(&article)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &article, nil
 }
 
+// ListPublishTimes 查询公众号最近的文章发布时间（按发布时间倒序），since 为0表示不限制
+func (r *MongoArticleRepo) ListPublishTimes(ctx context.Context, accountID primitive.ObjectID, since int64, limit int64) ([]int64, error) {
+	filter := bson.M{"account_id": accountID, "publish_time": bson.M{"$gt": since}}
+	opts := options.Find().
+		SetProjection(bson.M{"publish_time": 1}).
+		SetSort(bson.D{{Key: "publish_time", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		PublishTime int64 `bson:"publish_time"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	times := make([]int64, len(docs))
+	for i, doc := range docs {
+		times[i] = doc.PublishTime
+	}
+	return times, nil
+}
+
 // CountByAccountID 统计公众号文章数量
-func (r *ArticleRepo) CountByAccountID(ctx context.Context, accountID primitive.ObjectID) (int64, error) {
+func (r *MongoArticleRepo) CountByAccountID(ctx context.Context, accountID primitive.ObjectID) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{"account_id": accountID})
 }
 
 // Delete 删除文章
-func (r *ArticleRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (r *MongoArticleRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+// MarkRead 设置文章对用户的已读状态，返回状态发生变化的文章数
+func (r *MongoArticleRepo) MarkRead(ctx context.Context, ids []primitive.ObjectID, user string, read bool) (int64, error) {
+	return r.updateUserMark(ctx, bson.M{"_id": bson.M{"$in": ids}}, "read_by", user, read)
+}
+
+// MarkAccountRead 把公众号的全部文章标记为用户已读，返回新标记的文章数
+func (r *MongoArticleRepo) MarkAccountRead(ctx context.Context, accountID primitive.ObjectID, user string) (int64, error) {
+	return r.updateUserMark(ctx, bson.M{"account_id": accountID}, "read_by", user, true)
+}
+
+// SetStarred 设置或取消用户对文章的星标
+func (r *MongoArticleRepo) SetStarred(ctx context.Context, ids []primitive.ObjectID, user string, starred bool) error {
+	_, err := r.updateUserMark(ctx, bson.M{"_id": bson.M{"$in": ids}}, "starred_by", user, starred)
+	return err
+}
+
+// updateUserMark 在 field 中添加或移除用户名，返回发生变化的文章数
+func (r *MongoArticleRepo) updateUserMark(ctx context.Context, filter bson.M, field, user string, set bool) (int64, error) {
+	var update bson.M
+	if set {
+		filter[field] = bson.M{"$ne": user}
+		update = bson.M{"$addToSet": bson.M{field: user}}
+	} else {
+		filter[field] = user
+		update = bson.M{"$pull": bson.M{field: user}}
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// AddTags 给文章添加标签，已有的标签保持不变
+func (r *MongoArticleRepo) AddTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+	})
+	return err
+}
+
+// RemoveTags 移除文章的标签
+func (r *MongoArticleRepo) RemoveTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$pull": bson.M{"tags": bson.M{"$in": tags}},
+	})
+	return err
+}
+
+// ListTags 查询所有标签及使用的文章数，按文章数倒序
+func (r *MongoArticleRepo) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tags.0": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []model.TagCount{}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
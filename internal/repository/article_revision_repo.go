@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoArticleRevisionRepo 基于MongoDB的文章版本数据访问层
+type MongoArticleRevisionRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoArticleRevisionRepo 创建基于MongoDB的文章版本仓库实例
+func NewMongoArticleRevisionRepo() *MongoArticleRevisionRepo {
+	return &MongoArticleRevisionRepo{
+		collection: database.GetCollection(model.ArticleRevision{}.TableName()),
+	}
+}
+
+// Create 保存一个版本
+func (r *MongoArticleRevisionRepo) Create(ctx context.Context, revision *model.ArticleRevision) error {
+	result, err := r.collection.InsertOne(ctx, revision)
+	if err != nil {
+		return err
+	}
+	revision.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Latest 获取文章的最新版本（不含正文HTML），没有版本时返回nil
+func (r *MongoArticleRevisionRepo) Latest(ctx context.Context, articleID primitive.ObjectID) (*model.ArticleRevision, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetProjection(bson.M{"content": 0})
+
+	var revision model.ArticleRevision
+	err := r.collection.FindOne(ctx, bson.M{"article_id": articleID}, opts).Decode(&revision)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// ListByArticle 按版本号顺序获取文章的所有版本（不含正文HTML和规范化文本）
+func (r *MongoArticleRevisionRepo) ListByArticle(ctx context.Context, articleID primitive.ObjectID) ([]*model.ArticleRevision, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: 1}}).
+		SetProjection(bson.M{"content": 0, "text": 0})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"article_id": articleID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []*model.ArticleRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// Get 获取文章的指定版本，不存在时返回 ErrNotFound
+func (r *MongoArticleRevisionRepo) Get(ctx context.Context, articleID primitive.ObjectID, revision int) (*model.ArticleRevision, error) {
+	var result model.ArticleRevision
+	err := r.collection.FindOne(ctx, bson.M{"article_id": articleID, "revision": revision}).Decode(&result)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &result, nil
+}
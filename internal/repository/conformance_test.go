@@ -0,0 +1,900 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/search"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 各存储实现共用的一致性测试：SQLite 总是运行，
+// 设置 TEST_POSTGRES_DSN、TEST_MONGODB_URI 时同时在 PostgreSQL、MongoDB 上运行（会清空测试库中的相关数据）
+
+// repos 一组空的仓库实例
+type repos struct {
+	articles  ArticleRepo
+	accounts  WeChatAccountRepo
+	feishu    FeishuConfigRepo
+	settings  SettingsRepo
+	runs      CrawlRunRepo
+	revisions ArticleRevisionRepo
+	media     MediaRepo
+	leases    LeaseRepo
+}
+
+// backends 可用的存储实现
+func backends(t *testing.T) map[string]func(t *testing.T) repos {
+	b := map[string]func(t *testing.T) repos{
+		"sqlite": func(t *testing.T) repos {
+			return openSQLRepos(t, database.SQLConfig{
+				Driver:  database.DriverSQLite,
+				DSN:     filepath.Join(t.TempDir(), "test.db"),
+				Timeout: 5,
+			})
+		},
+	}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		b["postgres"] = func(t *testing.T) repos {
+			return openSQLRepos(t, database.SQLConfig{Driver: database.DriverPostgres, DSN: dsn, Timeout: 5})
+		}
+	}
+	if uri := os.Getenv("TEST_MONGODB_URI"); uri != "" {
+		b["mongodb"] = func(t *testing.T) repos {
+			return openMongoRepos(t, uri)
+		}
+	}
+	return b
+}
+
+// openSQLRepos 打开关系型数据库并重建表结构
+func openSQLRepos(t *testing.T, cfg database.SQLConfig) repos {
+	t.Helper()
+	db, err := database.OpenSQL(cfg)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	for _, table := range []string{
+		"article_reads", "article_stars", "article_tags", "articles", "wechat_accounts", "feishu_config",
+		"article_revisions", "crawl_runs", "crawl_run_accounts", "settings", "settings_history",
+		"media_files", "media_file_urls", "leases", "schema_migrations",
+	} {
+		if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			t.Fatalf("清理表 %s 失败: %v", table, err)
+		}
+	}
+	if _, err := database.MigrateSQL(ctx, db, SQLMigrations()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return repos{
+		articles:  NewSQLArticleRepo(db),
+		accounts:  NewSQLWeChatAccountRepo(db),
+		feishu:    NewSQLFeishuConfigRepo(db),
+		settings:  NewSQLSettingsRepo(db),
+		runs:      NewSQLCrawlRunRepo(db),
+		revisions: NewSQLArticleRevisionRepo(db),
+		media:     NewSQLMediaRepo(db),
+		leases:    NewSQLLeaseRepo(db),
+	}
+}
+
+// openMongoRepos 连接独立的MongoDB测试库，测试结束后删除
+func openMongoRepos(t *testing.T, uri string) repos {
+	t.Helper()
+	err := database.Init(database.Config{
+		URI:      uri,
+		Database: fmt.Sprintf("wechat_crawler_test_%d", time.Now().UnixNano()),
+		Timeout:  5,
+	})
+	if err != nil {
+		t.Fatalf("连接MongoDB失败: %v", err)
+	}
+	ctx := context.Background()
+	t.Cleanup(func() {
+		database.Database.Drop(ctx)
+		database.Close()
+	})
+	if _, err := database.Migrate(ctx, database.Database, Migrations()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return repos{
+		articles:  NewMongoArticleRepo(),
+		accounts:  NewMongoWeChatAccountRepo(),
+		feishu:    NewMongoFeishuConfigRepo(),
+		settings:  NewMongoSettingsRepo(),
+		runs:      NewMongoCrawlRunRepo(),
+		revisions: NewMongoArticleRevisionRepo(),
+		media:     NewMongoMediaRepo(),
+		leases:    NewMongoLeaseRepo(),
+	}
+}
+
+// forEachBackend 在每个存储实现上运行测试
+func forEachBackend(t *testing.T, test func(t *testing.T, r repos)) {
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+}
+
+// newArticle 测试用的文章，已生成检索词
+func newArticle(accountID primitive.ObjectID, title, url string, publishTime int64) *model.Article {
+	a := &model.Article{
+		AccountID:   accountID,
+		AccountName: "测试公众号",
+		Title:       title,
+		Author:      "作者",
+		Digest:      "摘要",
+		Content:     "<p>" + title + "</p>",
+		ContentURL:  url,
+		PublishTime: publishTime,
+		FetchStatus: model.ArticleFetchSuccess,
+	}
+	a.Text = title + " 正文"
+	a.SearchTokens = search.Tokens(a.Title, a.Author, a.Digest, a.Text)
+	return a
+}
+
+// articleIDs 文章ID列表，用于比较查询结果
+func articleIDs(articles []*model.Article) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+// checkIDs 检查查询结果的ID及顺序
+func checkIDs(t *testing.T, name string, got []*model.Article, want ...*model.Article) {
+	t.Helper()
+	g, w := articleIDs(got), articleIDs(want)
+	if fmt.Sprint(g) != fmt.Sprint(w) {
+		t.Errorf("%s: got %v, want %v", name, g, w)
+	}
+}
+
+// sameTime 比较时间（存储精度为毫秒）
+func sameTime(a, b time.Time) bool {
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+func TestArticleRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		accountID := primitive.NewObjectID()
+		otherAccount := primitive.NewObjectID()
+
+		a1 := newArticle(accountID, "Go 语言并发编程", "https://mp.weixin.qq.com/s/aaa", 1000)
+		a2 := newArticle(accountID, "数据库索引设计", "https://mp.weixin.qq.com/s/bbb", 2000)
+		a3 := newArticle(otherAccount, "并发与锁", "https://mp.weixin.qq.com/s/ccc", 3000)
+		dup := newArticle(accountID, "重复", "http://mp.weixin.qq.com/s/aaa#rd", 1500)
+
+		// 批量写入：同一批内和已存在的重复链接都归为 existing
+		created, existing, err := r.articles.BatchUpsert(ctx, []*model.Article{a1, a2, dup})
+		if err != nil {
+			t.Fatalf("BatchUpsert: %v", err)
+		}
+		if len(created) != 2 || len(existing) != 1 || existing[0] != dup {
+			t.Fatalf("BatchUpsert: got %d created, %d existing", len(created), len(existing))
+		}
+		if a1.ID.IsZero() || a2.ID.IsZero() || !dup.ID.IsZero() {
+			t.Fatalf("BatchUpsert: 新增文章应填充ID，已存在的不应填充")
+		}
+		if err := r.articles.Create(ctx, a3); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		created, existing, err = r.articles.BatchUpsert(ctx, []*model.Article{newArticle(accountID, "再次", a2.ContentURL, 2000)})
+		if err != nil || len(created) != 0 || len(existing) != 1 {
+			t.Fatalf("BatchUpsert 已存在: got %d created, %d existing, err %v", len(created), len(existing), err)
+		}
+		if _, _, err := r.articles.BatchUpsert(ctx, []*model.Article{newArticle(accountID, "无链接", "", 1)}); err == nil {
+			t.Errorf("BatchUpsert 链接为空应返回错误")
+		}
+
+		// 查询
+		got, err := r.articles.FindByID(ctx, a1.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.Title != a1.Title || got.AccountID != accountID || got.PublishTime != 1000 ||
+			got.FetchStatus != model.ArticleFetchSuccess || got.Text != a1.Text || !sameTime(got.CreatedAt, a1.CreatedAt) {
+			t.Errorf("FindByID: got %+v", got)
+		}
+		if _, err := r.articles.FindByID(ctx, primitive.NewObjectID()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByID 不存在: got %v, want ErrNotFound", err)
+		}
+		if got, err := r.articles.FindByContentURL(ctx, "https://mp.weixin.qq.com/s/aaa/"); err != nil || got.ID != a1.ID {
+			t.Errorf("FindByContentURL: got %v, %v", got, err)
+		}
+		if _, err := r.articles.FindByContentURL(ctx, "https://mp.weixin.qq.com/s/zzz"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByContentURL 不存在: got %v, want ErrNotFound", err)
+		}
+		if ok, err := r.articles.ExistsByContentURL(ctx, "http://mp.weixin.qq.com/s/bbb"); err != nil || !ok {
+			t.Errorf("ExistsByContentURL: got %v, %v", ok, err)
+		}
+		if ok, err := r.articles.ExistsByContentURL(ctx, "https://mp.weixin.qq.com/s/zzz"); err != nil || ok {
+			t.Errorf("ExistsByContentURL 不存在: got %v, %v", ok, err)
+		}
+
+		// 列表（按发布时间倒序）
+		list, total, err := r.articles.ListByAccountID(ctx, accountID, 1, 1)
+		if err != nil || total != 2 {
+			t.Fatalf("ListByAccountID: total %d, err %v", total, err)
+		}
+		checkIDs(t, "ListByAccountID", list, a2)
+		list, total, err = r.articles.List(ctx, 2, 2)
+		if err != nil || total != 3 {
+			t.Fatalf("List: total %d, err %v", total, err)
+		}
+		checkIDs(t, "List 第2页", list, a1)
+		if n, err := r.articles.CountByAccountID(ctx, accountID); err != nil || n != 2 {
+			t.Errorf("CountByAccountID: got %d, %v", n, err)
+		}
+		if latest, err := r.articles.GetLatestByAccountID(ctx, accountID); err != nil || latest.ID != a2.ID {
+			t.Errorf("GetLatestByAccountID: got %v, %v", latest, err)
+		}
+		if times, err := r.articles.ListPublishTimes(ctx, accountID, 1000, 10); err != nil || fmt.Sprint(times) != "[2000]" {
+			t.Errorf("ListPublishTimes: got %v, %v", times, err)
+		}
+
+		// 筛选
+		filters := []struct {
+			name   string
+			filter model.ArticleFilter
+			want   []*model.Article
+		}{
+			{"全部", model.ArticleFilter{}, []*model.Article{a3, a2, a1}},
+			{"公众号", model.ArticleFilter{AccountID: otherAccount.Hex()}, []*model.Article{a3}},
+			{"关键词", model.ArticleFilter{Keyword: "并发"}, []*model.Article{a3, a1}},
+			{"大小写", model.ArticleFilter{Keyword: "go"}, []*model.Article{a1}},
+			{"短语", model.ArticleFilter{Keyword: `"语言并发"`}, []*model.Article{a1}},
+			{"排除", model.ArticleFilter{Keyword: "并发 -锁"}, []*model.Article{a1}},
+			{"或", model.ArticleFilter{Keyword: "索引 OR 锁"}, []*model.Article{a3, a2}},
+			{"通配符", model.ArticleFilter{Keyword: "100%"}, nil},
+			{"时间范围", model.ArticleFilter{StartTime: 1500, EndTime: 2500}, []*model.Article{a2}},
+			{"正文缺失", model.ArticleFilter{MissingContent: true}, nil},
+		}
+		for _, tc := range filters {
+			list, total, err := r.articles.ListWithFilter(ctx, tc.filter, 1, 10)
+			if err != nil {
+				t.Errorf("ListWithFilter %s: %v", tc.name, err)
+				continue
+			}
+			if total != int64(len(tc.want)) {
+				t.Errorf("ListWithFilter %s: total %d, want %d", tc.name, total, len(tc.want))
+			}
+			checkIDs(t, "ListWithFilter "+tc.name, list, tc.want...)
+		}
+
+		// 正文获取失败与重试
+		now := time.Now()
+		if err := r.articles.UpdateFetchFailure(ctx, a2.ID, model.ArticleFetchPending, 1, "超时", now.Add(-time.Minute)); err != nil {
+			t.Fatalf("UpdateFetchFailure: %v", err)
+		}
+		if err := r.articles.UpdateFetchFailure(ctx, a3.ID, model.ArticleFetchPending, 1, "超时", now.Add(time.Hour)); err != nil {
+			t.Fatalf("UpdateFetchFailure: %v", err)
+		}
+		pending, err := r.articles.ListPendingFetch(ctx, now, 10)
+		if err != nil {
+			t.Fatalf("ListPendingFetch: %v", err)
+		}
+		checkIDs(t, "ListPendingFetch", pending, a2)
+		if pending[0].FetchError != "超时" || pending[0].FetchAttempts != 1 || !sameTime(pending[0].NextFetchAt, now.Add(-time.Minute)) {
+			t.Errorf("ListPendingFetch: got %+v", pending[0])
+		}
+		extract := model.ArticleExtract{
+			Markdown:     "新正文",
+			Text:         "新正文 分布式",
+			Meta:         &model.ArticleMeta{WordCount: 5, Images: []string{"https://example.com/a.png"}},
+			SearchTokens: search.Tokens(a2.Title, a2.Author, a2.Digest, "新正文 分布式"),
+		}
+		if err := r.articles.UpdateContent(ctx, a2.ID, "<p>新正文</p>", extract, 2); err != nil {
+			t.Fatalf("UpdateContent: %v", err)
+		}
+		got, _ = r.articles.FindByID(ctx, a2.ID)
+		if got.Content != "<p>新正文</p>" || got.FetchStatus != model.ArticleFetchSuccess || got.FetchAttempts != 2 ||
+			got.FetchError != "" || !got.NextFetchAt.IsZero() || got.Meta == nil || got.Meta.WordCount != 5 {
+			t.Errorf("UpdateContent: got %+v", got)
+		}
+		list, _, _ = r.articles.ListWithFilter(ctx, model.ArticleFilter{Keyword: "分布式"}, 1, 10)
+		checkIDs(t, "UpdateContent 后搜索", list, a2)
+
+		// 正文内容生成
+		missing, err := r.articles.ListMissingExtract(ctx, primitive.NilObjectID, false, 10)
+		if err != nil {
+			t.Fatalf("ListMissingExtract: %v", err)
+		}
+		checkIDs(t, "ListMissingExtract", missing, a1, a3)
+		if missing, _ := r.articles.ListMissingExtract(ctx, a1.ID, false, 10); len(missing) != 1 || missing[0].ID != a3.ID {
+			t.Errorf("ListMissingExtract afterID: got %v", articleIDs(missing))
+		}
+		archivedAt := now.Add(-time.Second)
+		extract = model.ArticleExtract{
+			Text:            a1.Text,
+			Meta:            &model.ArticleMeta{WordCount: 3},
+			LocalCover:      "/media/cover.jpg",
+			MediaArchivedAt: archivedAt,
+			MediaFailed:     1,
+			SearchTokens:    a1.SearchTokens,
+		}
+		if err := r.articles.UpdateExtract(ctx, a1.ID, extract); err != nil {
+			t.Fatalf("UpdateExtract: %v", err)
+		}
+		got, _ = r.articles.FindByID(ctx, a1.ID)
+		if got.LocalCover != "/media/cover.jpg" || got.MediaFailed != 1 || !sameTime(got.MediaArchivedAt, archivedAt) {
+			t.Errorf("UpdateExtract: got %+v", got.ArticleExtract)
+		}
+		missing, _ = r.articles.ListMissingExtract(ctx, primitive.NilObjectID, true, 10)
+		checkIDs(t, "ListMissingExtract withMedia", missing, a1, a2, a3)
+		extract.LocalCover, extract.MediaArchivedAt, extract.MediaFailed = "x", time.Time{}, 3
+		if err := r.articles.UpdateExtract(ctx, a1.ID, extract); err != nil {
+			t.Fatalf("UpdateExtract: %v", err)
+		}
+		got, _ = r.articles.FindByID(ctx, a1.ID)
+		if got.LocalCover != "" || got.MediaFailed != 0 || !got.MediaArchivedAt.IsZero() {
+			t.Errorf("UpdateExtract 未归档图片应清除归档信息: got %+v", got.ArticleExtract)
+		}
+
+		// 快照
+		snapshot := model.ArticleSnapshot{Format: "pdf", Key: "a.pdf", Size: 10, CapturedAt: now}
+		for i := 0; i < 2; i++ {
+			if err := r.articles.AddSnapshots(ctx, a1.ID, []model.ArticleSnapshot{snapshot}); err != nil {
+				t.Fatalf("AddSnapshots: %v", err)
+			}
+		}
+		got, _ = r.articles.FindByID(ctx, a1.ID)
+		if len(got.Snapshots) != 2 || got.Snapshots[1].Key != "a.pdf" || !sameTime(got.Snapshots[0].CapturedAt, now) {
+			t.Errorf("AddSnapshots: got %+v", got.Snapshots)
+		}
+
+		// 在线状态复查
+		verify, err := r.articles.ListForVerify(ctx, 1500, now, 10)
+		if err != nil {
+			t.Fatalf("ListForVerify: %v", err)
+		}
+		checkIDs(t, "ListForVerify", verify, a3, a2)
+		if err := r.articles.UpdateChecked(ctx, a3.ID, model.ArticleStatusLive, now); err != nil {
+			t.Fatalf("UpdateChecked: %v", err)
+		}
+		change := model.ArticleStatusChange{From: model.ArticleStatusLive, To: model.ArticleStatusDeleted, Reason: "已删除", At: now}
+		if err := r.articles.UpdateStatus(ctx, a2.ID, change); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		verify, _ = r.articles.ListForVerify(ctx, 0, now, 10)
+		checkIDs(t, "ListForVerify 复查后", verify, a1)
+		got, _ = r.articles.FindByID(ctx, a2.ID)
+		if got.Status != model.ArticleStatusDeleted || len(got.StatusHistory) != 1 ||
+			got.StatusHistory[0].Reason != "已删除" || !sameTime(got.StatusChangedAt, now) {
+			t.Errorf("UpdateStatus: got %+v", got)
+		}
+		if n, err := r.articles.CountRemoved(ctx); err != nil || n != 1 {
+			t.Errorf("CountRemoved: got %d, %v", n, err)
+		}
+		for status, want := range map[string][]*model.Article{
+			model.ArticleStatusLive:    {a3, a1},
+			model.ArticleStatusRemoved: {a2},
+			model.ArticleStatusDeleted: {a2},
+		} {
+			list, _, _ := r.articles.ListWithFilter(ctx, model.ArticleFilter{Status: status}, 1, 10)
+			checkIDs(t, "ListWithFilter "+status, list, want...)
+		}
+
+		// 正文修改
+		extract = model.ArticleExtract{Text: "修订后", SearchTokens: search.Tokens(a3.Title, "修订后")}
+		if err := r.articles.UpdateRevision(ctx, a3.ID, "<p>修订后</p>", extract, 2, now); err != nil {
+			t.Fatalf("UpdateRevision: %v", err)
+		}
+		got, _ = r.articles.FindByID(ctx, a3.ID)
+		if got.Content != "<p>修订后</p>" || got.Revisions != 2 || !sameTime(got.ContentChangedAt, now) {
+			t.Errorf("UpdateRevision: got %+v", got)
+		}
+		list, _, _ = r.articles.ListWithFilter(ctx, model.ArticleFilter{Keyword: "修订"}, 1, 10)
+		checkIDs(t, "UpdateRevision 后搜索", list, a3)
+
+		// 正文缺失与删除
+		if n, err := r.articles.CountMissingContent(ctx); err != nil || n != 0 {
+			t.Errorf("CountMissingContent: got %d, %v", n, err)
+		}
+		if err := r.articles.Delete(ctx, a3.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := r.articles.FindByID(ctx, a3.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete 后 FindByID: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestArticleMarksConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		accountID := primitive.NewObjectID()
+		a1 := newArticle(accountID, "文章一", "https://mp.weixin.qq.com/s/m1", 1000)
+		a2 := newArticle(accountID, "文章二", "https://mp.weixin.qq.com/s/m2", 2000)
+		a3 := newArticle(primitive.NewObjectID(), "文章三", "https://mp.weixin.qq.com/s/m3", 3000)
+		if _, _, err := r.articles.BatchUpsert(ctx, []*model.Article{a1, a2, a3}); err != nil {
+			t.Fatalf("BatchUpsert: %v", err)
+		}
+		list := func(f model.ArticleFilter) []*model.Article {
+			t.Helper()
+			articles, total, err := r.articles.ListWithFilter(ctx, f, 1, 10)
+			if err != nil || total != int64(len(articles)) {
+				t.Fatalf("ListWithFilter %+v: total %d, err %v", f, total, err)
+			}
+			return articles
+		}
+
+		// 已读状态按用户区分
+		if n, err := r.articles.MarkRead(ctx, []primitive.ObjectID{a1.ID, a3.ID, primitive.NewObjectID()}, "alice", true); err != nil || n != 2 {
+			t.Errorf("MarkRead: got %d, %v", n, err)
+		}
+		if n, err := r.articles.MarkRead(ctx, []primitive.ObjectID{a1.ID}, "alice", true); err != nil || n != 0 {
+			t.Errorf("MarkRead 重复标记: got %d, %v", n, err)
+		}
+		checkIDs(t, "alice 已读", list(model.ArticleFilter{User: "alice", Read: model.ArticleRead}), a3, a1)
+		checkIDs(t, "alice 未读", list(model.ArticleFilter{User: "alice", Read: model.ArticleUnread}), a2)
+		checkIDs(t, "bob 未读", list(model.ArticleFilter{User: "bob", Read: model.ArticleUnread}), a3, a2, a1)
+		checkIDs(t, "未指定用户", list(model.ArticleFilter{Read: model.ArticleRead}), a3, a2, a1)
+		if n, err := r.articles.MarkAccountRead(ctx, accountID, "alice"); err != nil || n != 1 {
+			t.Errorf("MarkAccountRead: got %d, %v", n, err)
+		}
+		if n, err := r.articles.MarkRead(ctx, []primitive.ObjectID{a3.ID}, "alice", false); err != nil || n != 1 {
+			t.Errorf("MarkRead 未读: got %d, %v", n, err)
+		}
+		checkIDs(t, "alice 未读", list(model.ArticleFilter{User: "alice", Read: model.ArticleUnread}), a3)
+		if _, err := r.articles.MarkRead(ctx, []primitive.ObjectID{a2.ID}, "bob", true); err != nil {
+			t.Fatalf("MarkRead: %v", err)
+		}
+		got, err := r.articles.FindByID(ctx, a2.ID)
+		if err != nil || !got.IsReadBy("alice") || !got.IsReadBy("bob") || got.IsReadBy("carol") {
+			t.Errorf("FindByID 已读用户: got %v, %v", got.ReadBy, err)
+		}
+
+		// 星标
+		if err := r.articles.SetStarred(ctx, []primitive.ObjectID{a1.ID, a2.ID}, "alice", true); err != nil {
+			t.Fatalf("SetStarred: %v", err)
+		}
+		if err := r.articles.SetStarred(ctx, []primitive.ObjectID{a2.ID}, "alice", false); err != nil {
+			t.Fatalf("SetStarred: %v", err)
+		}
+		checkIDs(t, "alice 星标", list(model.ArticleFilter{User: "alice", Starred: true}), a1)
+		checkIDs(t, "bob 星标", list(model.ArticleFilter{User: "bob", Starred: true}))
+		checkIDs(t, "alice 星标且未读", list(model.ArticleFilter{User: "alice", Starred: true, Read: model.ArticleUnread}))
+
+		// 标签
+		if err := r.articles.AddTags(ctx, []primitive.ObjectID{a1.ID, a2.ID}, []string{"待审", "行业"}); err != nil {
+			t.Fatalf("AddTags: %v", err)
+		}
+		if err := r.articles.AddTags(ctx, []primitive.ObjectID{a2.ID, a3.ID}, []string{"行业"}); err != nil {
+			t.Fatalf("AddTags: %v", err)
+		}
+		if err := r.articles.RemoveTags(ctx, []primitive.ObjectID{a1.ID}, []string{"待审", "不存在"}); err != nil {
+			t.Fatalf("RemoveTags: %v", err)
+		}
+		checkIDs(t, "标签 行业", list(model.ArticleFilter{Tag: "行业"}), a3, a2, a1)
+		checkIDs(t, "标签 待审", list(model.ArticleFilter{Tag: "待审"}), a2)
+		checkIDs(t, "标签且星标", list(model.ArticleFilter{Tag: "行业", User: "alice", Starred: true}), a1)
+		got, _ = r.articles.FindByID(ctx, a2.ID)
+		tags := append([]string(nil), got.Tags...)
+		sort.Strings(tags)
+		if fmt.Sprint(tags) != "[待审 行业]" {
+			t.Errorf("FindByID 标签: got %v", got.Tags)
+		}
+		if page, _, _ := r.articles.ListByAccountID(ctx, accountID, 1, 10); len(page) != 2 || len(page[1].Tags) != 1 || !page[1].IsStarredBy("alice") {
+			t.Errorf("ListByAccountID 应包含标签和星标: got %+v", page)
+		}
+		counts, err := r.articles.ListTags(ctx)
+		if err != nil || fmt.Sprint(counts) != "[{行业 3} {待审 1}]" {
+			t.Errorf("ListTags: got %v, %v", counts, err)
+		}
+
+		// 删除文章后不再统计其标签
+		if err := r.articles.Delete(ctx, a3.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if counts, _ := r.articles.ListTags(ctx); fmt.Sprint(counts) != "[{行业 2} {待审 1}]" {
+			t.Errorf("Delete 后 ListTags: got %v", counts)
+		}
+	})
+}
+
+func TestWeChatAccountRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+
+		a := &model.WeChatAccount{Name: "公众号A", Alias: "a", FakeID: "fake-a", Schedule: model.CrawlSchedule{Interval: 30}}
+		b := &model.WeChatAccount{Name: "公众号B", FakeID: "fake-b"}
+		for _, account := range []*model.WeChatAccount{a, b} {
+			if err := r.accounts.Create(ctx, account); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		if a.ID.IsZero() || a.Status != 1 {
+			t.Fatalf("Create: got %+v", a)
+		}
+		if err := r.accounts.Create(ctx, &model.WeChatAccount{Name: "重复", FakeID: "fake-a"}); err == nil {
+			t.Errorf("Create 重复的FakeID应返回错误")
+		}
+
+		got, err := r.accounts.FindByID(ctx, a.ID)
+		if err != nil || got.Name != "公众号A" || got.Alias != "a" || got.Schedule.Interval != 30 || !sameTime(got.CreatedAt, a.CreatedAt) {
+			t.Errorf("FindByID: got %+v, %v", got, err)
+		}
+		if got, err := r.accounts.FindByName(ctx, "公众号B"); err != nil || got.ID != b.ID {
+			t.Errorf("FindByName: got %v, %v", got, err)
+		}
+		if got, err := r.accounts.FindByFakeID(ctx, "fake-a"); err != nil || got.ID != a.ID {
+			t.Errorf("FindByFakeID: got %v, %v", got, err)
+		}
+		if _, err := r.accounts.FindByName(ctx, "不存在"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByName 不存在: got %v, want ErrNotFound", err)
+		}
+
+		// 更新
+		now := time.Now()
+		got.LastArticle = "https://mp.weixin.qq.com/s/aaa"
+		got.Pattern = &model.PostingPattern{HotHours: []int{8, 20}, Samples: 10, UpdatedAt: now}
+		if err := r.accounts.Update(ctx, got); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if err := r.accounts.UpdateSchedule(ctx, a.ID, model.CrawlSchedule{Cron: "0 8 * * *", Priority: 2}, now); err != nil {
+			t.Fatalf("UpdateSchedule: %v", err)
+		}
+		if err := r.accounts.UpdateCrawlTime(ctx, a.ID, now, now.Add(time.Hour)); err != nil {
+			t.Fatalf("UpdateCrawlTime: %v", err)
+		}
+		if err := r.accounts.UpdateLastArticle(ctx, a.ID, "https://mp.weixin.qq.com/s/bbb"); err != nil {
+			t.Fatalf("UpdateLastArticle: %v", err)
+		}
+		pattern := &model.PostingPattern{HotHours: []int{9}, ActiveWeekdays: []int{1, 2}, Samples: 20}
+		pattern.Hours[9] = 5
+		if err := r.accounts.UpdatePattern(ctx, a.ID, pattern); err != nil {
+			t.Fatalf("UpdatePattern: %v", err)
+		}
+		got, _ = r.accounts.FindByID(ctx, a.ID)
+		if got.LastArticle != "https://mp.weixin.qq.com/s/bbb" || got.Schedule.Cron != "0 8 * * *" || got.Schedule.Priority != 2 ||
+			!sameTime(got.LastCrawlAt, now) || !sameTime(got.NextCrawlAt, now.Add(time.Hour)) ||
+			!got.Pattern.Ready() || got.Pattern.Hours[9] != 5 || got.Pattern.Samples != 20 {
+			t.Errorf("更新后: got %+v", got)
+		}
+
+		// 回溯
+		if err := r.accounts.UpdateBackfill(ctx, b.ID, &model.BackfillState{Status: model.BackfillStatusRunning, Begin: 5, StartedAt: now}); err != nil {
+			t.Fatalf("UpdateBackfill: %v", err)
+		}
+		backfilling, err := r.accounts.ListBackfilling(ctx)
+		if err != nil || len(backfilling) != 1 || backfilling[0].ID != b.ID || backfilling[0].Backfill.Begin != 5 {
+			t.Errorf("ListBackfilling: got %v, %v", backfilling, err)
+		}
+
+		// 软删除
+		if err := r.accounts.Delete(ctx, b.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		list, err := r.accounts.List(ctx)
+		if err != nil || len(list) != 1 || list[0].ID != a.ID {
+			t.Errorf("List: got %v, %v", list, err)
+		}
+		if n, err := r.accounts.Count(ctx); err != nil || n != 1 {
+			t.Errorf("Count: got %d, %v", n, err)
+		}
+		if backfilling, _ := r.accounts.ListBackfilling(ctx); len(backfilling) != 0 {
+			t.Errorf("ListBackfilling 删除后: got %d", len(backfilling))
+		}
+		if got, err := r.accounts.FindByID(ctx, b.ID); err != nil || got.Status != 0 {
+			t.Errorf("Delete 后 FindByID: got %v, %v", got, err)
+		}
+		if _, err := r.accounts.FindByName(ctx, "公众号B"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete 后 FindByName: got %v, want ErrNotFound", err)
+		}
+
+		// 删除后可以重新添加同一公众号
+		readded := &model.WeChatAccount{Name: "公众号B", FakeID: "fake-b"}
+		if err := r.accounts.Create(ctx, readded); err != nil {
+			t.Fatalf("Create 删除后重新添加: %v", err)
+		}
+		if got, err := r.accounts.FindByFakeID(ctx, "fake-b"); err != nil || got.ID != readded.ID {
+			t.Errorf("重新添加后 FindByFakeID: got %v, %v", got, err)
+		}
+		if err := r.accounts.Create(ctx, &model.WeChatAccount{Name: "重复", FakeID: "fake-b"}); err == nil {
+			t.Errorf("Create 与正常状态的公众号FakeID重复应返回错误")
+		}
+	})
+}
+
+func TestFeishuConfigRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+
+		config, err := r.feishu.GetConfig(ctx)
+		if err != nil || !config.ID.IsZero() || config.Enabled || config.NotifyTime != "09:00" {
+			t.Fatalf("GetConfig 默认配置: got %+v, %v", config, err)
+		}
+
+		config.WebhookURL = "https://open.feishu.cn/hook/1"
+		config.Enabled = true
+		if err := r.feishu.UpsertConfig(ctx, config); err != nil {
+			t.Fatalf("UpsertConfig: %v", err)
+		}
+		if config.ID.IsZero() {
+			t.Fatalf("UpsertConfig 应填充ID")
+		}
+		if err := r.feishu.UpsertConfig(ctx, &model.FeishuConfig{WebhookURL: "https://open.feishu.cn/hook/2", NotifyRemoved: true}); err != nil {
+			t.Fatalf("UpsertConfig: %v", err)
+		}
+		got, err := r.feishu.GetConfig(ctx)
+		if err != nil || got.ID != config.ID || got.WebhookURL != "https://open.feishu.cn/hook/2" || got.Enabled || !got.NotifyRemoved {
+			t.Errorf("UpsertConfig 应更新已有配置: got %+v, %v", got, err)
+		}
+
+		got.NotifyTime = "18:30"
+		if err := r.feishu.SaveConfig(ctx, got); err != nil {
+			t.Fatalf("SaveConfig: %v", err)
+		}
+		if got, err := r.feishu.GetConfig(ctx); err != nil || got.NotifyTime != "18:30" {
+			t.Errorf("SaveConfig: got %+v, %v", got, err)
+		}
+	})
+}
+
+func TestSettingsRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		if got, err := r.settings.Get(ctx); err != nil || got != nil {
+			t.Fatalf("Get 未保存: got %+v, %v", got, err)
+		}
+
+		updatedAt := time.Now().Add(-time.Minute)
+		record := &model.SettingsRecord{Overrides: model.RuntimeSettings{CrawlInterval: 30}, UpdatedBy: "alice", UpdatedAt: updatedAt}
+		if err := r.settings.Save(ctx, record); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := r.settings.Save(ctx, &model.SettingsRecord{Overrides: model.RuntimeSettings{FetchCount: 5}, UpdatedBy: "bob"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		got, err := r.settings.Get(ctx)
+		if err != nil || got.ID != model.RuntimeSettingsID || got.UpdatedBy != "bob" ||
+			got.Overrides != (model.RuntimeSettings{FetchCount: 5}) || got.UpdatedAt.IsZero() {
+			t.Errorf("Save 应整条替换: got %+v, %v", got, err)
+		}
+
+		for i, operator := range []string{"alice", "bob", "carol"} {
+			change := &model.SettingsChange{
+				Operator:  operator,
+				Action:    model.SettingsActionUpdate,
+				After:     model.RuntimeSettings{Timeout: 10 * (i + 1)},
+				CreatedAt: updatedAt.Add(time.Duration(i) * time.Second),
+			}
+			if operator == "carol" {
+				change.Changed = []string{"timeout"}
+			}
+			if err := r.settings.AddHistory(ctx, change); err != nil || change.ID.IsZero() {
+				t.Fatalf("AddHistory: %v", err)
+			}
+		}
+		changes, total, err := r.settings.ListHistory(ctx, 1, 2)
+		if err != nil || total != 3 || len(changes) != 2 {
+			t.Fatalf("ListHistory: got %d/%d, %v", len(changes), total, err)
+		}
+		if changes[0].Operator != "carol" || changes[0].After.Timeout != 30 || fmt.Sprint(changes[0].Changed) != "[timeout]" ||
+			changes[1].Operator != "bob" || changes[1].Changed == nil {
+			t.Errorf("ListHistory: got %+v, %+v", changes[0], changes[1])
+		}
+	})
+}
+
+func TestCrawlRunRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		startedAt := time.Now().Add(-time.Hour)
+		cron := &model.CrawlRun{Trigger: model.CrawlTriggerCron, Status: model.CrawlRunRunning, StartedAt: startedAt}
+		manual := &model.CrawlRun{Trigger: model.CrawlTriggerManual, Operator: "alice", Status: model.CrawlRunRunning}
+		for _, run := range []*model.CrawlRun{cron, manual} {
+			if err := r.runs.Create(ctx, run); err != nil || run.ID.IsZero() {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		accountID := primitive.NewObjectID()
+		if err := r.runs.UpdateTotal(ctx, manual.ID, 2); err != nil {
+			t.Fatalf("UpdateTotal: %v", err)
+		}
+		results := []model.CrawlRunAccount{
+			{AccountID: accountID, AccountName: "公众号A", Status: model.CrawlAccountSuccess, NewCount: 3, StartedAt: startedAt},
+			{AccountName: "公众号B", Status: model.CrawlAccountFailed, Error: "超时", NewCount: 1},
+		}
+		for _, result := range results {
+			if err := r.runs.AddAccountResult(ctx, manual.ID, result); err != nil {
+				t.Fatalf("AddAccountResult: %v", err)
+			}
+		}
+		if err := r.runs.Finish(ctx, manual.ID, model.CrawlRunPartial, "部分失败"); err != nil {
+			t.Fatalf("Finish: %v", err)
+		}
+
+		got, err := r.runs.FindByID(ctx, manual.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.Operator != "alice" || got.Status != model.CrawlRunPartial || got.Error != "部分失败" || got.Total != 2 ||
+			got.NewArticles != 4 || got.FinishedAt.IsZero() || len(got.Accounts) != 2 {
+			t.Fatalf("FindByID: got %+v", got)
+		}
+		if a := got.Accounts[0]; a.AccountID != accountID || a.AccountName != "公众号A" || a.NewCount != 3 || !sameTime(a.StartedAt, startedAt) {
+			t.Errorf("FindByID 第1个公众号: got %+v", a)
+		}
+		if a := got.Accounts[1]; a.AccountName != "公众号B" || a.Error != "超时" {
+			t.Errorf("FindByID 第2个公众号: got %+v", a)
+		}
+		if _, err := r.runs.FindByID(ctx, primitive.NewObjectID()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindByID 不存在: got %v, want ErrNotFound", err)
+		}
+
+		// 重启后未结束的任务标记为中断
+		if n, err := r.runs.MarkInterrupted(ctx); err != nil || n != 1 {
+			t.Errorf("MarkInterrupted: got %d, %v", n, err)
+		}
+		if got, _ := r.runs.FindByID(ctx, cron.ID); got.Status != model.CrawlRunInterrupted || got.Accounts == nil {
+			t.Errorf("MarkInterrupted: got %+v", got)
+		}
+
+		runs, total, err := r.runs.List(ctx, "", 1, 10)
+		if err != nil || total != 2 || len(runs) != 2 || runs[0].ID != manual.ID || len(runs[0].Accounts) != 2 {
+			t.Errorf("List: got %d/%d, %v", len(runs), total, err)
+		}
+		runs, total, err = r.runs.List(ctx, model.CrawlTriggerCron, 1, 10)
+		if err != nil || total != 1 || len(runs) != 1 || runs[0].ID != cron.ID {
+			t.Errorf("List cron: got %d/%d, %v", len(runs), total, err)
+		}
+	})
+}
+
+func TestArticleRevisionRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		articleID := primitive.NewObjectID()
+		if got, err := r.revisions.Latest(ctx, articleID); err != nil || got != nil {
+			t.Fatalf("Latest 没有版本: got %+v, %v", got, err)
+		}
+
+		for i := 1; i <= 2; i++ {
+			revision := &model.ArticleRevision{
+				ArticleID: articleID,
+				Revision:  i,
+				Content:   fmt.Sprintf("<p>第%d版</p>", i),
+				Text:      fmt.Sprintf("第%d版", i),
+				TextHash:  fmt.Sprint("hash", i),
+				Source:    model.RevisionSourceVerify,
+				CreatedAt: time.Now(),
+			}
+			if err := r.revisions.Create(ctx, revision); err != nil || revision.ID.IsZero() {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		if err := r.revisions.Create(ctx, &model.ArticleRevision{ArticleID: articleID, Revision: 2}); err == nil {
+			t.Errorf("Create 重复版本号应返回错误")
+		}
+
+		latest, err := r.revisions.Latest(ctx, articleID)
+		if err != nil || latest.Revision != 2 || latest.Content != "" || latest.Text != "第2版" || latest.TextHash != "hash2" {
+			t.Errorf("Latest: got %+v, %v", latest, err)
+		}
+		list, err := r.revisions.ListByArticle(ctx, articleID)
+		if err != nil || len(list) != 2 || list[0].Revision != 1 || list[1].Revision != 2 || list[0].Text != "" || list[0].Content != "" {
+			t.Errorf("ListByArticle: got %v, %v", list, err)
+		}
+		got, err := r.revisions.Get(ctx, articleID, 1)
+		if err != nil || got.Content != "<p>第1版</p>" || got.Text != "第1版" || got.Source != model.RevisionSourceVerify {
+			t.Errorf("Get: got %+v, %v", got, err)
+		}
+		if _, err := r.revisions.Get(ctx, articleID, 3); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get 不存在: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestMediaRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		if got, err := r.media.FindByURL(ctx, "https://mmbiz.qpic.cn/a.png"); err != nil || got != nil {
+			t.Fatalf("FindByURL 不存在: got %+v, %v", got, err)
+		}
+
+		file := &model.MediaFile{Hash: "abc", Key: "images/ab/abc.png", ContentType: "image/png", Size: 42, CreatedAt: time.Now()}
+		for _, url := range []string{"https://mmbiz.qpic.cn/a.png", "https://mmbiz.qpic.cn/b.png", "https://mmbiz.qpic.cn/a.png"} {
+			if err := r.media.Save(ctx, file, url); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+		}
+		// 相同内容只保留第一次的记录
+		if err := r.media.Save(ctx, &model.MediaFile{Hash: "abc", Key: "other"}, "https://mmbiz.qpic.cn/c.png"); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		got, err := r.media.FindByURL(ctx, "https://mmbiz.qpic.cn/b.png")
+		if err != nil || got == nil {
+			t.Fatalf("FindByURL: got %+v, %v", got, err)
+		}
+		urls := append([]string(nil), got.URLs...)
+		sort.Strings(urls)
+		if got.Key != file.Key || got.ContentType != "image/png" || got.Size != 42 || !sameTime(got.CreatedAt, file.CreatedAt) ||
+			fmt.Sprint(urls) != "[https://mmbiz.qpic.cn/a.png https://mmbiz.qpic.cn/b.png https://mmbiz.qpic.cn/c.png]" {
+			t.Errorf("FindByURL: got %+v", got)
+		}
+		if got, err := r.media.FindByHash(ctx, "abc"); err != nil || got == nil || got.Key != file.Key {
+			t.Errorf("FindByHash: got %+v, %v", got, err)
+		}
+		if got, err := r.media.FindByHash(ctx, "zzz"); err != nil || got != nil {
+			t.Errorf("FindByHash 不存在: got %+v, %v", got, err)
+		}
+	})
+}
+
+func TestLeaseRepoConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		ttl := 300 * time.Millisecond
+		if ok, err := r.leases.TryAcquire(ctx, "scheduler", "a", ttl); err != nil || !ok {
+			t.Fatalf("TryAcquire 空闲: got %v, %v", ok, err)
+		}
+		first, err := r.leases.Get(ctx, "scheduler")
+		if err != nil || first == nil || first.Owner != "a" || !first.ExpiresAt.After(first.HeartbeatAt) {
+			t.Fatalf("Get: got %+v, %v", first, err)
+		}
+		if ok, err := r.leases.TryAcquire(ctx, "scheduler", "b", ttl); err != nil || ok {
+			t.Errorf("TryAcquire 被其他实例持有: got %v, %v", ok, err)
+		}
+		if ok, err := r.leases.TryAcquire(ctx, "other", "b", ttl); err != nil || !ok {
+			t.Errorf("TryAcquire 其他租约: got %v, %v", ok, err)
+		}
+
+		// 续约不改变获得时间
+		time.Sleep(10 * time.Millisecond)
+		if ok, err := r.leases.TryAcquire(ctx, "scheduler", "a", ttl); err != nil || !ok {
+			t.Fatalf("TryAcquire 续约: got %v, %v", ok, err)
+		}
+		renewed, _ := r.leases.Get(ctx, "scheduler")
+		if !sameTime(renewed.AcquiredAt, first.AcquiredAt) || !renewed.HeartbeatAt.After(first.HeartbeatAt) {
+			t.Errorf("续约: got %+v, first %+v", renewed, first)
+		}
+
+		// 过期后其他实例接管并重置获得时间
+		time.Sleep(ttl + 50*time.Millisecond)
+		if ok, err := r.leases.TryAcquire(ctx, "scheduler", "b", ttl); err != nil || !ok {
+			t.Fatalf("TryAcquire 过期后接管: got %v, %v", ok, err)
+		}
+		taken, _ := r.leases.Get(ctx, "scheduler")
+		if taken.Owner != "b" || !taken.AcquiredAt.After(first.AcquiredAt) {
+			t.Errorf("接管: got %+v", taken)
+		}
+
+		// 只有持有者可以释放
+		if err := r.leases.Release(ctx, "scheduler", "a"); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if got, _ := r.leases.Get(ctx, "scheduler"); got == nil || got.Owner != "b" {
+			t.Errorf("非持有者释放不应生效: got %+v", got)
+		}
+		if err := r.leases.Release(ctx, "scheduler", "b"); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if got, err := r.leases.Get(ctx, "scheduler"); err != nil || got != nil {
+			t.Errorf("释放后: got %+v, %v", got, err)
+		}
+		if ok, err := r.leases.TryAcquire(ctx, "scheduler", "a", ttl); err != nil || !ok {
+			t.Errorf("释放后获取: got %v, %v", ok, err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCrawlRunRepo 基于MongoDB的爬取任务执行记录数据访问层
+type MongoCrawlRunRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCrawlRunRepo 创建基于MongoDB的爬取任务记录仓库实例
+func NewMongoCrawlRunRepo() *MongoCrawlRunRepo {
+	return &MongoCrawlRunRepo{
+		collection: database.GetCollection(model.CrawlRun{}.TableName()),
+	}
+}
+
+// Create 创建执行记录
+func (r *MongoCrawlRunRepo) Create(ctx context.Context, run *model.CrawlRun) error {
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	if run.Accounts == nil {
+		run.Accounts = []model.CrawlRunAccount{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, run)
+	if err != nil {
+		return err
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// AddAccountResult 追加单个公众号的爬取结果并累加新增文章数
+func (r *MongoCrawlRunRepo) AddAccountResult(ctx context.Context, id primitive.ObjectID, result model.CrawlRunAccount) error {
+	update := bson.M{
+		"$push": bson.M{"accounts": result},
+		"$inc":  bson.M{"new_articles": result.NewCount},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// UpdateTotal 更新待爬取的公众号数量
+func (r *MongoCrawlRunRepo) UpdateTotal(ctx context.Context, id primitive.ObjectID, total int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"total": total}})
+	return err
+}
+
+// Finish 结束执行记录
+func (r *MongoCrawlRunRepo) Finish(ctx context.Context, id primitive.ObjectID, status, errMsg string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"error":       errMsg,
+			"finished_at": time.Now(),
+		},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// MarkInterrupted 将服务重启前未结束的执行记录标记为中断
+func (r *MongoCrawlRunRepo) MarkInterrupted(ctx context.Context) (int64, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      model.CrawlRunInterrupted,
+			"error":       "服务重启，任务中断",
+			"finished_at": time.Now(),
+		},
+	}
+	result, err := r.collection.UpdateMany(ctx, bson.M{"status": model.CrawlRunRunning}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindByID 根据ID查找执行记录，不存在时返回 ErrNotFound
+func (r *MongoCrawlRunRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CrawlRun, error) {
+	var run model.CrawlRun
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&run); err != nil {
+		return nil, notFound(err)
+	}
+	return &run, nil
+}
+
+// List 分页查询执行记录（按开始时间倒序），trigger为空时不筛选
+func (r *MongoCrawlRunRepo) List(ctx context.Context, trigger string, page, pageSize int64) ([]*model.CrawlRun, int64, error) {
+	filter := bson.M{}
+	if trigger != "" {
+		filter["trigger"] = trigger
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*model.CrawlRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
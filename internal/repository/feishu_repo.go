@@ -12,20 +12,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// FeishuConfigRepo 飞书配置数据访问层
-type FeishuConfigRepo struct {
+// MongoFeishuConfigRepo 基于MongoDB的飞书配置数据访问层
+type MongoFeishuConfigRepo struct {
 	collection *mongo.Collection
 }
 
-// NewFeishuConfigRepo 创建飞书配置仓库实例
-func NewFeishuConfigRepo() *FeishuConfigRepo {
-	return &FeishuConfigRepo{
+// NewMongoFeishuConfigRepo 创建基于MongoDB的飞书配置仓库实例
+func NewMongoFeishuConfigRepo() *MongoFeishuConfigRepo {
+	return &MongoFeishuConfigRepo{
 		collection: database.GetCollection(model.FeishuConfig{}.TableName()),
 	}
 }
 
 // GetConfig 获取飞书配置（只有一条配置）
-func (r *FeishuConfigRepo) GetConfig(ctx context.Context) (*model.FeishuConfig, error) {
+func (r *MongoFeishuConfigRepo) GetConfig(ctx context.Context) (*model.FeishuConfig, error) {
 	var config model.FeishuConfig
 	err := r.collection.FindOne(ctx, bson.M{}).Decode(&config)
 	if err != nil {
@@ -44,7 +44,7 @@ func (r *FeishuConfigRepo) GetConfig(ctx context.Context) (*model.FeishuConfig,
 }
 
 // SaveConfig 保存飞书配置
-func (r *FeishuConfigRepo) SaveConfig(ctx context.Context, config *model.FeishuConfig) error {
+func (r *MongoFeishuConfigRepo) SaveConfig(ctx context.Context, config *model.FeishuConfig) error {
 	config.UpdatedAt = time.Now()
 
 	// 如果ID为空，表示新建
@@ -62,12 +62,13 @@ func (r *FeishuConfigRepo) SaveConfig(ctx context.Context, config *model.FeishuC
 	filter := bson.M{"_id": config.ID}
 	update := bson.M{
 		"$set": bson.M{
-			"webhook_url":   config.WebhookURL,
-			"enabled":       config.Enabled,
-			"notify_time":   config.NotifyTime,
-			"notify_title":  config.NotifyTitle,
-			"notify_period": config.NotifyPeriod,
-			"updated_at":    config.UpdatedAt,
+			"webhook_url":    config.WebhookURL,
+			"enabled":        config.Enabled,
+			"notify_time":    config.NotifyTime,
+			"notify_title":   config.NotifyTitle,
+			"notify_period":  config.NotifyPeriod,
+			"notify_removed": config.NotifyRemoved,
+			"updated_at":     config.UpdatedAt,
 		},
 	}
 
@@ -76,7 +77,7 @@ func (r *FeishuConfigRepo) SaveConfig(ctx context.Context, config *model.FeishuC
 }
 
 // UpsertConfig 更新或插入配置（确保只有一条配置记录）
-func (r *FeishuConfigRepo) UpsertConfig(ctx context.Context, config *model.FeishuConfig) error {
+func (r *MongoFeishuConfigRepo) UpsertConfig(ctx context.Context, config *model.FeishuConfig) error {
 	config.UpdatedAt = time.Now()
 
 	// 查找现有配置
@@ -105,12 +106,13 @@ func (r *FeishuConfigRepo) UpsertConfig(ctx context.Context, config *model.Feish
 	filter := bson.M{"_id": config.ID}
 	update := bson.M{
 		"$set": bson.M{
-			"webhook_url":   config.WebhookURL,
-			"enabled":       config.Enabled,
-			"notify_time":   config.NotifyTime,
-			"notify_title":  config.NotifyTitle,
-			"notify_period": config.NotifyPeriod,
-			"updated_at":    config.UpdatedAt,
+			"webhook_url":    config.WebhookURL,
+			"enabled":        config.Enabled,
+			"notify_time":    config.NotifyTime,
+			"notify_title":   config.NotifyTitle,
+			"notify_period":  config.NotifyPeriod,
+			"notify_removed": config.NotifyRemoved,
+			"updated_at":     config.UpdatedAt,
 		},
 	}
 
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLeaseRepo 基于MongoDB的租约数据访问层
+type MongoLeaseRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoLeaseRepo 创建基于MongoDB的租约仓库实例
+func NewMongoLeaseRepo() *MongoLeaseRepo {
+	return &MongoLeaseRepo{
+		collection: database.GetCollection(model.Lease{}.TableName()),
+	}
+}
+
+// TryAcquire 尝试获取或续约租约，租约未被持有、已过期或由 owner 持有时成功
+// 其他实例持有未过期的租约时返回 false
+func (r *MongoLeaseRepo) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"$expr": bson.M{"$lte": bson.A{"$expires_at", "$$NOW"}}},
+		},
+	}
+	// 使用数据库服务器时间，$owner 引用的是更新前的持有者，换人时重置获得时间
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"acquired_at": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$owner", owner}}, "$acquired_at", "$$NOW",
+			}},
+			"owner":        owner,
+			"heartbeat_at": "$$NOW",
+			"expires_at":   bson.M{"$add": bson.A{"$$NOW", ttl.Milliseconds()}},
+		}}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// 租约由其他实例持有时 filter 不匹配，upsert 插入同名文档导致主键冲突
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release 释放 owner 持有的租约，其他实例可以立即接管
+func (r *MongoLeaseRepo) Release(ctx context.Context, name, owner string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
+
+// Get 获取租约，不存在时返回nil
+func (r *MongoLeaseRepo) Get(ctx context.Context, name string) (*model.Lease, error) {
+	var lease model.Lease
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lease, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoMediaRepo 基于MongoDB的归档文件数据访问层
+type MongoMediaRepo struct {
+	collection *mongo.Collection
+}
+
+// NewMongoMediaRepo 创建基于MongoDB的归档文件仓库实例
+func NewMongoMediaRepo() *MongoMediaRepo {
+	return &MongoMediaRepo{
+		collection: database.GetCollection(model.MediaFile{}.TableName()),
+	}
+}
+
+// FindByURL 根据原始地址查询已归档的文件，不存在时返回nil
+func (r *MongoMediaRepo) FindByURL(ctx context.Context, url string) (*model.MediaFile, error) {
+	var file model.MediaFile
+	err := r.collection.FindOne(ctx, bson.M{"urls": url}).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindByHash 根据内容摘要查询已归档的文件，不存在时返回nil
+func (r *MongoMediaRepo) FindByHash(ctx context.Context, hash string) (*model.MediaFile, error) {
+	var file model.MediaFile
+	err := r.collection.FindOne(ctx, bson.M{"hash": hash}).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Save 保存归档记录，相同内容的文件已存在时只追加原始地址
+func (r *MongoMediaRepo) Save(ctx context.Context, file *model.MediaFile, url string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"hash": file.Hash}, bson.M{
+		"$setOnInsert": bson.M{
+			"key":          file.Key,
+			"content_type": file.ContentType,
+			"size":         file.Size,
+			"created_at":   file.CreatedAt,
+		},
+		"$addToSet": bson.M{"urls": url},
+	}, options.Update().SetUpsert(true))
+	return err
+}
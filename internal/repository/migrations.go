@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Migrations 数据库迁移列表，新增迁移时追加新的版本号，已发布的迁移不要修改
+func Migrations() []database.Migration {
+	return []database.Migration{
+		{
+			Version:     1,
+			Description: "创建文章列表、待获取正文和全文搜索索引",
+			Up: database.CreateIndexes(model.Article{}.TableName(),
+				mongo.IndexModel{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "publish_time", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "publish_time", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "fetch_status", Value: 1}, {Key: "next_fetch_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "search_tokens", Value: 1}}},
+			),
+		},
+		{
+			Version:     2,
+			Description: "删除重复文章并创建文章链接唯一索引",
+			Up:          uniqueContentURL,
+		},
+		{
+			Version:     3,
+			Description: "创建公众号 fake_id 唯一索引",
+			Up:          uniqueFakeID,
+		},
+		{
+			Version:     4,
+			Description: "创建文章版本唯一索引",
+			Up: database.CreateIndexes(model.ArticleRevision{}.TableName(),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "article_id", Value: 1}, {Key: "revision", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			),
+		},
+		{
+			Version:     5,
+			Description: "创建归档文件内容摘要唯一索引和原始地址索引",
+			Up: database.CreateIndexes(model.MediaFile{}.TableName(),
+				mongo.IndexModel{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "urls", Value: 1}}},
+			),
+		},
+		{
+			// 持有者异常退出后残留的租约由数据库自动清理
+			Version:     6,
+			Description: "创建租约过期时间TTL索引",
+			Up: database.CreateIndexes(model.Lease{}.TableName(),
+				mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+			),
+		},
+		{
+			Version:     7,
+			Description: "创建爬取记录时间索引",
+			Up: database.CreateIndexes(model.CrawlRun{}.TableName(),
+				mongo.IndexModel{Keys: bson.D{{Key: "trigger", Value: 1}, {Key: "started_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "started_at", Value: -1}}},
+			),
+		},
+		{
+			Version:     8,
+			Description: "生成文章规范化链接并创建唯一索引",
+			Up:          uniqueURLKey,
+		},
+		{
+			Version:     9,
+			Description: "创建文章标签和星标索引",
+			Up: database.CreateIndexes(model.Article{}.TableName(),
+				mongo.IndexModel{Keys: bson.D{{Key: "tags", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "starred_by", Value: 1}}},
+			),
+		},
+		{
+			Version:     10,
+			Description: "公众号 fake_id 唯一索引只约束正常状态的公众号",
+			Up:          activeFakeID,
+		},
+	}
+}
+
+// duplicateArticle 重复文章中用于决定保留哪一篇的信息
+type duplicateArticle struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	HasContent bool               `bson:"has_content"`
+}
+
+// keepArticle 在同一链接的重复文章中选出保留的一篇：优先保留已获取到正文的文章，都有或都没有正文时保留最早采集的
+// articles 需按 _id 升序，返回保留的文章和需要删除的文章ID
+func keepArticle(articles []duplicateArticle) (duplicateArticle, []primitive.ObjectID) {
+	keep := 0
+	for i, article := range articles {
+		if article.HasContent {
+			keep = i
+			break
+		}
+	}
+	removed := make([]primitive.ObjectID, 0, len(articles)-1)
+	for i, article := range articles {
+		if i != keep {
+			removed = append(removed, article.ID)
+		}
+	}
+	return articles[keep], removed
+}
+
+// deleteDuplicateArticles 删除重复文章及其版本记录
+func deleteDuplicateArticles(ctx context.Context, db *mongo.Database, ids bson.A) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := db.Collection(model.Article{}.TableName()).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("删除重复文章失败: %w", err)
+	}
+	revisions := db.Collection(model.ArticleRevision{}.TableName())
+	if _, err := revisions.DeleteMany(ctx, bson.M{"article_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("删除重复文章的版本记录失败: %w", err)
+	}
+	return nil
+}
+
+// logDuplicateArticles 记录删除的重复文章，便于事后核对
+func logDuplicateArticles(url string, kept duplicateArticle, removed []primitive.ObjectID) {
+	ids := make([]string, len(removed))
+	for i, id := range removed {
+		ids[i] = id.Hex()
+	}
+	logger.Warn("删除重复文章",
+		zap.String("url", url),
+		zap.String("kept_id", kept.ID.Hex()),
+		zap.Bool("kept_has_content", kept.HasContent),
+		zap.Strings("removed_ids", ids))
+}
+
+// uniqueContentURL 同一链接保留一篇文章（见 keepArticle），删除其余重复文章及其版本记录，然后创建唯一索引
+func uniqueContentURL(ctx context.Context, db *mongo.Database) error {
+	articles := db.Collection(model.Article{}.TableName())
+	cursor, err := articles.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"content_url": bson.M{"$gt": ""}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$content_url",
+			"articles": bson.M{"$push": bson.M{
+				"_id":         "$_id",
+				"has_content": bson.M{"$gt": bson.A{"$content", ""}},
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("查询重复文章失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates bson.A
+	for cursor.Next(ctx) {
+		var group struct {
+			URL      string             `bson:"_id"`
+			Articles []duplicateArticle `bson:"articles"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		kept, removed := keepArticle(group.Articles)
+		logDuplicateArticles(group.URL, kept, removed)
+		for _, id := range removed {
+			duplicates = append(duplicates, id)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if err := deleteDuplicateArticles(ctx, db, duplicates); err != nil {
+		return err
+	}
+
+	return database.CreateIndexes(model.Article{}.TableName(), mongo.IndexModel{
+		Keys: bson.D{{Key: "content_url", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"content_url": bson.M{"$gt": ""}}),
+	})(ctx, db)
+}
+
+// uniqueURLKey 为历史文章生成规范化链接（url_key），删除链接形式不同的重复文章（保留规则见 keepArticle）及其版本记录，
+// 然后创建唯一索引
+func uniqueURLKey(ctx context.Context, db *mongo.Database) error {
+	articles := db.Collection(model.Article{}.TableName())
+	cursor, err := articles.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$project", Value: bson.M{
+			"content_url": 1,
+			"url_key":     1,
+			"has_content": bson.M{"$gt": bson.A{"$content", ""}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("查询文章链接失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// 按规范化链接分组，记录各文章原有的 url_key
+	type keyedArticle struct {
+		duplicateArticle `bson:",inline"`
+		ContentURL       string `bson:"content_url"`
+		URLKey           string `bson:"url_key"`
+	}
+	groups := make(map[string][]keyedArticle)
+	var keys []string
+	for cursor.Next(ctx) {
+		var article keyedArticle
+		if err := cursor.Decode(&article); err != nil {
+			return err
+		}
+		key := model.ArticleURLKey(article.ContentURL)
+		if key == "" {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], article)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	var duplicates bson.A
+	var updates []mongo.WriteModel
+	for _, key := range keys {
+		group := groups[key]
+		kept := group[0]
+		if len(group) > 1 {
+			candidates := make([]duplicateArticle, len(group))
+			for i, article := range group {
+				candidates[i] = article.duplicateArticle
+			}
+			keptArticle, removed := keepArticle(candidates)
+			logDuplicateArticles(key, keptArticle, removed)
+			for _, id := range removed {
+				duplicates = append(duplicates, id)
+			}
+			for _, article := range group {
+				if article.ID == keptArticle.ID {
+					kept = article
+				}
+			}
+		}
+		if kept.URLKey != key {
+			updates = append(updates, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": kept.ID}).
+				SetUpdate(bson.M{"$set": bson.M{"url_key": key}}))
+		}
+	}
+
+	// 先删除重复文章，避免写入 url_key 时与被删除的文章冲突
+	if err := deleteDuplicateArticles(ctx, db, duplicates); err != nil {
+		return err
+	}
+	for start := 0; start < len(updates); start += 1000 {
+		end := min(start+1000, len(updates))
+		if _, err := articles.BulkWrite(ctx, updates[start:end], options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("保存文章规范化链接失败: %w", err)
+		}
+	}
+
+	return database.CreateIndexes(model.Article{}.TableName(), mongo.IndexModel{
+		Keys: bson.D{{Key: "url_key", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"url_key": bson.M{"$gt": ""}}),
+	})(ctx, db)
+}
+
+// uniqueFakeID 创建公众号 fake_id 唯一索引
+// 公众号关联着文章，存在重复时不自动删除，需要在管理后台删除重复的公众号后重新执行迁移
+func uniqueFakeID(ctx context.Context, db *mongo.Database) error {
+	accounts := db.Collection(model.WeChatAccount{}.TableName())
+	cursor, err := accounts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"fake_id": bson.M{"$gt": ""}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$fake_id", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return fmt.Errorf("查询重复公众号失败: %w", err)
+	}
+	var duplicates []struct {
+		FakeID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		fakeIDs := make([]string, len(duplicates))
+		for i, d := range duplicates {
+			fakeIDs[i] = d.FakeID
+		}
+		return fmt.Errorf("以下 fake_id 存在重复的公众号，请删除重复的公众号后重新执行迁移: %v", fakeIDs)
+	}
+
+	return database.CreateIndexes(model.WeChatAccount{}.TableName(), mongo.IndexModel{
+		Keys: bson.D{{Key: "fake_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"fake_id": bson.M{"$gt": ""}}),
+	})(ctx, db)
+}
+
+// activeFakeID 重建公众号 fake_id 唯一索引，只约束正常状态（status=1）的公众号
+// 已删除（软删除）的公众号保留原 fake_id，重新添加同一公众号时不再冲突
+func activeFakeID(ctx context.Context, db *mongo.Database) error {
+	accounts := db.Collection(model.WeChatAccount{}.TableName())
+	if _, err := accounts.Indexes().DropOne(ctx, "fake_id_1"); err != nil {
+		// 集合或索引不存在（26/27）时直接创建新索引
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Code != 26 && cmdErr.Code != 27) {
+			return fmt.Errorf("删除 fake_id 索引失败: %w", err)
+		}
+	}
+
+	return database.CreateIndexes(model.WeChatAccount{}.TableName(), mongo.IndexModel{
+		Keys: bson.D{{Key: "fake_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"fake_id": bson.M{"$gt": ""}, "status": 1}),
+	})(ctx, db)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKeepArticle(t *testing.T) {
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+
+	tests := []struct {
+		name     string
+		articles []duplicateArticle
+		want     primitive.ObjectID
+	}{
+		{
+			name:     "都没有正文时保留最早的",
+			articles: []duplicateArticle{{ID: ids[0]}, {ID: ids[1]}, {ID: ids[2]}},
+			want:     ids[0],
+		},
+		{
+			name:     "优先保留有正文的",
+			articles: []duplicateArticle{{ID: ids[0]}, {ID: ids[1], HasContent: true}, {ID: ids[2], HasContent: true}},
+			want:     ids[1],
+		},
+		{
+			name:     "都有正文时保留最早的",
+			articles: []duplicateArticle{{ID: ids[0], HasContent: true}, {ID: ids[1], HasContent: true}},
+			want:     ids[0],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, removed := keepArticle(tt.articles)
+			if kept.ID != tt.want {
+				t.Errorf("保留 %s，期望 %s", kept.ID.Hex(), tt.want.Hex())
+			}
+			if len(removed) != len(tt.articles)-1 {
+				t.Fatalf("删除 %d 篇，期望 %d 篇", len(removed), len(tt.articles)-1)
+			}
+			for _, id := range removed {
+				if id == tt.want {
+					t.Errorf("删除了保留的文章 %s", id.Hex())
+				}
+			}
+		})
+	}
+}
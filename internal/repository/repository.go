@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound 查询的记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// notFound 把MongoDB的 ErrNoDocuments 转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ArticleRepo 文章数据访问接口
+// 所有数据可以保存在MongoDB或关系型数据库（SQLite、PostgreSQL）中，由配置的 database.driver 决定
+type ArticleRepo interface {
+	// Create 创建文章记录
+	Create(ctx context.Context, article *model.Article) error
+	// BatchUpsert 按规范化链接批量写入文章，已存在的文章保持不变，返回新增和已存在的文章
+	BatchUpsert(ctx context.Context, articles []*model.Article) (created, existing []*model.Article, err error)
+	// FindByID 根据ID查询，不存在时返回 ErrNotFound
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Article, error)
+	// FindByContentURL 根据文章链接查询，不存在时返回 ErrNotFound
+	FindByContentURL(ctx context.Context, contentURL string) (*model.Article, error)
+	// ExistsByContentURL 检查文章是否已存在
+	ExistsByContentURL(ctx context.Context, contentURL string) (bool, error)
+	// ListByAccountID 按发布时间倒序查询公众号的文章
+	ListByAccountID(ctx context.Context, accountID primitive.ObjectID, page, pageSize int64) ([]*model.Article, int64, error)
+	// ListWithFilter 按条件查询文章，按发布时间倒序
+	ListWithFilter(ctx context.Context, f model.ArticleFilter, page, pageSize int64) ([]*model.Article, int64, error)
+	// List 按发布时间倒序查询所有文章
+	List(ctx context.Context, page, pageSize int64) ([]*model.Article, int64, error)
+	// ListPendingFetch 查询已到重试时间的正文缺失文章
+	ListPendingFetch(ctx context.Context, now time.Time, limit int64) ([]*model.Article, error)
+	// UpdateContent 保存重试获取到的正文
+	UpdateContent(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, attempts int) error
+	// AddSnapshots 追加页面快照记录
+	AddSnapshots(ctx context.Context, id primitive.ObjectID, snapshots []model.ArticleSnapshot) error
+	// ListMissingExtract 按ID顺序查询尚未生成Markdown、纯文本、检索词或尚未归档图片的文章
+	ListMissingExtract(ctx context.Context, afterID primitive.ObjectID, withMedia bool, limit int64) ([]*model.Article, error)
+	// UpdateExtract 保存由正文生成的内容
+	UpdateExtract(ctx context.Context, id primitive.ObjectID, extract model.ArticleExtract) error
+	// UpdateFetchFailure 记录正文获取失败
+	UpdateFetchFailure(ctx context.Context, id primitive.ObjectID, status string, attempts int, fetchErr string, next time.Time) error
+	// CountRemoved 统计已删除或被屏蔽的文章数量
+	CountRemoved(ctx context.Context) (int64, error)
+	// ListForVerify 查询需要复查在线状态的文章
+	ListForVerify(ctx context.Context, since int64, checkedBefore time.Time, limit int64) ([]*model.Article, error)
+	// UpdateChecked 记录复查结果
+	UpdateChecked(ctx context.Context, id primitive.ObjectID, status string, checkedAt time.Time) error
+	// UpdateStatus 记录文章在线状态的变化
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, change model.ArticleStatusChange) error
+	// UpdateRevision 更新为最新版本的正文
+	UpdateRevision(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, revisions int, changedAt time.Time) error
+	// CountMissingContent 统计正文缺失的文章数量
+	CountMissingContent(ctx context.Context) (int64, error)
+	// GetLatestByAccountID 获取公众号最新的一篇文章，没有文章时返回 ErrNotFound
+	GetLatestByAccountID(ctx context.Context, accountID primitive.ObjectID) (*model.Article, error)
+	// ListPublishTimes 按发布时间倒序查询公众号最近的文章发布时间
+	ListPublishTimes(ctx context.Context, accountID primitive.ObjectID, since int64, limit int64) ([]int64, error)
+	// CountByAccountID 统计公众号文章数量
+	CountByAccountID(ctx context.Context, accountID primitive.ObjectID) (int64, error)
+	// Delete 删除文章
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// MarkRead 设置文章对用户的已读状态，返回状态发生变化的文章数
+	MarkRead(ctx context.Context, ids []primitive.ObjectID, user string, read bool) (int64, error)
+	// MarkAccountRead 把公众号的全部文章标记为用户已读，返回新标记的文章数
+	MarkAccountRead(ctx context.Context, accountID primitive.ObjectID, user string) (int64, error)
+	// SetStarred 设置或取消用户对文章的星标
+	SetStarred(ctx context.Context, ids []primitive.ObjectID, user string, starred bool) error
+	// AddTags 给文章添加标签，已有的标签保持不变
+	AddTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error
+	// RemoveTags 移除文章的标签
+	RemoveTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error
+	// ListTags 查询所有标签及使用的文章数，按文章数倒序
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+}
+
+// WeChatAccountRepo 公众号数据访问接口
+type WeChatAccountRepo interface {
+	// Create 创建公众号记录
+	Create(ctx context.Context, account *model.WeChatAccount) error
+	// FindByID 根据ID查询，不存在时返回 ErrNotFound
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.WeChatAccount, error)
+	// FindByName 根据名称查询正常状态的公众号，不存在或已删除时返回 ErrNotFound
+	FindByName(ctx context.Context, name string) (*model.WeChatAccount, error)
+	// FindByFakeID 根据FakeID查询正常状态的公众号，不存在或已删除时返回 ErrNotFound
+	FindByFakeID(ctx context.Context, fakeID string) (*model.WeChatAccount, error)
+	// List 查询所有正常状态的公众号
+	List(ctx context.Context) ([]*model.WeChatAccount, error)
+	// Update 更新公众号信息
+	Update(ctx context.Context, account *model.WeChatAccount) error
+	// UpdateLastArticle 更新最后一篇文章URL
+	UpdateLastArticle(ctx context.Context, id primitive.ObjectID, articleURL string) error
+	// UpdateSchedule 更新爬取计划和下次爬取时间
+	UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule model.CrawlSchedule, nextCrawlAt time.Time) error
+	// UpdateCrawlTime 更新最近一次爬取时间和下次爬取时间
+	UpdateCrawlTime(ctx context.Context, id primitive.ObjectID, lastCrawlAt, nextCrawlAt time.Time) error
+	// UpdatePattern 更新发文规律
+	UpdatePattern(ctx context.Context, id primitive.ObjectID, pattern *model.PostingPattern) error
+	// UpdateBackfill 更新历史文章回溯进度
+	UpdateBackfill(ctx context.Context, id primitive.ObjectID, state *model.BackfillState) error
+	// ListBackfilling 查询回溯中的公众号
+	ListBackfilling(ctx context.Context) ([]*model.WeChatAccount, error)
+	// Delete 删除公众号（软删除）
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Count 统计正常状态的公众号数量
+	Count(ctx context.Context) (int64, error)
+}
+
+// FeishuConfigRepo 飞书配置数据访问接口
+type FeishuConfigRepo interface {
+	// GetConfig 获取飞书配置，没有保存过时返回默认配置
+	GetConfig(ctx context.Context) (*model.FeishuConfig, error)
+	// SaveConfig 保存飞书配置，ID为空时新建
+	SaveConfig(ctx context.Context, config *model.FeishuConfig) error
+	// UpsertConfig 更新或插入配置（确保只有一条配置记录）
+	UpsertConfig(ctx context.Context, config *model.FeishuConfig) error
+}
+
+// SettingsRepo 运行时设置数据访问接口
+type SettingsRepo interface {
+	// Get 获取保存的设置，从未保存过时返回nil
+	Get(ctx context.Context) (*model.SettingsRecord, error)
+	// Save 保存设置（整条替换）
+	Save(ctx context.Context, record *model.SettingsRecord) error
+	// AddHistory 添加修改记录
+	AddHistory(ctx context.Context, change *model.SettingsChange) error
+	// ListHistory 分页获取修改记录，最近的在前
+	ListHistory(ctx context.Context, page, pageSize int64) ([]*model.SettingsChange, int64, error)
+}
+
+// CrawlRunRepo 爬取任务执行记录数据访问接口
+type CrawlRunRepo interface {
+	// Create 创建执行记录
+	Create(ctx context.Context, run *model.CrawlRun) error
+	// AddAccountResult 追加单个公众号的爬取结果并累加新增文章数
+	AddAccountResult(ctx context.Context, id primitive.ObjectID, result model.CrawlRunAccount) error
+	// UpdateTotal 更新待爬取的公众号数量
+	UpdateTotal(ctx context.Context, id primitive.ObjectID, total int) error
+	// Finish 结束执行记录
+	Finish(ctx context.Context, id primitive.ObjectID, status, errMsg string) error
+	// MarkInterrupted 将服务重启前未结束的执行记录标记为中断
+	MarkInterrupted(ctx context.Context) (int64, error)
+	// FindByID 根据ID查找执行记录，不存在时返回 ErrNotFound
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.CrawlRun, error)
+	// List 分页查询执行记录（按开始时间倒序），trigger为空时不筛选
+	List(ctx context.Context, trigger string, page, pageSize int64) ([]*model.CrawlRun, int64, error)
+}
+
+// ArticleRevisionRepo 文章版本数据访问接口
+type ArticleRevisionRepo interface {
+	// Create 保存一个版本
+	Create(ctx context.Context, revision *model.ArticleRevision) error
+	// Latest 获取文章的最新版本（不含正文HTML），没有版本时返回nil
+	Latest(ctx context.Context, articleID primitive.ObjectID) (*model.ArticleRevision, error)
+	// ListByArticle 按版本号顺序获取文章的所有版本（不含正文HTML和规范化文本）
+	ListByArticle(ctx context.Context, articleID primitive.ObjectID) ([]*model.ArticleRevision, error)
+	// Get 获取文章的指定版本，不存在时返回 ErrNotFound
+	Get(ctx context.Context, articleID primitive.ObjectID, revision int) (*model.ArticleRevision, error)
+}
+
+// MediaRepo 归档文件数据访问接口
+type MediaRepo interface {
+	// FindByURL 根据原始地址查询已归档的文件，不存在时返回nil
+	FindByURL(ctx context.Context, url string) (*model.MediaFile, error)
+	// FindByHash 根据内容摘要查询已归档的文件，不存在时返回nil
+	FindByHash(ctx context.Context, hash string) (*model.MediaFile, error)
+	// Save 保存归档记录，相同内容的文件已存在时只追加原始地址
+	Save(ctx context.Context, file *model.MediaFile, url string) error
+}
+
+// LeaseRepo 租约数据访问接口，过期时间按数据库服务器时钟计算
+type LeaseRepo interface {
+	// TryAcquire 尝试获取或续约租约，其他实例持有未过期的租约时返回 false
+	TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Release 释放 owner 持有的租约
+	Release(ctx context.Context, name, owner string) error
+	// Get 获取租约，不存在时返回nil
+	Get(ctx context.Context, name string) (*model.Lease, error)
+}
+
+var (
+	_ ArticleRepo       = (*MongoArticleRepo)(nil)
+	_ ArticleRepo       = (*SQLArticleRepo)(nil)
+	_ WeChatAccountRepo = (*MongoWeChatAccountRepo)(nil)
+	_ WeChatAccountRepo = (*SQLWeChatAccountRepo)(nil)
+	_ FeishuConfigRepo  = (*MongoFeishuConfigRepo)(nil)
+	_ FeishuConfigRepo  = (*SQLFeishuConfigRepo)(nil)
+
+	_ SettingsRepo        = (*MongoSettingsRepo)(nil)
+	_ SettingsRepo        = (*SQLSettingsRepo)(nil)
+	_ CrawlRunRepo        = (*MongoCrawlRunRepo)(nil)
+	_ CrawlRunRepo        = (*SQLCrawlRunRepo)(nil)
+	_ ArticleRevisionRepo = (*MongoArticleRevisionRepo)(nil)
+	_ ArticleRevisionRepo = (*SQLArticleRevisionRepo)(nil)
+	_ MediaRepo           = (*MongoMediaRepo)(nil)
+	_ MediaRepo           = (*SQLMediaRepo)(nil)
+	_ LeaseRepo           = (*MongoLeaseRepo)(nil)
+	_ LeaseRepo           = (*SQLLeaseRepo)(nil)
+)
+
+// NewArticleRepo 创建文章仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewArticleRepo() ArticleRepo {
+	if database.SQL != nil {
+		return NewSQLArticleRepo(database.SQL)
+	}
+	return NewMongoArticleRepo()
+}
+
+// NewWeChatAccountRepo 创建公众号仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewWeChatAccountRepo() WeChatAccountRepo {
+	if database.SQL != nil {
+		return NewSQLWeChatAccountRepo(database.SQL)
+	}
+	return NewMongoWeChatAccountRepo()
+}
+
+// NewFeishuConfigRepo 创建飞书配置仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewFeishuConfigRepo() FeishuConfigRepo {
+	if database.SQL != nil {
+		return NewSQLFeishuConfigRepo(database.SQL)
+	}
+	return NewMongoFeishuConfigRepo()
+}
+
+// NewSettingsRepo 创建运行时设置仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewSettingsRepo() SettingsRepo {
+	if database.SQL != nil {
+		return NewSQLSettingsRepo(database.SQL)
+	}
+	return NewMongoSettingsRepo()
+}
+
+// NewCrawlRunRepo 创建爬取任务记录仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewCrawlRunRepo() CrawlRunRepo {
+	if database.SQL != nil {
+		return NewSQLCrawlRunRepo(database.SQL)
+	}
+	return NewMongoCrawlRunRepo()
+}
+
+// NewArticleRevisionRepo 创建文章版本仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewArticleRevisionRepo() ArticleRevisionRepo {
+	if database.SQL != nil {
+		return NewSQLArticleRevisionRepo(database.SQL)
+	}
+	return NewMongoArticleRevisionRepo()
+}
+
+// NewMediaRepo 创建归档文件仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewMediaRepo() MediaRepo {
+	if database.SQL != nil {
+		return NewSQLMediaRepo(database.SQL)
+	}
+	return NewMongoMediaRepo()
+}
+
+// NewLeaseRepo 创建租约仓库，启用关系型数据库时使用关系型数据库，否则使用MongoDB
+func NewLeaseRepo() LeaseRepo {
+	if database.SQL != nil {
+		return NewSQLLeaseRepo(database.SQL)
+	}
+	return NewMongoLeaseRepo()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSettingsRepo 基于MongoDB的运行时设置数据访问层
+type MongoSettingsRepo struct {
+	collection *mongo.Collection
+	history    *mongo.Collection
+}
+
+// NewMongoSettingsRepo 创建基于MongoDB的运行时设置仓库实例
+func NewMongoSettingsRepo() *MongoSettingsRepo {
+	return &MongoSettingsRepo{
+		collection: database.GetCollection(model.SettingsRecord{}.TableName()),
+		history:    database.GetCollection(model.SettingsChange{}.TableName()),
+	}
+}
+
+// Get 获取保存的设置，从未保存过时返回nil
+func (r *MongoSettingsRepo) Get(ctx context.Context) (*model.SettingsRecord, error) {
+	var record model.SettingsRecord
+	err := r.collection.FindOne(ctx, bson.M{"_id": model.RuntimeSettingsID}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Save 保存设置（整条替换）
+func (r *MongoSettingsRepo) Save(ctx context.Context, record *model.SettingsRecord) error {
+	record.ID = model.RuntimeSettingsID
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = time.Now()
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": record.ID}, record, opts)
+	return err
+}
+
+// AddHistory 添加修改记录
+func (r *MongoSettingsRepo) AddHistory(ctx context.Context, change *model.SettingsChange) error {
+	if change.CreatedAt.IsZero() {
+		change.CreatedAt = time.Now()
+	}
+	if change.Changed == nil {
+		change.Changed = []string{}
+	}
+
+	result, err := r.history.InsertOne(ctx, change)
+	if err != nil {
+		return err
+	}
+	change.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListHistory 分页获取修改记录，最近的在前
+func (r *MongoSettingsRepo) ListHistory(ctx context.Context, page, pageSize int64) ([]*model.SettingsChange, int64, error) {
+	total, err := r.history.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.history.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []*model.SettingsChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
@@ -0,0 +1,741 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/search"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// articleColumns 文章表的字段（不含 search_text），与 scanArticle 和 articleArgs 的顺序一致
+const articleColumns = `id, account_id, account_name, title, author, digest, content, content_url, url_key, cover,
+	source_url, publish_time, created_at, fetch_status, fetch_attempts, fetch_error, next_fetch_at, status,
+	status_changed_at, last_checked_at, status_history, revisions, content_changed_at, snapshots, clean_html,
+	markdown, text, meta, local_cover, media_archived_at, media_failed`
+
+// articleColumnCount articleColumns 中的字段数
+const articleColumnCount = 31
+
+// SQLArticleRepo 基于关系型数据库的文章数据访问层
+// 全文搜索使用 search_text 列（标题、作者、摘要和正文转为小写、合并空白后以换行分隔），按原文连续匹配关键词
+type SQLArticleRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLArticleRepo 创建基于关系型数据库的文章仓库实例
+func NewSQLArticleRepo(db *database.SQLDB) *SQLArticleRepo {
+	return &SQLArticleRepo{db: db}
+}
+
+// searchText 全文搜索匹配的文本，各字段以换行分隔，关键词不会跨字段匹配
+// 尚未生成检索词（SearchTokens 为空）的文章与MongoDB实现一致不参与搜索，返回NULL
+func searchText(article *model.Article) any {
+	if len(article.SearchTokens) == 0 {
+		return nil
+	}
+	fields := []string{article.Title, article.Author, article.Digest, article.Text}
+	for i, f := range fields {
+		fields[i] = strings.ToLower(strings.Join(strings.Fields(f), " "))
+	}
+	return strings.Join(fields, "\n")
+}
+
+// scanArticle 读取一行文章记录
+func scanArticle(row rowScanner) (*model.Article, error) {
+	var a model.Article
+	var id, accountID string
+	var createdAt, nextFetchAt, statusChangedAt, lastCheckedAt, contentChangedAt, mediaArchivedAt int64
+	var statusHistory, snapshots, meta sql.NullString
+	err := row.Scan(&id, &accountID, &a.AccountName, &a.Title, &a.Author, &a.Digest, &a.Content, &a.ContentURL,
+		&a.URLKey, &a.Cover, &a.SourceURL, &a.PublishTime, &createdAt, &a.FetchStatus, &a.FetchAttempts,
+		&a.FetchError, &nextFetchAt, &a.Status, &statusChangedAt, &lastCheckedAt, &statusHistory, &a.Revisions,
+		&contentChangedAt, &snapshots, &a.CleanHTML, &a.Markdown, &a.Text, &meta, &a.LocalCover,
+		&mediaArchivedAt, &a.MediaFailed)
+	if err != nil {
+		return nil, err
+	}
+	if a.ID, err = parseID(id); err != nil {
+		return nil, err
+	}
+	if a.AccountID, err = parseID(accountID); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(statusHistory, &a.StatusHistory); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(snapshots, &a.Snapshots); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(meta, &a.Meta); err != nil {
+		return nil, err
+	}
+	a.CreatedAt = fromMillis(createdAt)
+	a.NextFetchAt = fromMillis(nextFetchAt)
+	a.StatusChangedAt = fromMillis(statusChangedAt)
+	a.LastCheckedAt = fromMillis(lastCheckedAt)
+	a.ContentChangedAt = fromMillis(contentChangedAt)
+	a.MediaArchivedAt = fromMillis(mediaArchivedAt)
+	return &a, nil
+}
+
+// articleArgs 文章各字段的值，顺序与 articleColumns 一致
+func articleArgs(a *model.Article) ([]any, error) {
+	statusHistory, err := toJSON(a.StatusHistory)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := toJSON(a.Snapshots)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := toJSON(a.Meta)
+	if err != nil {
+		return nil, err
+	}
+	return []any{a.ID.Hex(), a.AccountID.Hex(), a.AccountName, a.Title, a.Author, a.Digest, a.Content, a.ContentURL,
+		a.URLKey, a.Cover, a.SourceURL, a.PublishTime, toMillis(a.CreatedAt), a.FetchStatus, a.FetchAttempts,
+		a.FetchError, toMillis(a.NextFetchAt), a.Status, toMillis(a.StatusChangedAt), toMillis(a.LastCheckedAt),
+		statusHistory, a.Revisions, toMillis(a.ContentChangedAt), snapshots, a.CleanHTML, a.Markdown, a.Text, meta,
+		a.LocalCover, toMillis(a.MediaArchivedAt), a.MediaFailed}, nil
+}
+
+// insertArticle 插入文章，onConflict 为冲突时的处理（如 ON CONFLICT DO NOTHING），返回是否插入
+func insertArticle(ctx context.Context, exec interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, db *database.SQLDB, a *model.Article, onConflict string) (bool, error) {
+	args, err := articleArgs(a)
+	if err != nil {
+		return false, err
+	}
+	args = append(args, searchText(a))
+	result, err := exec.ExecContext(ctx, db.Rebind(`INSERT INTO articles (`+articleColumns+`, search_text)
+		VALUES (`+placeholders(articleColumnCount+1)+`) `+onConflict), args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// queryArticles 查询文章列表
+func (r *SQLArticleRepo) queryArticles(ctx context.Context, query string, args ...any) ([]*model.Article, error) {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []*model.Article
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+	return articles, rows.Err()
+}
+
+// findOne 按条件查询一篇文章，不存在时返回 ErrNotFound
+func (r *SQLArticleRepo) findOne(ctx context.Context, where string, args ...any) (*model.Article, error) {
+	row := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT `+articleColumns+` FROM articles WHERE `+where+` LIMIT 1`), args...)
+	article, err := scanArticle(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadMarks(ctx, []*model.Article{article}); err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// count 按条件统计文章数量
+func (r *SQLArticleRepo) count(ctx context.Context, where string, args ...any) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT COUNT(*) FROM articles WHERE `+where), args...).Scan(&count)
+	return count, err
+}
+
+// exec 执行更新语句
+func (r *SQLArticleRepo) exec(ctx context.Context, query string, args ...any) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...)
+	return err
+}
+
+// page 按条件分页查询文章，按发布时间倒序
+func (r *SQLArticleRepo) page(ctx context.Context, where string, args []any, page, pageSize int64) ([]*model.Article, int64, error) {
+	total, err := r.count(ctx, where, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	articles, err := r.queryArticles(ctx, `SELECT `+articleColumns+` FROM articles WHERE `+where+
+		` ORDER BY publish_time DESC, id DESC LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadMarks(ctx, articles); err != nil {
+		return nil, 0, err
+	}
+	return articles, total, nil
+}
+
+// loadMarks 读取文章的已读用户、星标用户和标签，只在按条件查询单篇文章和分页查询时读取
+func (r *SQLArticleRepo) loadMarks(ctx context.Context, articles []*model.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	byID := make(map[string]*model.Article, len(articles))
+	args := make([]any, len(articles))
+	for i, a := range articles {
+		byID[a.ID.Hex()] = a
+		args[i] = a.ID.Hex()
+	}
+	in := placeholders(len(articles))
+
+	if err := r.loadMark(ctx, `SELECT article_id, username FROM article_reads WHERE article_id IN (`+in+`)
+		ORDER BY created_at, username`, args, byID, func(a *model.Article, v string) { a.ReadBy = append(a.ReadBy, v) }); err != nil {
+		return err
+	}
+	if err := r.loadMark(ctx, `SELECT article_id, username FROM article_stars WHERE article_id IN (`+in+`)
+		ORDER BY created_at, username`, args, byID, func(a *model.Article, v string) { a.StarredBy = append(a.StarredBy, v) }); err != nil {
+		return err
+	}
+	return r.loadMark(ctx, `SELECT article_id, tag FROM article_tags WHERE article_id IN (`+in+`)
+		ORDER BY created_at, tag`, args, byID, func(a *model.Article, v string) { a.Tags = append(a.Tags, v) })
+}
+
+// loadMark 执行返回 (article_id, 值) 的查询，把值加入对应的文章
+func (r *SQLArticleRepo) loadMark(ctx context.Context, query string, args []any, byID map[string]*model.Article, add func(a *model.Article, v string)) error {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, v string
+		if err := rows.Scan(&id, &v); err != nil {
+			return err
+		}
+		if a := byID[id]; a != nil {
+			add(a, v)
+		}
+	}
+	return rows.Err()
+}
+
+// Create 创建文章记录
+func (r *SQLArticleRepo) Create(ctx context.Context, article *model.Article) error {
+	article.CreatedAt = time.Now()
+	article.URLKey = model.ArticleURLKey(article.ContentURL)
+	article.ID = primitive.NewObjectID()
+	if _, err := insertArticle(ctx, r.db, r.db, article, ""); err != nil {
+		article.ID = primitive.NilObjectID
+		return err
+	}
+	return nil
+}
+
+// BatchUpsert 按规范化链接（url_key）批量写入文章，已存在的文章保持不变
+// 在同一个事务中写入，返回新增和已存在的文章（保持传入顺序），新增文章的ID会被填充
+func (r *SQLArticleRepo) BatchUpsert(ctx context.Context, articles []*model.Article) (created, existing []*model.Article, err error) {
+	if len(articles) == 0 {
+		return nil, nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, article := range articles {
+		article.URLKey = model.ArticleURLKey(article.ContentURL)
+		if article.URLKey == "" {
+			return nil, nil, fmt.Errorf("文章链接为空: %s", article.Title)
+		}
+		article.ID = primitive.NewObjectID()
+		article.CreatedAt = now
+		inserted, err := insertArticle(ctx, tx, r.db, article, `ON CONFLICT DO NOTHING`)
+		if err != nil {
+			return nil, nil, err
+		}
+		if inserted {
+			created = append(created, article)
+		} else {
+			article.ID = primitive.NilObjectID
+			existing = append(existing, article)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return created, existing, nil
+}
+
+// FindByID 根据ID查询
+func (r *SQLArticleRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Article, error) {
+	return r.findOne(ctx, `id = ?`, id.Hex())
+}
+
+// FindByContentURL 根据文章URL查询（用于去重），不同形式的同一文章链接视为相同
+func (r *SQLArticleRepo) FindByContentURL(ctx context.Context, contentURL string) (*model.Article, error) {
+	return r.findOne(ctx, `url_key = ?`, model.ArticleURLKey(contentURL))
+}
+
+// ExistsByContentURL 检查文章是否已存在，不同形式的同一文章链接视为相同
+func (r *SQLArticleRepo) ExistsByContentURL(ctx context.Context, contentURL string) (bool, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT 1 FROM articles WHERE url_key = ? LIMIT 1`),
+		model.ArticleURLKey(contentURL)).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListByAccountID 根据公众号ID查询文章列表
+func (r *SQLArticleRepo) ListByAccountID(ctx context.Context, accountID primitive.ObjectID, page, pageSize int64) ([]*model.Article, int64, error) {
+	return r.page(ctx, `account_id = ?`, []any{accountID.Hex()}, page, pageSize)
+}
+
+// ListWithFilter 根据条件查询文章列表（支持全文搜索、时间范围、公众号、正文缺失、标签、阅读状态和星标筛选）
+func (r *SQLArticleRepo) ListWithFilter(ctx context.Context, f model.ArticleFilter, page, pageSize int64) ([]*model.Article, int64, error) {
+	conds := []string{"1 = 1"}
+	var args []any
+
+	// 公众号筛选
+	if f.AccountID != "" {
+		if objectID, err := primitive.ObjectIDFromHex(f.AccountID); err == nil {
+			conds = append(conds, "account_id = ?")
+			args = append(args, objectID.Hex())
+		}
+	}
+
+	// 全文搜索（标题、作者、摘要和正文）
+	query, err := search.Parse(f.Keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+	if query != nil {
+		cond, searchArgs := sqlSearchFilter(query)
+		conds = append(conds, cond)
+		args = append(args, searchArgs...)
+	}
+
+	// 时间范围筛选
+	if f.StartTime > 0 {
+		conds = append(conds, "publish_time >= ?")
+		args = append(args, f.StartTime)
+	}
+	if f.EndTime > 0 {
+		conds = append(conds, "publish_time <= ?")
+		args = append(args, f.EndTime)
+	}
+
+	// 正文缺失（包括等待重试和已放弃重试的文章）
+	if f.MissingContent {
+		conds = append(conds, "content = ''")
+	}
+
+	// 在线状态，未复查过的文章视为正常
+	switch f.Status {
+	case "":
+	case model.ArticleStatusLive:
+		conds = append(conds, "status NOT IN (?, ?)")
+		args = append(args, model.ArticleStatusDeleted, model.ArticleStatusBlocked)
+	case model.ArticleStatusRemoved:
+		conds = append(conds, "status IN (?, ?)")
+		args = append(args, model.ArticleStatusDeleted, model.ArticleStatusBlocked)
+	default:
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	// 标签
+	if f.Tag != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM article_tags t WHERE t.article_id = articles.id AND t.tag = ?)")
+		args = append(args, f.Tag)
+	}
+
+	// 阅读状态和星标，按当前用户区分
+	if f.User != "" {
+		switch f.Read {
+		case model.ArticleRead:
+			conds = append(conds, "EXISTS (SELECT 1 FROM article_reads m WHERE m.article_id = articles.id AND m.username = ?)")
+			args = append(args, f.User)
+		case model.ArticleUnread:
+			conds = append(conds, "NOT EXISTS (SELECT 1 FROM article_reads m WHERE m.article_id = articles.id AND m.username = ?)")
+			args = append(args, f.User)
+		}
+		if f.Starred {
+			conds = append(conds, "EXISTS (SELECT 1 FROM article_stars m WHERE m.article_id = articles.id AND m.username = ?)")
+			args = append(args, f.User)
+		}
+	}
+
+	return r.page(ctx, strings.Join(conds, " AND "), args, page, pageSize)
+}
+
+// sqlSearchFilter 全文搜索条件，满足任意一组即可，关键词不区分大小写按原文连续匹配
+func sqlSearchFilter(query *search.Query) (string, []any) {
+	var groups []string
+	var args []any
+	for _, g := range query.Groups {
+		var conds []string
+		for _, term := range g.Include {
+			conds = append(conds, `search_text LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(strings.ToLower(term.Text))+"%")
+		}
+		for _, term := range g.Exclude {
+			conds = append(conds, `search_text NOT LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(strings.ToLower(term.Text))+"%")
+		}
+		groups = append(groups, "("+strings.Join(conds, " AND ")+")")
+	}
+	return "(" + strings.Join(groups, " OR ") + ")", args
+}
+
+// List 查询所有文章（分页）
+func (r *SQLArticleRepo) List(ctx context.Context, page, pageSize int64) ([]*model.Article, int64, error) {
+	return r.page(ctx, "1 = 1", nil, page, pageSize)
+}
+
+// ListPendingFetch 查询已到重试时间的正文缺失文章，按重试时间先后排序
+// 没有获取状态的文章中正文为空的文章也一并重试
+func (r *SQLArticleRepo) ListPendingFetch(ctx context.Context, now time.Time, limit int64) ([]*model.Article, error) {
+	return r.queryArticles(ctx, `SELECT `+articleColumns+` FROM articles
+		WHERE (fetch_status = ? AND next_fetch_at <= ?) OR (fetch_status = '' AND content = '')
+		ORDER BY next_fetch_at LIMIT ?`, model.ArticleFetchPending, toMillis(now), limit)
+}
+
+// UpdateContent 保存重试获取到的正文
+func (r *SQLArticleRepo) UpdateContent(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, attempts int) error {
+	return r.updateExtract(ctx, id, extract, `content = ?, fetch_status = ?, fetch_attempts = ?, fetch_error = '', next_fetch_at = 0`,
+		content, model.ArticleFetchSuccess, attempts)
+}
+
+// AddSnapshots 追加页面快照记录
+func (r *SQLArticleRepo) AddSnapshots(ctx context.Context, id primitive.ObjectID, snapshots []model.ArticleSnapshot) error {
+	return r.appendJSON(ctx, id, "snapshots", func(a *model.Article) any {
+		a.Snapshots = append(a.Snapshots, snapshots...)
+		return a.Snapshots
+	})
+}
+
+// appendJSON 在事务中读取文章、修改JSON字段后写回
+func (r *SQLArticleRepo) appendJSON(ctx context.Context, id primitive.ObjectID, column string, update func(a *model.Article) any, extra ...string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var statusHistory, snapshots sql.NullString
+	err = tx.QueryRowContext(ctx, r.db.Rebind(`SELECT status_history, snapshots FROM articles WHERE id = ?`), id.Hex()).
+		Scan(&statusHistory, &snapshots)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var a model.Article
+	if err := fromJSON(statusHistory, &a.StatusHistory); err != nil {
+		return err
+	}
+	if err := fromJSON(snapshots, &a.Snapshots); err != nil {
+		return err
+	}
+
+	value, err := toJSON(update(&a))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, r.db.Rebind(`UPDATE articles SET `+column+` = ? WHERE id = ?`), value, id.Hex()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListMissingExtract 按ID顺序获取 afterID 之后有正文但尚未生成Markdown、纯文本等内容，或尚未生成检索词的文章
+// withMedia 为 true 时同时获取尚未归档图片或有图片归档失败的文章
+func (r *SQLArticleRepo) ListMissingExtract(ctx context.Context, afterID primitive.ObjectID, withMedia bool, limit int64) ([]*model.Article, error) {
+	missing := "meta IS NULL"
+	if withMedia {
+		missing += " OR media_archived_at = 0 OR media_failed > 0"
+	}
+	return r.queryArticles(ctx, `SELECT `+articleColumns+` FROM articles
+		WHERE id > ? AND ((content <> '' AND (`+missing+`)) OR search_text IS NULL)
+		ORDER BY id LIMIT ?`, afterID.Hex(), limit)
+}
+
+// UpdateExtract 保存由正文生成的内容
+func (r *SQLArticleRepo) UpdateExtract(ctx context.Context, id primitive.ObjectID, extract model.ArticleExtract) error {
+	return r.updateExtract(ctx, id, extract, "")
+}
+
+// updateExtract 保存由正文生成的内容和 set 中的其他字段，同时更新全文搜索文本
+// 未归档图片时清除上次的归档信息
+func (r *SQLArticleRepo) updateExtract(ctx context.Context, id primitive.ObjectID, extract model.ArticleExtract, set string, args ...any) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	a := model.Article{ArticleExtract: extract}
+	err = tx.QueryRowContext(ctx, r.db.Rebind(`SELECT title, author, digest FROM articles WHERE id = ?`), id.Hex()).
+		Scan(&a.Title, &a.Author, &a.Digest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	meta, err := toJSON(extract.Meta)
+	if err != nil {
+		return err
+	}
+	// 与MongoDB实现一致，处理过的文章即使没有检索词也不再重新处理
+	text := searchText(&a)
+	if text == nil {
+		text = ""
+	}
+	if extract.MediaArchivedAt.IsZero() {
+		extract.LocalCover = ""
+		extract.MediaFailed = 0
+	}
+
+	if set != "" {
+		set += ", "
+	}
+	args = append(args, extract.CleanHTML, extract.Markdown, extract.Text, meta, text,
+		extract.LocalCover, toMillis(extract.MediaArchivedAt), extract.MediaFailed, id.Hex())
+	_, err = tx.ExecContext(ctx, r.db.Rebind(`UPDATE articles SET `+set+`clean_html = ?, markdown = ?, text = ?,
+		meta = ?, search_text = ?, local_cover = ?, media_archived_at = ?, media_failed = ? WHERE id = ?`), args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateFetchFailure 记录正文获取失败，status 为 pending 时在 next 之后重试
+func (r *SQLArticleRepo) UpdateFetchFailure(ctx context.Context, id primitive.ObjectID, status string, attempts int, fetchErr string, next time.Time) error {
+	return r.exec(ctx, `UPDATE articles SET fetch_status = ?, fetch_attempts = ?, fetch_error = ?, next_fetch_at = ? WHERE id = ?`,
+		status, attempts, fetchErr, toMillis(next), id.Hex())
+}
+
+// CountRemoved 统计已删除或被屏蔽的文章数量
+func (r *SQLArticleRepo) CountRemoved(ctx context.Context) (int64, error) {
+	return r.count(ctx, `status IN (?, ?)`, model.ArticleStatusDeleted, model.ArticleStatusBlocked)
+}
+
+// ListForVerify 获取需要复查在线状态的文章
+// 只复查 since 之后发布、未删除、且 checkedBefore 之后没有复查过的文章，从未复查过的优先
+func (r *SQLArticleRepo) ListForVerify(ctx context.Context, since int64, checkedBefore time.Time, limit int64) ([]*model.Article, error) {
+	return r.queryArticles(ctx, `SELECT `+articleColumns+` FROM articles
+		WHERE publish_time >= ? AND status <> ? AND last_checked_at < ?
+		ORDER BY last_checked_at, publish_time DESC LIMIT ?`,
+		since, model.ArticleStatusDeleted, toMillis(checkedBefore), limit)
+}
+
+// UpdateChecked 记录复查结果，状态未变化时只更新复查时间
+func (r *SQLArticleRepo) UpdateChecked(ctx context.Context, id primitive.ObjectID, status string, checkedAt time.Time) error {
+	return r.exec(ctx, `UPDATE articles SET status = ?, last_checked_at = ? WHERE id = ?`,
+		status, toMillis(checkedAt), id.Hex())
+}
+
+// UpdateStatus 记录文章在线状态的变化，保留已采集的正文
+func (r *SQLArticleRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, change model.ArticleStatusChange) error {
+	if err := r.exec(ctx, `UPDATE articles SET status = ?, status_changed_at = ?, last_checked_at = ? WHERE id = ?`,
+		change.To, toMillis(change.At), toMillis(change.At), id.Hex()); err != nil {
+		return err
+	}
+	return r.appendJSON(ctx, id, "status_history", func(a *model.Article) any {
+		a.StatusHistory = append(a.StatusHistory, change)
+		return a.StatusHistory
+	})
+}
+
+// UpdateRevision 复查发现正文被修改时更新为最新版本的正文，历史版本保存在 article_revisions 中
+func (r *SQLArticleRepo) UpdateRevision(ctx context.Context, id primitive.ObjectID, content string, extract model.ArticleExtract, revisions int, changedAt time.Time) error {
+	return r.updateExtract(ctx, id, extract, `content = ?, revisions = ?, content_changed_at = ?`,
+		content, revisions, toMillis(changedAt))
+}
+
+// CountMissingContent 统计正文缺失的文章数量
+func (r *SQLArticleRepo) CountMissingContent(ctx context.Context) (int64, error) {
+	return r.count(ctx, `content = ''`)
+}
+
+// GetLatestByAccountID 获取公众号最新的一篇文章
+func (r *SQLArticleRepo) GetLatestByAccountID(ctx context.Context, accountID primitive.ObjectID) (*model.Article, error) {
+	return r.findOne(ctx, `account_id = ? ORDER BY publish_time DESC`, accountID.Hex())
+}
+
+// ListPublishTimes 查询公众号最近的文章发布时间（按发布时间倒序），since 为0表示不限制
+func (r *SQLArticleRepo) ListPublishTimes(ctx context.Context, accountID primitive.ObjectID, since int64, limit int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT publish_time FROM articles
+		WHERE account_id = ? AND publish_time > ? ORDER BY publish_time DESC LIMIT ?`), accountID.Hex(), since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := []int64{}
+	for rows.Next() {
+		var t int64
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// CountByAccountID 统计公众号文章数量
+func (r *SQLArticleRepo) CountByAccountID(ctx context.Context, accountID primitive.ObjectID) (int64, error) {
+	return r.count(ctx, `account_id = ?`, accountID.Hex())
+}
+
+// Delete 删除文章及其已读、星标和标签记录
+func (r *SQLArticleRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM article_reads WHERE article_id = ?`,
+		`DELETE FROM article_stars WHERE article_id = ?`,
+		`DELETE FROM article_tags WHERE article_id = ?`,
+		`DELETE FROM articles WHERE id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, r.db.Rebind(query), id.Hex()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// idArgs ID列表转换为查询参数
+func idArgs(ids []primitive.ObjectID) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id.Hex()
+	}
+	return args
+}
+
+// MarkRead 设置文章对用户的已读状态，返回状态发生变化的文章数
+func (r *SQLArticleRepo) MarkRead(ctx context.Context, ids []primitive.ObjectID, user string, read bool) (int64, error) {
+	return r.setUserMark(ctx, "article_reads", ids, user, read)
+}
+
+// MarkAccountRead 把公众号的全部文章标记为用户已读，返回新标记的文章数
+func (r *SQLArticleRepo) MarkAccountRead(ctx context.Context, accountID primitive.ObjectID, user string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO article_reads (article_id, username, created_at)
+		SELECT id, CAST(? AS TEXT), CAST(? AS BIGINT) FROM articles WHERE account_id = ? ON CONFLICT DO NOTHING`),
+		user, toMillis(time.Now()), accountID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SetStarred 设置或取消用户对文章的星标
+func (r *SQLArticleRepo) SetStarred(ctx context.Context, ids []primitive.ObjectID, user string, starred bool) error {
+	_, err := r.setUserMark(ctx, "article_stars", ids, user, starred)
+	return err
+}
+
+// setUserMark 在 table 中添加或删除用户对文章的记录，只处理存在的文章，返回发生变化的文章数
+func (r *SQLArticleRepo) setUserMark(ctx context.Context, table string, ids []primitive.ObjectID, user string, set bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	in := placeholders(len(ids))
+
+	var result sql.Result
+	var err error
+	if set {
+		result, err = r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO `+table+` (article_id, username, created_at)
+			SELECT id, CAST(? AS TEXT), CAST(? AS BIGINT) FROM articles WHERE id IN (`+in+`) ON CONFLICT DO NOTHING`),
+			append([]any{user, toMillis(time.Now())}, idArgs(ids)...)...)
+	} else {
+		result, err = r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM `+table+` WHERE username = ? AND article_id IN (`+in+`)`),
+			append([]any{user}, idArgs(ids)...)...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// AddTags 给文章添加标签，已有的标签保持不变
+func (r *SQLArticleRepo) AddTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error {
+	if len(ids) == 0 || len(tags) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := r.db.Rebind(`INSERT INTO article_tags (article_id, tag, created_at)
+		SELECT id, CAST(? AS TEXT), CAST(? AS BIGINT) FROM articles WHERE id IN (` + placeholders(len(ids)) + `) ON CONFLICT DO NOTHING`)
+	now := toMillis(time.Now())
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, query, append([]any{tag, now}, idArgs(ids)...)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveTags 移除文章的标签
+func (r *SQLArticleRepo) RemoveTags(ctx context.Context, ids []primitive.ObjectID, tags []string) error {
+	if len(ids) == 0 || len(tags) == 0 {
+		return nil
+	}
+	args := idArgs(ids)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	return r.exec(ctx, `DELETE FROM article_tags WHERE article_id IN (`+placeholders(len(ids))+`)
+		AND tag IN (`+placeholders(len(tags))+`)`, args...)
+}
+
+// ListTags 查询所有标签及使用的文章数，按文章数倒序
+func (r *SQLArticleRepo) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT tag, COUNT(*) FROM article_tags GROUP BY tag ORDER BY COUNT(*) DESC, tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.TagCount{}
+	for rows.Next() {
+		var tag model.TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLArticleRevisionRepo 基于关系型数据库的文章版本数据访问层
+type SQLArticleRevisionRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLArticleRevisionRepo 创建基于关系型数据库的文章版本仓库实例
+func NewSQLArticleRevisionRepo(db *database.SQLDB) *SQLArticleRevisionRepo {
+	return &SQLArticleRevisionRepo{db: db}
+}
+
+// queryRevisions 按条件查询文章版本，withContent、withText 为是否读取正文HTML和规范化文本
+func (r *SQLArticleRevisionRepo) queryRevisions(ctx context.Context, withContent, withText bool, where string, args ...any) ([]*model.ArticleRevision, error) {
+	content, text := "''", "''"
+	if withContent {
+		content = "content"
+	}
+	if withText {
+		text = "text"
+	}
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT id, article_id, revision, `+content+`, `+text+`,
+		text_hash, source, created_at FROM article_revisions WHERE `+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*model.ArticleRevision
+	for rows.Next() {
+		var revision model.ArticleRevision
+		var id, articleID string
+		var createdAt int64
+		if err := rows.Scan(&id, &articleID, &revision.Revision, &revision.Content, &revision.Text,
+			&revision.TextHash, &revision.Source, &createdAt); err != nil {
+			return nil, err
+		}
+		if revision.ID, err = parseID(id); err != nil {
+			return nil, err
+		}
+		if revision.ArticleID, err = parseID(articleID); err != nil {
+			return nil, err
+		}
+		revision.CreatedAt = fromMillis(createdAt)
+		revisions = append(revisions, &revision)
+	}
+	return revisions, rows.Err()
+}
+
+// Create 保存一个版本
+func (r *SQLArticleRevisionRepo) Create(ctx context.Context, revision *model.ArticleRevision) error {
+	id := primitive.NewObjectID()
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO article_revisions (id, article_id, revision, content, text,
+		text_hash, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		id.Hex(), revision.ArticleID.Hex(), revision.Revision, revision.Content, revision.Text,
+		revision.TextHash, revision.Source, toMillis(revision.CreatedAt))
+	if err != nil {
+		return err
+	}
+	revision.ID = id
+	return nil
+}
+
+// Latest 获取文章的最新版本（不含正文HTML），没有版本时返回nil
+func (r *SQLArticleRevisionRepo) Latest(ctx context.Context, articleID primitive.ObjectID) (*model.ArticleRevision, error) {
+	revisions, err := r.queryRevisions(ctx, false, true, `article_id = ? ORDER BY revision DESC LIMIT 1`, articleID.Hex())
+	if err != nil || len(revisions) == 0 {
+		return nil, err
+	}
+	return revisions[0], nil
+}
+
+// ListByArticle 按版本号顺序获取文章的所有版本（不含正文HTML和规范化文本）
+func (r *SQLArticleRevisionRepo) ListByArticle(ctx context.Context, articleID primitive.ObjectID) ([]*model.ArticleRevision, error) {
+	return r.queryRevisions(ctx, false, false, `article_id = ? ORDER BY revision`, articleID.Hex())
+}
+
+// Get 获取文章的指定版本，不存在时返回 ErrNotFound
+func (r *SQLArticleRevisionRepo) Get(ctx context.Context, articleID primitive.ObjectID, revision int) (*model.ArticleRevision, error) {
+	revisions, err := r.queryRevisions(ctx, true, true, `article_id = ? AND revision = ?`, articleID.Hex(), revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, ErrNotFound
+	}
+	return revisions[0], nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// crawlRunColumns 执行记录表的字段，与 scanCrawlRun 的顺序一致
+const crawlRunColumns = `id, trigger_type, operator, status, error, total, new_articles, started_at, finished_at`
+
+// SQLCrawlRunRepo 基于关系型数据库的爬取任务执行记录数据访问层
+// 各公众号的爬取结果按追加顺序保存在 crawl_run_accounts 表中
+type SQLCrawlRunRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLCrawlRunRepo 创建基于关系型数据库的爬取任务记录仓库实例
+func NewSQLCrawlRunRepo(db *database.SQLDB) *SQLCrawlRunRepo {
+	return &SQLCrawlRunRepo{db: db}
+}
+
+// scanCrawlRun 读取一行执行记录（不含各公众号的结果）
+func scanCrawlRun(row rowScanner) (*model.CrawlRun, error) {
+	var run model.CrawlRun
+	var id string
+	var startedAt, finishedAt int64
+	err := row.Scan(&id, &run.Trigger, &run.Operator, &run.Status, &run.Error, &run.Total, &run.NewArticles,
+		&startedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if run.ID, err = parseID(id); err != nil {
+		return nil, err
+	}
+	run.StartedAt = fromMillis(startedAt)
+	run.FinishedAt = fromMillis(finishedAt)
+	run.Accounts = []model.CrawlRunAccount{}
+	return &run, nil
+}
+
+// loadAccounts 读取执行记录中各公众号的爬取结果
+func (r *SQLCrawlRunRepo) loadAccounts(ctx context.Context, runs []*model.CrawlRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	byID := make(map[string]*model.CrawlRun, len(runs))
+	args := make([]any, len(runs))
+	for i, run := range runs {
+		byID[run.ID.Hex()] = run
+		args[i] = run.ID.Hex()
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT run_id, account_id, account_name, status, error, new_count,
+		started_at, finished_at FROM crawl_run_accounts WHERE run_id IN (`+placeholders(len(args))+`) ORDER BY run_id, seq`), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result model.CrawlRunAccount
+		var runID, accountID string
+		var startedAt, finishedAt int64
+		if err := rows.Scan(&runID, &accountID, &result.AccountName, &result.Status, &result.Error, &result.NewCount,
+			&startedAt, &finishedAt); err != nil {
+			return err
+		}
+		if result.AccountID, err = parseID(accountID); err != nil {
+			return err
+		}
+		result.StartedAt = fromMillis(startedAt)
+		result.FinishedAt = fromMillis(finishedAt)
+		if run := byID[runID]; run != nil {
+			run.Accounts = append(run.Accounts, result)
+		}
+	}
+	return rows.Err()
+}
+
+// Create 创建执行记录
+func (r *SQLCrawlRunRepo) Create(ctx context.Context, run *model.CrawlRun) error {
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	if run.Accounts == nil {
+		run.Accounts = []model.CrawlRunAccount{}
+	}
+
+	id := primitive.NewObjectID()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.db.Rebind(`INSERT INTO crawl_runs (`+crawlRunColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		id.Hex(), run.Trigger, run.Operator, run.Status, run.Error, run.Total, run.NewArticles,
+		toMillis(run.StartedAt), toMillis(run.FinishedAt))
+	if err != nil {
+		return err
+	}
+	for i, result := range run.Accounts {
+		if err := r.insertAccount(ctx, tx, id, i+1, result); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	run.ID = id
+	return nil
+}
+
+// insertAccount 保存单个公众号的爬取结果，seq 为追加顺序
+func (r *SQLCrawlRunRepo) insertAccount(ctx context.Context, tx *sql.Tx, runID primitive.ObjectID, seq int, result model.CrawlRunAccount) error {
+	_, err := tx.ExecContext(ctx, r.db.Rebind(`INSERT INTO crawl_run_accounts (run_id, seq, account_id, account_name,
+		status, error, new_count, started_at, finished_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		runID.Hex(), seq, result.AccountID.Hex(), result.AccountName, result.Status, result.Error, result.NewCount,
+		toMillis(result.StartedAt), toMillis(result.FinishedAt))
+	return err
+}
+
+// AddAccountResult 追加单个公众号的爬取结果并累加新增文章数
+func (r *SQLCrawlRunRepo) AddAccountResult(ctx context.Context, id primitive.ObjectID, result model.CrawlRunAccount) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seq int
+	if err := tx.QueryRowContext(ctx, r.db.Rebind(`SELECT COALESCE(MAX(seq), 0) FROM crawl_run_accounts WHERE run_id = ?`),
+		id.Hex()).Scan(&seq); err != nil {
+		return err
+	}
+	if err := r.insertAccount(ctx, tx, id, seq+1, result); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, r.db.Rebind(`UPDATE crawl_runs SET new_articles = new_articles + ? WHERE id = ?`),
+		result.NewCount, id.Hex()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateTotal 更新待爬取的公众号数量
+func (r *SQLCrawlRunRepo) UpdateTotal(ctx context.Context, id primitive.ObjectID, total int) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE crawl_runs SET total = ? WHERE id = ?`), total, id.Hex())
+	return err
+}
+
+// Finish 结束执行记录
+func (r *SQLCrawlRunRepo) Finish(ctx context.Context, id primitive.ObjectID, status, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE crawl_runs SET status = ?, error = ?, finished_at = ? WHERE id = ?`),
+		status, errMsg, toMillis(time.Now()), id.Hex())
+	return err
+}
+
+// MarkInterrupted 将服务重启前未结束的执行记录标记为中断
+func (r *SQLCrawlRunRepo) MarkInterrupted(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE crawl_runs SET status = ?, error = ?, finished_at = ?
+		WHERE status = ?`),
+		model.CrawlRunInterrupted, "服务重启，任务中断", toMillis(time.Now()), model.CrawlRunRunning)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// FindByID 根据ID查找执行记录，不存在时返回 ErrNotFound
+func (r *SQLCrawlRunRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CrawlRun, error) {
+	row := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT `+crawlRunColumns+` FROM crawl_runs WHERE id = ?`), id.Hex())
+	run, err := scanCrawlRun(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := r.loadAccounts(ctx, []*model.CrawlRun{run}); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// List 分页查询执行记录（按开始时间倒序），trigger为空时不筛选
+func (r *SQLCrawlRunRepo) List(ctx context.Context, trigger string, page, pageSize int64) ([]*model.CrawlRun, int64, error) {
+	where := "1 = 1"
+	var args []any
+	if trigger != "" {
+		where = "trigger_type = ?"
+		args = append(args, trigger)
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT COUNT(*) FROM crawl_runs WHERE `+where), args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT `+crawlRunColumns+` FROM crawl_runs WHERE `+where+
+		` ORDER BY started_at DESC, id DESC LIMIT ? OFFSET ?`), append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var runs []*model.CrawlRun
+	for rows.Next() {
+		run, err := scanCrawlRun(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+
+	if err := r.loadAccounts(ctx, runs); err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLFeishuConfigRepo 基于关系型数据库的飞书配置数据访问层
+type SQLFeishuConfigRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLFeishuConfigRepo 创建基于关系型数据库的飞书配置仓库实例
+func NewSQLFeishuConfigRepo(db *database.SQLDB) *SQLFeishuConfigRepo {
+	return &SQLFeishuConfigRepo{db: db}
+}
+
+// GetConfig 获取飞书配置（只有一条配置）
+func (r *SQLFeishuConfigRepo) GetConfig(ctx context.Context) (*model.FeishuConfig, error) {
+	var config model.FeishuConfig
+	var id string
+	var createdAt, updatedAt int64
+	err := r.db.QueryRowContext(ctx, `SELECT id, webhook_url, enabled, notify_time, notify_title, notify_period,
+		notify_removed, created_at, updated_at FROM feishu_config ORDER BY created_at LIMIT 1`).Scan(
+		&id, &config.WebhookURL, &config.Enabled, &config.NotifyTime, &config.NotifyTitle, &config.NotifyPeriod,
+		&config.NotifyRemoved, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 返回默认配置
+			return &model.FeishuConfig{
+				Enabled:      false,
+				NotifyTime:   "09:00",
+				NotifyTitle:  "微信公众号文章推送",
+				NotifyPeriod: "daily",
+			}, nil
+		}
+		return nil, err
+	}
+	if config.ID, err = parseID(id); err != nil {
+		return nil, err
+	}
+	config.CreatedAt = fromMillis(createdAt)
+	config.UpdatedAt = fromMillis(updatedAt)
+	return &config, nil
+}
+
+// SaveConfig 保存飞书配置
+func (r *SQLFeishuConfigRepo) SaveConfig(ctx context.Context, config *model.FeishuConfig) error {
+	config.UpdatedAt = time.Now()
+
+	// 如果ID为空，表示新建
+	if config.ID.IsZero() {
+		return r.insert(ctx, config)
+	}
+	return r.update(ctx, config)
+}
+
+// UpsertConfig 更新或插入配置（确保只有一条配置记录）
+func (r *SQLFeishuConfigRepo) UpsertConfig(ctx context.Context, config *model.FeishuConfig) error {
+	config.UpdatedAt = time.Now()
+
+	existingConfig, err := r.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !existingConfig.ID.IsZero() {
+		config.ID = existingConfig.ID
+		return r.update(ctx, config)
+	}
+	config.ID = primitive.NilObjectID
+	return r.insert(ctx, config)
+}
+
+// insert 新建配置
+func (r *SQLFeishuConfigRepo) insert(ctx context.Context, config *model.FeishuConfig) error {
+	id := primitive.NewObjectID()
+	config.CreatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO feishu_config (id, webhook_url, enabled, notify_time,
+		notify_title, notify_period, notify_removed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		id.Hex(), config.WebhookURL, config.Enabled, config.NotifyTime, config.NotifyTitle, config.NotifyPeriod,
+		config.NotifyRemoved, toMillis(config.CreatedAt), toMillis(config.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	config.ID = id
+	return nil
+}
+
+// update 更新现有配置
+func (r *SQLFeishuConfigRepo) update(ctx context.Context, config *model.FeishuConfig) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE feishu_config SET webhook_url = ?, enabled = ?,
+		notify_time = ?, notify_title = ?, notify_period = ?, notify_removed = ?, updated_at = ? WHERE id = ?`),
+		config.WebhookURL, config.Enabled, config.NotifyTime, config.NotifyTitle, config.NotifyPeriod,
+		config.NotifyRemoved, toMillis(config.UpdatedAt), config.ID.Hex())
+	return err
+}
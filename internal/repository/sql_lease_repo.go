@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+)
+
+// SQLLeaseRepo 基于关系型数据库的租约数据访问层
+// 与MongoDB实现一样使用数据库服务器时钟，过期的租约不会被删除，由下一个获取者覆盖
+type SQLLeaseRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLLeaseRepo 创建基于关系型数据库的租约仓库实例
+func NewSQLLeaseRepo(db *database.SQLDB) *SQLLeaseRepo {
+	return &SQLLeaseRepo{db: db}
+}
+
+// TryAcquire 尝试获取或续约租约，租约未被持有、已过期或由 owner 持有时成功
+// 其他实例持有未过期的租约时返回 false
+func (r *SQLLeaseRepo) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := r.db.NowMillis()
+	// 冲突时只有持有者相同或已过期才更新，换人时重置获得时间
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO leases (name, owner, acquired_at, heartbeat_at, expires_at)
+		VALUES (?, ?, `+now+`, `+now+`, `+now+` + CAST(? AS BIGINT))
+		ON CONFLICT (name) DO UPDATE SET
+			acquired_at = CASE WHEN leases.owner = excluded.owner THEN leases.acquired_at ELSE excluded.acquired_at END,
+			owner = excluded.owner, heartbeat_at = excluded.heartbeat_at, expires_at = excluded.expires_at
+		WHERE leases.owner = excluded.owner OR leases.expires_at <= excluded.heartbeat_at`),
+		name, owner, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Release 释放 owner 持有的租约，其他实例可以立即接管
+func (r *SQLLeaseRepo) Release(ctx context.Context, name, owner string) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM leases WHERE name = ? AND owner = ?`), name, owner)
+	return err
+}
+
+// Get 获取租约，不存在或已过期时返回nil
+func (r *SQLLeaseRepo) Get(ctx context.Context, name string) (*model.Lease, error) {
+	var lease model.Lease
+	var acquiredAt, heartbeatAt, expiresAt int64
+	err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT name, owner, acquired_at, heartbeat_at, expires_at FROM leases
+		WHERE name = ? AND expires_at > `+r.db.NowMillis()), name).Scan(
+		&lease.Name, &lease.Owner, &acquiredAt, &heartbeatAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lease.AcquiredAt = fromMillis(acquiredAt)
+	lease.HeartbeatAt = fromMillis(heartbeatAt)
+	lease.ExpiresAt = fromMillis(expiresAt)
+	return &lease, nil
+}
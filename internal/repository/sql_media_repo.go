@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLMediaRepo 基于关系型数据库的归档文件数据访问层，原始地址保存在 media_file_urls 表中
+type SQLMediaRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLMediaRepo 创建基于关系型数据库的归档文件仓库实例
+func NewSQLMediaRepo(db *database.SQLDB) *SQLMediaRepo {
+	return &SQLMediaRepo{db: db}
+}
+
+// findOne 按条件查询一个归档文件及其原始地址，不存在时返回nil
+func (r *SQLMediaRepo) findOne(ctx context.Context, where string, args ...any) (*model.MediaFile, error) {
+	var file model.MediaFile
+	var id string
+	var createdAt int64
+	err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT id, hash, storage_key, content_type, size, created_at
+		FROM media_files WHERE `+where+` LIMIT 1`), args...).Scan(
+		&id, &file.Hash, &file.Key, &file.ContentType, &file.Size, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if file.ID, err = parseID(id); err != nil {
+		return nil, err
+	}
+	file.CreatedAt = fromMillis(createdAt)
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT url FROM media_file_urls WHERE file_id = ? ORDER BY url`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		file.URLs = append(file.URLs, url)
+	}
+	return &file, rows.Err()
+}
+
+// FindByURL 根据原始地址查询已归档的文件，不存在时返回nil
+func (r *SQLMediaRepo) FindByURL(ctx context.Context, url string) (*model.MediaFile, error) {
+	return r.findOne(ctx, `id IN (SELECT file_id FROM media_file_urls WHERE url = ?)`, url)
+}
+
+// FindByHash 根据内容摘要查询已归档的文件，不存在时返回nil
+func (r *SQLMediaRepo) FindByHash(ctx context.Context, hash string) (*model.MediaFile, error) {
+	return r.findOne(ctx, `hash = ?`, hash)
+}
+
+// Save 保存归档记录，相同内容的文件已存在时只追加原始地址
+func (r *SQLMediaRepo) Save(ctx context.Context, file *model.MediaFile, url string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.db.Rebind(`INSERT INTO media_files (id, hash, storage_key, content_type, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`),
+		primitive.NewObjectID().Hex(), file.Hash, file.Key, file.ContentType, file.Size, toMillis(file.CreatedAt))
+	if err != nil {
+		return err
+	}
+	var id string
+	if err := tx.QueryRowContext(ctx, r.db.Rebind(`SELECT id FROM media_files WHERE hash = ?`), file.Hash).Scan(&id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, r.db.Rebind(`INSERT INTO media_file_urls (file_id, url) VALUES (?, ?)
+		ON CONFLICT DO NOTHING`), id, url); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,205 @@
+package repository
+
+import "wechat-crawler/pkg/database"
+
+// SQLMigrations 关系型数据库的迁移列表，语句需同时兼容 SQLite 和 PostgreSQL
+// 时间字段保存为毫秒时间戳（零值为0），嵌套结构保存为JSON文本
+func SQLMigrations() []database.SQLMigration {
+	return []database.SQLMigration{
+		{
+			Version:     1,
+			Description: "创建公众号、文章和飞书配置表",
+			Statements: []string{
+				`CREATE TABLE wechat_accounts (
+					id              TEXT PRIMARY KEY,
+					name            TEXT NOT NULL DEFAULT '',
+					alias           TEXT NOT NULL DEFAULT '',
+					fake_id         TEXT NOT NULL DEFAULT '',
+					url             TEXT NOT NULL DEFAULT '',
+					last_article    TEXT NOT NULL DEFAULT '',
+					status          INTEGER NOT NULL DEFAULT 0,
+					backfill        TEXT,
+					backfill_status TEXT NOT NULL DEFAULT '',
+					schedule        TEXT NOT NULL DEFAULT '{}',
+					last_crawl_at   BIGINT NOT NULL DEFAULT 0,
+					next_crawl_at   BIGINT NOT NULL DEFAULT 0,
+					pattern         TEXT,
+					created_at      BIGINT NOT NULL DEFAULT 0,
+					updated_at      BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE INDEX idx_wechat_accounts_name ON wechat_accounts (name)`,
+				`CREATE UNIQUE INDEX uniq_wechat_accounts_fake_id ON wechat_accounts (fake_id) WHERE fake_id <> ''`,
+
+				`CREATE TABLE articles (
+					id                 TEXT PRIMARY KEY,
+					account_id         TEXT NOT NULL,
+					account_name       TEXT NOT NULL DEFAULT '',
+					title              TEXT NOT NULL DEFAULT '',
+					author             TEXT NOT NULL DEFAULT '',
+					digest             TEXT NOT NULL DEFAULT '',
+					content            TEXT NOT NULL DEFAULT '',
+					content_url        TEXT NOT NULL DEFAULT '',
+					url_key            TEXT NOT NULL DEFAULT '',
+					cover              TEXT NOT NULL DEFAULT '',
+					source_url         TEXT NOT NULL DEFAULT '',
+					publish_time       BIGINT NOT NULL DEFAULT 0,
+					created_at         BIGINT NOT NULL DEFAULT 0,
+					fetch_status       TEXT NOT NULL DEFAULT '',
+					fetch_attempts     INTEGER NOT NULL DEFAULT 0,
+					fetch_error        TEXT NOT NULL DEFAULT '',
+					next_fetch_at      BIGINT NOT NULL DEFAULT 0,
+					status             TEXT NOT NULL DEFAULT '',
+					status_changed_at  BIGINT NOT NULL DEFAULT 0,
+					last_checked_at    BIGINT NOT NULL DEFAULT 0,
+					status_history     TEXT,
+					revisions          INTEGER NOT NULL DEFAULT 0,
+					content_changed_at BIGINT NOT NULL DEFAULT 0,
+					snapshots          TEXT,
+					clean_html         TEXT NOT NULL DEFAULT '',
+					markdown           TEXT NOT NULL DEFAULT '',
+					text               TEXT NOT NULL DEFAULT '',
+					meta               TEXT,
+					local_cover        TEXT NOT NULL DEFAULT '',
+					media_archived_at  BIGINT NOT NULL DEFAULT 0,
+					media_failed       INTEGER NOT NULL DEFAULT 0,
+					search_text        TEXT
+				)`,
+				`CREATE UNIQUE INDEX uniq_articles_url_key ON articles (url_key) WHERE url_key <> ''`,
+				`CREATE INDEX idx_articles_account_publish ON articles (account_id, publish_time DESC)`,
+				`CREATE INDEX idx_articles_publish ON articles (publish_time DESC)`,
+				`CREATE INDEX idx_articles_fetch ON articles (fetch_status, next_fetch_at)`,
+
+				`CREATE TABLE feishu_config (
+					id             TEXT PRIMARY KEY,
+					webhook_url    TEXT NOT NULL DEFAULT '',
+					enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+					notify_time    TEXT NOT NULL DEFAULT '',
+					notify_title   TEXT NOT NULL DEFAULT '',
+					notify_period  TEXT NOT NULL DEFAULT '',
+					notify_removed BOOLEAN NOT NULL DEFAULT FALSE,
+					created_at     BIGINT NOT NULL DEFAULT 0,
+					updated_at     BIGINT NOT NULL DEFAULT 0
+				)`,
+			},
+		},
+		{
+			Version:     2,
+			Description: "创建文章已读、星标和标签表",
+			Statements: []string{
+				`CREATE TABLE article_reads (
+					article_id TEXT NOT NULL,
+					username   TEXT NOT NULL,
+					created_at BIGINT NOT NULL DEFAULT 0,
+					PRIMARY KEY (article_id, username)
+				)`,
+				`CREATE TABLE article_stars (
+					article_id TEXT NOT NULL,
+					username   TEXT NOT NULL,
+					created_at BIGINT NOT NULL DEFAULT 0,
+					PRIMARY KEY (article_id, username)
+				)`,
+				`CREATE INDEX idx_article_stars_username ON article_stars (username)`,
+				`CREATE TABLE article_tags (
+					article_id TEXT NOT NULL,
+					tag        TEXT NOT NULL,
+					created_at BIGINT NOT NULL DEFAULT 0,
+					PRIMARY KEY (article_id, tag)
+				)`,
+				`CREATE INDEX idx_article_tags_tag ON article_tags (tag)`,
+			},
+		},
+		{
+			Version:     3,
+			Description: "创建文章版本、爬取记录、运行时设置、归档文件和租约表",
+			Statements: []string{
+				`CREATE TABLE article_revisions (
+					id         TEXT PRIMARY KEY,
+					article_id TEXT NOT NULL,
+					revision   INTEGER NOT NULL,
+					content    TEXT NOT NULL DEFAULT '',
+					text       TEXT NOT NULL DEFAULT '',
+					text_hash  TEXT NOT NULL DEFAULT '',
+					source     TEXT NOT NULL DEFAULT '',
+					created_at BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE UNIQUE INDEX uniq_article_revisions ON article_revisions (article_id, revision)`,
+
+				`CREATE TABLE crawl_runs (
+					id           TEXT PRIMARY KEY,
+					trigger_type TEXT NOT NULL DEFAULT '',
+					operator     TEXT NOT NULL DEFAULT '',
+					status       TEXT NOT NULL DEFAULT '',
+					error        TEXT NOT NULL DEFAULT '',
+					total        INTEGER NOT NULL DEFAULT 0,
+					new_articles INTEGER NOT NULL DEFAULT 0,
+					started_at   BIGINT NOT NULL DEFAULT 0,
+					finished_at  BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE INDEX idx_crawl_runs_trigger ON crawl_runs (trigger_type, started_at DESC)`,
+				`CREATE INDEX idx_crawl_runs_started ON crawl_runs (started_at DESC)`,
+				`CREATE INDEX idx_crawl_runs_status ON crawl_runs (status)`,
+				`CREATE TABLE crawl_run_accounts (
+					run_id       TEXT NOT NULL,
+					seq          INTEGER NOT NULL,
+					account_id   TEXT NOT NULL DEFAULT '',
+					account_name TEXT NOT NULL DEFAULT '',
+					status       TEXT NOT NULL DEFAULT '',
+					error        TEXT NOT NULL DEFAULT '',
+					new_count    INTEGER NOT NULL DEFAULT 0,
+					started_at   BIGINT NOT NULL DEFAULT 0,
+					finished_at  BIGINT NOT NULL DEFAULT 0,
+					PRIMARY KEY (run_id, seq)
+				)`,
+
+				`CREATE TABLE settings (
+					id         TEXT PRIMARY KEY,
+					overrides  TEXT,
+					updated_by TEXT NOT NULL DEFAULT '',
+					updated_at BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE TABLE settings_history (
+					id              TEXT PRIMARY KEY,
+					operator        TEXT NOT NULL DEFAULT '',
+					action          TEXT NOT NULL DEFAULT '',
+					before_settings TEXT,
+					after_settings  TEXT,
+					changed         TEXT,
+					created_at      BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE INDEX idx_settings_history_created ON settings_history (created_at DESC)`,
+
+				`CREATE TABLE media_files (
+					id           TEXT PRIMARY KEY,
+					hash         TEXT NOT NULL,
+					storage_key  TEXT NOT NULL DEFAULT '',
+					content_type TEXT NOT NULL DEFAULT '',
+					size         BIGINT NOT NULL DEFAULT 0,
+					created_at   BIGINT NOT NULL DEFAULT 0
+				)`,
+				`CREATE UNIQUE INDEX uniq_media_files_hash ON media_files (hash)`,
+				`CREATE TABLE media_file_urls (
+					file_id TEXT NOT NULL,
+					url     TEXT NOT NULL,
+					PRIMARY KEY (file_id, url)
+				)`,
+				`CREATE INDEX idx_media_file_urls_url ON media_file_urls (url)`,
+
+				`CREATE TABLE leases (
+					name         TEXT PRIMARY KEY,
+					owner        TEXT NOT NULL DEFAULT '',
+					acquired_at  BIGINT NOT NULL DEFAULT 0,
+					heartbeat_at BIGINT NOT NULL DEFAULT 0,
+					expires_at   BIGINT NOT NULL DEFAULT 0
+				)`,
+			},
+		},
+		{
+			Version:     4,
+			Description: "公众号 fake_id 唯一索引只约束正常状态的公众号",
+			Statements: []string{
+				`DROP INDEX IF EXISTS uniq_wechat_accounts_fake_id`,
+				`CREATE UNIQUE INDEX uniq_wechat_accounts_fake_id ON wechat_accounts (fake_id) WHERE fake_id <> '' AND status = 1`,
+			},
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLSettingsRepo 基于关系型数据库的运行时设置数据访问层
+type SQLSettingsRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLSettingsRepo 创建基于关系型数据库的运行时设置仓库实例
+func NewSQLSettingsRepo(db *database.SQLDB) *SQLSettingsRepo {
+	return &SQLSettingsRepo{db: db}
+}
+
+// Get 获取保存的设置，从未保存过时返回nil
+func (r *SQLSettingsRepo) Get(ctx context.Context) (*model.SettingsRecord, error) {
+	var record model.SettingsRecord
+	var overrides sql.NullString
+	var updatedAt int64
+	err := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT id, overrides, updated_by, updated_at FROM settings WHERE id = ?`),
+		model.RuntimeSettingsID).Scan(&record.ID, &overrides, &record.UpdatedBy, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := fromJSON(overrides, &record.Overrides); err != nil {
+		return nil, err
+	}
+	record.UpdatedAt = fromMillis(updatedAt)
+	return &record, nil
+}
+
+// Save 保存设置（整条替换）
+func (r *SQLSettingsRepo) Save(ctx context.Context, record *model.SettingsRecord) error {
+	record.ID = model.RuntimeSettingsID
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = time.Now()
+	}
+
+	overrides, err := toJSON(record.Overrides)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO settings (id, overrides, updated_by, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET overrides = excluded.overrides, updated_by = excluded.updated_by,
+		updated_at = excluded.updated_at`),
+		record.ID, overrides, record.UpdatedBy, toMillis(record.UpdatedAt))
+	return err
+}
+
+// AddHistory 添加修改记录
+func (r *SQLSettingsRepo) AddHistory(ctx context.Context, change *model.SettingsChange) error {
+	if change.CreatedAt.IsZero() {
+		change.CreatedAt = time.Now()
+	}
+	if change.Changed == nil {
+		change.Changed = []string{}
+	}
+
+	before, err := toJSON(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := toJSON(change.After)
+	if err != nil {
+		return err
+	}
+	changed, err := toJSON(change.Changed)
+	if err != nil {
+		return err
+	}
+	id := primitive.NewObjectID()
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO settings_history (id, operator, action, before_settings,
+		after_settings, changed, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		id.Hex(), change.Operator, change.Action, before, after, changed, toMillis(change.CreatedAt))
+	if err != nil {
+		return err
+	}
+	change.ID = id
+	return nil
+}
+
+// ListHistory 分页获取修改记录，最近的在前
+func (r *SQLSettingsRepo) ListHistory(ctx context.Context, page, pageSize int64) ([]*model.SettingsChange, int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM settings_history`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT id, operator, action, before_settings, after_settings,
+		changed, created_at FROM settings_history ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`),
+		pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	changes := []*model.SettingsChange{}
+	for rows.Next() {
+		var change model.SettingsChange
+		var id string
+		var before, after, changed sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&id, &change.Operator, &change.Action, &before, &after, &changed, &createdAt); err != nil {
+			return nil, 0, err
+		}
+		if change.ID, err = parseID(id); err != nil {
+			return nil, 0, err
+		}
+		if err := fromJSON(before, &change.Before); err != nil {
+			return nil, 0, err
+		}
+		if err := fromJSON(after, &change.After); err != nil {
+			return nil, 0, err
+		}
+		if err := fromJSON(changed, &change.Changed); err != nil {
+			return nil, 0, err
+		}
+		if change.Changed == nil {
+			change.Changed = []string{}
+		}
+		change.CreatedAt = fromMillis(createdAt)
+		changes = append(changes, &change)
+	}
+	return changes, total, rows.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rowScanner sql.Row 和 sql.Rows 共有的读取方法
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// toMillis 时间转换为毫秒时间戳，零值保存为0
+func toMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// fromMillis 毫秒时间戳转换为时间，0为零值
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// toJSON 嵌套结构编码为JSON文本，nil 指针和空切片保存为NULL
+func toJSON(v any) (any, error) {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// fromJSON 解码JSON文本，NULL 保持零值
+func fromJSON(s sql.NullString, dest any) error {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s.String), dest); err != nil {
+		return fmt.Errorf("解析JSON字段失败: %w", err)
+	}
+	return nil
+}
+
+// parseID 读取以十六进制字符串保存的ID
+func parseID(s string) (primitive.ObjectID, error) {
+	if s == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(s)
+}
+
+// escapeLike 转义 LIKE 模式中的通配符，配合 ESCAPE '\' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// placeholders 生成 n 个以逗号分隔的 ? 占位符
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
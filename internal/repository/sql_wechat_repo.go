@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accountColumns 公众号表的字段，与 scanAccount 的顺序一致
+const accountColumns = `id, name, alias, fake_id, url, last_article, status, backfill, schedule,
+	last_crawl_at, next_crawl_at, pattern, created_at, updated_at`
+
+// SQLWeChatAccountRepo 基于关系型数据库的公众号数据访问层
+type SQLWeChatAccountRepo struct {
+	db *database.SQLDB
+}
+
+// NewSQLWeChatAccountRepo 创建基于关系型数据库的公众号仓库实例
+func NewSQLWeChatAccountRepo(db *database.SQLDB) *SQLWeChatAccountRepo {
+	return &SQLWeChatAccountRepo{db: db}
+}
+
+// scanAccount 读取一行公众号记录
+func scanAccount(row rowScanner) (*model.WeChatAccount, error) {
+	var account model.WeChatAccount
+	var id string
+	var backfill, schedule, pattern sql.NullString
+	var lastCrawlAt, nextCrawlAt, createdAt, updatedAt int64
+	err := row.Scan(&id, &account.Name, &account.Alias, &account.FakeID, &account.URL, &account.LastArticle,
+		&account.Status, &backfill, &schedule, &lastCrawlAt, &nextCrawlAt, &pattern, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if account.ID, err = parseID(id); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(backfill, &account.Backfill); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(schedule, &account.Schedule); err != nil {
+		return nil, err
+	}
+	if err := fromJSON(pattern, &account.Pattern); err != nil {
+		return nil, err
+	}
+	account.LastCrawlAt = fromMillis(lastCrawlAt)
+	account.NextCrawlAt = fromMillis(nextCrawlAt)
+	account.CreatedAt = fromMillis(createdAt)
+	account.UpdatedAt = fromMillis(updatedAt)
+	return &account, nil
+}
+
+// accountValues 公众号的嵌套字段编码为JSON
+func accountValues(account *model.WeChatAccount) (backfill, schedule, pattern any, err error) {
+	if backfill, err = toJSON(account.Backfill); err != nil {
+		return
+	}
+	if schedule, err = toJSON(account.Schedule); err != nil {
+		return
+	}
+	pattern, err = toJSON(account.Pattern)
+	return
+}
+
+// backfillStatus 回溯状态，单独保存一列用于查询回溯中的公众号
+func backfillStatus(state *model.BackfillState) string {
+	if state == nil {
+		return ""
+	}
+	return state.Status
+}
+
+// findOne 按条件查询一个公众号，不存在时返回 ErrNotFound
+func (r *SQLWeChatAccountRepo) findOne(ctx context.Context, where string, args ...any) (*model.WeChatAccount, error) {
+	row := r.db.QueryRowContext(ctx, r.db.Rebind(`SELECT `+accountColumns+` FROM wechat_accounts WHERE `+where+` LIMIT 1`), args...)
+	account, err := scanAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return account, err
+}
+
+// list 按条件查询公众号
+func (r *SQLWeChatAccountRepo) list(ctx context.Context, where string, args ...any) ([]*model.WeChatAccount, error) {
+	rows, err := r.db.QueryContext(ctx, r.db.Rebind(`SELECT `+accountColumns+` FROM wechat_accounts WHERE `+where+` ORDER BY id`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*model.WeChatAccount
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// Create 创建公众号记录
+func (r *SQLWeChatAccountRepo) Create(ctx context.Context, account *model.WeChatAccount) error {
+	account.CreatedAt = time.Now()
+	account.UpdatedAt = time.Now()
+	account.Status = 1
+
+	backfill, schedule, pattern, err := accountValues(account)
+	if err != nil {
+		return err
+	}
+	id := primitive.NewObjectID()
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO wechat_accounts (`+accountColumns+`, backfill_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		id.Hex(), account.Name, account.Alias, account.FakeID, account.URL, account.LastArticle, account.Status,
+		backfill, schedule, toMillis(account.LastCrawlAt), toMillis(account.NextCrawlAt), pattern,
+		toMillis(account.CreatedAt), toMillis(account.UpdatedAt), backfillStatus(account.Backfill))
+	if err != nil {
+		return err
+	}
+
+	account.ID = id
+	return nil
+}
+
+// FindByID 根据ID查询
+func (r *SQLWeChatAccountRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.WeChatAccount, error) {
+	return r.findOne(ctx, `id = ?`, id.Hex())
+}
+
+// FindByName 根据名称查询正常状态的公众号
+func (r *SQLWeChatAccountRepo) FindByName(ctx context.Context, name string) (*model.WeChatAccount, error) {
+	return r.findOne(ctx, `name = ? AND status = 1`, name)
+}
+
+// FindByFakeID 根据FakeID查询正常状态的公众号
+func (r *SQLWeChatAccountRepo) FindByFakeID(ctx context.Context, fakeID string) (*model.WeChatAccount, error) {
+	return r.findOne(ctx, `fake_id = ? AND status = 1`, fakeID)
+}
+
+// List 查询所有公众号
+func (r *SQLWeChatAccountRepo) List(ctx context.Context) ([]*model.WeChatAccount, error) {
+	return r.list(ctx, `status = 1`)
+}
+
+// Update 更新公众号信息
+func (r *SQLWeChatAccountRepo) Update(ctx context.Context, account *model.WeChatAccount) error {
+	account.UpdatedAt = time.Now()
+
+	backfill, schedule, pattern, err := accountValues(account)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET name = ?, alias = ?, fake_id = ?, url = ?,
+		last_article = ?, status = ?, backfill = ?, backfill_status = ?, schedule = ?, last_crawl_at = ?,
+		next_crawl_at = ?, pattern = ?, created_at = ?, updated_at = ? WHERE id = ?`),
+		account.Name, account.Alias, account.FakeID, account.URL, account.LastArticle, account.Status,
+		backfill, backfillStatus(account.Backfill), schedule, toMillis(account.LastCrawlAt),
+		toMillis(account.NextCrawlAt), pattern, toMillis(account.CreatedAt), toMillis(account.UpdatedAt),
+		account.ID.Hex())
+	return err
+}
+
+// UpdateLastArticle 更新最后一篇文章URL
+func (r *SQLWeChatAccountRepo) UpdateLastArticle(ctx context.Context, id primitive.ObjectID, articleURL string) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET last_article = ?, updated_at = ? WHERE id = ?`),
+		articleURL, toMillis(time.Now()), id.Hex())
+	return err
+}
+
+// UpdateSchedule 更新爬取计划和下次爬取时间
+func (r *SQLWeChatAccountRepo) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule model.CrawlSchedule, nextCrawlAt time.Time) error {
+	value, err := toJSON(schedule)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET schedule = ?, next_crawl_at = ?, updated_at = ? WHERE id = ?`),
+		value, toMillis(nextCrawlAt), toMillis(time.Now()), id.Hex())
+	return err
+}
+
+// UpdateCrawlTime 更新最近一次爬取时间和下次爬取时间
+func (r *SQLWeChatAccountRepo) UpdateCrawlTime(ctx context.Context, id primitive.ObjectID, lastCrawlAt, nextCrawlAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET last_crawl_at = ?, next_crawl_at = ? WHERE id = ?`),
+		toMillis(lastCrawlAt), toMillis(nextCrawlAt), id.Hex())
+	return err
+}
+
+// UpdatePattern 更新发文规律
+func (r *SQLWeChatAccountRepo) UpdatePattern(ctx context.Context, id primitive.ObjectID, pattern *model.PostingPattern) error {
+	value, err := toJSON(pattern)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET pattern = ? WHERE id = ?`), value, id.Hex())
+	return err
+}
+
+// UpdateBackfill 更新历史文章回溯进度
+func (r *SQLWeChatAccountRepo) UpdateBackfill(ctx context.Context, id primitive.ObjectID, state *model.BackfillState) error {
+	value, err := toJSON(state)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET backfill = ?, backfill_status = ?, updated_at = ? WHERE id = ?`),
+		value, backfillStatus(state), toMillis(time.Now()), id.Hex())
+	return err
+}
+
+// ListBackfilling 查询回溯中的公众号（用于服务重启后恢复）
+func (r *SQLWeChatAccountRepo) ListBackfilling(ctx context.Context) ([]*model.WeChatAccount, error) {
+	return r.list(ctx, `status = 1 AND backfill_status = ?`, model.BackfillStatusRunning)
+}
+
+// Delete 删除公众号（软删除）
+func (r *SQLWeChatAccountRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE wechat_accounts SET status = 0, updated_at = ? WHERE id = ?`),
+		toMillis(time.Now()), id.Hex())
+	return err
+}
+
+// Count 统计公众号数量
+func (r *SQLWeChatAccountRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM wechat_accounts WHERE status = 1`).Scan(&count)
+	return count, err
+}
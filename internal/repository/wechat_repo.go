@@ -12,20 +12,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// WeChatAccountRepo 公众号数据访问层
-type WeChatAccountRepo struct {
+// MongoWeChatAccountRepo 基于MongoDB的公众号数据访问层
+type MongoWeChatAccountRepo struct {
 	collection *mongo.Collection
 }
 
-// NewWeChatAccountRepo 创建公众号仓库实例
-func NewWeChatAccountRepo() *WeChatAccountRepo {
-	return &WeChatAccountRepo{
+// NewMongoWeChatAccountRepo 创建基于MongoDB的公众号仓库实例
+func NewMongoWeChatAccountRepo() *MongoWeChatAccountRepo {
+	return &MongoWeChatAccountRepo{
 		collection: database.GetCollection(model.WeChatAccount{}.TableName()),
 	}
 }
 
 // Create 创建公众号记录
-func (r *WeChatAccountRepo) Create(ctx context.Context, account *model.WeChatAccount) error {
+func (r *MongoWeChatAccountRepo) Create(ctx context.Context, account *model.WeChatAccount) error {
 	account.CreatedAt = time.Now()
 	account.UpdatedAt = time.Now()
 	account.Status = 1
@@ -40,37 +40,37 @@ func (r *WeChatAccountRepo) Create(ctx context.Context, account *model.WeChatAcc
 }
 
 // FindByID 根据ID查询
-func (r *WeChatAccountRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.WeChatAccount, error) {
+func (r *MongoWeChatAccountRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*model.WeChatAccount, error) {
 	var account model.WeChatAccount
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&account)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &account, nil
 }
 
-// FindByName 根据名称查询
-func (r *WeChatAccountRepo) FindByName(ctx context.Context, name string) (*model.WeChatAccount, error) {
+// FindByName 根据名称查询正常状态的公众号
+func (r *MongoWeChatAccountRepo) FindByName(ctx context.Context, name string) (*model.WeChatAccount, error) {
 	var account model.WeChatAccount
-	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&account)
+	err := r.collection.FindOne(ctx, bson.M{"name": name, "status": 1}).Decode(&account)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &account, nil
 }
 
-// FindByFakeID 根据FakeID查询
-func (r *WeChatAccountRepo) FindByFakeID(ctx context.Context, fakeID string) (*model.WeChatAccount, error) {
+// FindByFakeID 根据FakeID查询正常状态的公众号
+func (r *MongoWeChatAccountRepo) FindByFakeID(ctx context.Context, fakeID string) (*model.WeChatAccount, error) {
 	var account model.WeChatAccount
-	err := r.collection.FindOne(ctx, bson.M{"fake_id": fakeID}).Decode(&account)
+	err := r.collection.FindOne(ctx, bson.M{"fake_id": fakeID, "status": 1}).Decode(&account)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &account, nil
 }
 
 // List 查询所有公众号
-func (r *WeChatAccountRepo) List(ctx context.Context) ([]*model.WeChatAccount, error) {
+func (r *MongoWeChatAccountRepo) List(ctx context.Context) ([]*model.WeChatAccount, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{"status": 1})
 	if err != nil {
 		return nil, err
@@ -86,7 +86,7 @@ func (r *WeChatAccountRepo) List(ctx context.Context) ([]*model.WeChatAccount, e
 }
 
 // Update 更新公众号信息
-func (r *WeChatAccountRepo) Update(ctx context.Context, account *model.WeChatAccount) error {
+func (r *MongoWeChatAccountRepo) Update(ctx context.Context, account *model.WeChatAccount) error {
 	account.UpdatedAt = time.Now()
 
 	_, err := r.collection.UpdateOne(
@@ -98,7 +98,7 @@ func (r *WeChatAccountRepo) Update(ctx context.Context, account *model.WeChatAcc
 }
 
 // UpdateLastArticle 更新最后一篇文章URL
-func (r *WeChatAccountRepo) UpdateLastArticle(ctx context.Context, id primitive.ObjectID, articleURL string) error {
+func (r *MongoWeChatAccountRepo) UpdateLastArticle(ctx context.Context, id primitive.ObjectID, articleURL string) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -112,8 +112,87 @@ func (r *WeChatAccountRepo) UpdateLastArticle(ctx context.Context, id primitive.
 	return err
 }
 
+// UpdateSchedule 更新爬取计划和下次爬取时间
+func (r *MongoWeChatAccountRepo) UpdateSchedule(ctx context.Context, id primitive.ObjectID, schedule model.CrawlSchedule, nextCrawlAt time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"schedule":      schedule,
+				"next_crawl_at": nextCrawlAt,
+				"updated_at":    time.Now(),
+			},
+		},
+	)
+	return err
+}
+
+// UpdateCrawlTime 更新最近一次爬取时间和下次爬取时间
+func (r *MongoWeChatAccountRepo) UpdateCrawlTime(ctx context.Context, id primitive.ObjectID, lastCrawlAt, nextCrawlAt time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"last_crawl_at": lastCrawlAt,
+				"next_crawl_at": nextCrawlAt,
+			},
+		},
+	)
+	return err
+}
+
+// UpdatePattern 更新发文规律
+func (r *MongoWeChatAccountRepo) UpdatePattern(ctx context.Context, id primitive.ObjectID, pattern *model.PostingPattern) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"pattern": pattern,
+			},
+		},
+	)
+	return err
+}
+
+// UpdateBackfill 更新历史文章回溯进度
+func (r *MongoWeChatAccountRepo) UpdateBackfill(ctx context.Context, id primitive.ObjectID, state *model.BackfillState) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"backfill":   state,
+				"updated_at": time.Now(),
+			},
+		},
+	)
+	return err
+}
+
+// ListBackfilling 查询回溯中的公众号（用于服务重启后恢复）
+func (r *MongoWeChatAccountRepo) ListBackfilling(ctx context.Context) ([]*model.WeChatAccount, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"status":          1,
+		"backfill.status": model.BackfillStatusRunning,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []*model.WeChatAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
 // Delete 删除公众号（软删除）
-func (r *WeChatAccountRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (r *MongoWeChatAccountRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -128,6 +207,6 @@ func (r *WeChatAccountRepo) Delete(ctx context.Context, id primitive.ObjectID) e
 }
 
 // Count 统计公众号数量
-func (r *WeChatAccountRepo) Count(ctx context.Context) (int64, error) {
+func (r *MongoWeChatAccountRepo) Count(ctx context.Context) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{"status": 1})
 }
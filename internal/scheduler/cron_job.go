@@ -2,8 +2,12 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"wechat-crawler/internal/model"
 	"wechat-crawler/internal/service"
 	"wechat-crawler/pkg/logger"
 
@@ -11,47 +15,91 @@ import (
 	"go.uber.org/zap"
 )
 
+// dispatchCronExpr 检查到期公众号的频率（秒 分 时 日 月 周）
+const dispatchCronExpr = "0 * * * * *"
+
+// settingsPollExpr 多实例部署时主实例检查设置是否被其他实例修改的频率
+const settingsPollExpr = "@every 30s"
+
+// 定时任务名称
+const (
+	JobCrawlDispatch = "crawl_dispatch" // 检查到期的公众号并爬取
+	JobContentRetry  = "content_retry"  // 重试获取缺失的文章正文
+	JobArticleVerify = "article_verify" // 复查文章是否被删除或屏蔽
+	JobFeishuNotify  = "feishu_notify"  // 飞书通知
+)
+
+// jobOrder 任务列表的展示顺序
+var jobOrder = []string{JobCrawlDispatch, JobContentRetry, JobArticleVerify, JobFeishuNotify}
+
+// JobStatus 定时任务的执行计划
+type JobStatus struct {
+	Name  string    `json:"name"`
+	Label string    `json:"label"` // 显示名称
+	Spec  string    `json:"spec"`  // cron表达式（秒 分 时 日 月 周）
+	Next  time.Time `json:"next"`  // 下次执行时间
+	Prev  time.Time `json:"prev"`  // 上次执行时间，未执行过为零值
+}
+
+// scheduledJob 已注册到cron的任务
+type scheduledJob struct {
+	id    cron.EntryID
+	label string
+	spec  string
+}
+
 // Scheduler 定时任务调度器
 type Scheduler struct {
 	cron           *cron.Cron
 	crawlerService *service.CrawlerService
 	feishuService  *service.FeishuService
-	interval       int // 爬取间隔（分钟）
+	settings       *service.SettingsService // 运行时设置，默认爬取间隔实时读取
+	retryInterval  int                      // 缺失正文重试的检查间隔（分钟），0表示不重试
+	verifyInterval int                      // 文章复查的间隔（分钟），0表示不复查
+	mu             sync.Mutex
+	jobs           map[string]scheduledJob // 按任务名称记录cron的EntryID，配置变更时替换
+	lease          *leaderLease            // 主实例租约，为nil时不限制（单实例部署）
 }
 
 // NewScheduler 创建调度器实例
-func NewScheduler(crawlerService *service.CrawlerService, feishuService *service.FeishuService, interval int) *Scheduler {
+func NewScheduler(crawlerService *service.CrawlerService, feishuService *service.FeishuService, settings *service.SettingsService, retryInterval, verifyInterval int) *Scheduler {
 	return &Scheduler{
 		cron:           cron.New(cron.WithSeconds()),
 		crawlerService: crawlerService,
 		feishuService:  feishuService,
-		interval:       interval,
+		settings:       settings,
+		retryInterval:  retryInterval,
+		verifyInterval: verifyInterval,
+		jobs:           make(map[string]scheduledJob),
 	}
 }
 
 // Start 启动定时任务
 func (s *Scheduler) Start() error {
-	// 构建cron表达式：每N分钟执行一次
-	// 格式：秒 分 时 日 月 周
-	cronExpr, err := s.BuildCronExpr(s.interval)
-	if err != nil {
-		logger.Error("构建cron表达式失败", zap.Error(err))
+	if err := s.setupCrawlTask(); err != nil {
+		logger.Error("添加爬取定时任务失败", zap.Error(err))
 		return err
 	}
-	// cronExpr := fmt.Sprintf("0 */%d * * * *", s.interval)
 
-	logger.Info("配置爬取任务定时器",
-		zap.Int("interval_minutes", s.interval),
-		zap.String("cron_expr", cronExpr))
+	// 默认爬取间隔修改后立即检查到期的公众号
+	s.settings.OnChange(s.onSettingsChange)
 
-	// 添加爬取定时任务
-	_, err = s.cron.AddFunc(cronExpr, func() {
-		s.executeCrawlTask()
-	})
+	// 定时重试获取缺失的文章正文
+	if s.retryInterval > 0 {
+		retryExpr := fmt.Sprintf("@every %dm", s.retryInterval)
+		if err := s.registerJob(JobContentRetry, "重试缺失正文", retryExpr, s.retryMissingContent); err != nil {
+			logger.Error("添加正文重试定时任务失败", zap.Error(err))
+			return err
+		}
+	}
 
-	if err != nil {
-		logger.Error("添加爬取定时任务失败", zap.Error(err))
-		return err
+	// 定时复查最近的文章是否被删除或屏蔽
+	if s.verifyInterval > 0 {
+		verifyExpr := fmt.Sprintf("@every %dm", s.verifyInterval)
+		if err := s.registerJob(JobArticleVerify, "复查文章状态", verifyExpr, s.verifyArticles); err != nil {
+			logger.Error("添加文章复查定时任务失败", zap.Error(err))
+			return err
+		}
 	}
 
 	// 添加飞书通知定时任务
@@ -59,6 +107,15 @@ func (s *Scheduler) Start() error {
 		logger.Warn("配置飞书通知任务失败", zap.Error(err))
 	}
 
+	// 多实例部署时先竞争主实例租约，只有主实例执行定时任务
+	if s.lease != nil {
+		if _, err := s.cron.AddFunc(settingsPollExpr, s.pollSettings); err != nil {
+			logger.Error("添加设置检查定时任务失败", zap.Error(err))
+			return err
+		}
+		s.lease.start()
+	}
+
 	// 启动调度器
 	s.cron.Start()
 	logger.Info("定时任务调度器已启动")
@@ -66,7 +123,104 @@ func (s *Scheduler) Start() error {
 	return nil
 }
 
-// setupFeishuNotifyTask 配置飞书通知定时任务
+// registerJob 注册定时任务，同名任务已存在时替换，新任务注册失败时保留原任务
+// 启用主实例租约时任务只在主实例上执行
+func (s *Scheduler) registerJob(name, label, spec string, job func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.cron.AddFunc(spec, s.leaderOnly(name, job))
+	if err != nil {
+		return err
+	}
+	if old, ok := s.jobs[name]; ok {
+		s.cron.Remove(old.id)
+	}
+	s.jobs[name] = scheduledJob{id: id, label: label, spec: spec}
+
+	logger.Info("注册定时任务",
+		zap.String("job", name),
+		zap.String("cron_expr", spec))
+	return nil
+}
+
+// removeJob 移除定时任务
+func (s *Scheduler) removeJob(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.jobs[name]; ok {
+		s.cron.Remove(old.id)
+		delete(s.jobs, name)
+		logger.Info("移除定时任务", zap.String("job", name))
+	}
+}
+
+// Jobs 获取已注册定时任务的执行计划
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []JobStatus
+	for _, name := range jobOrder {
+		job, ok := s.jobs[name]
+		if !ok {
+			continue
+		}
+		entry := s.cron.Entry(job.id)
+		jobs = append(jobs, JobStatus{
+			Name:  name,
+			Label: job.label,
+			Spec:  job.spec,
+			Next:  entry.Next,
+			Prev:  entry.Prev,
+		})
+	}
+	return jobs
+}
+
+// setupCrawlTask 配置爬取调度任务
+// 每分钟检查一次哪些公众号到了爬取时间
+// 各公众号的间隔/cron表达式保存在公众号的爬取计划中，未设置时使用默认间隔
+func (s *Scheduler) setupCrawlTask() error {
+	logger.Info("配置爬取调度器",
+		zap.Int("default_interval_minutes", s.settings.Current().CrawlInterval),
+		zap.String("dispatch_cron_expr", dispatchCronExpr))
+
+	return s.registerJob(JobCrawlDispatch, "检查到期公众号", dispatchCronExpr, s.dispatchDueAccounts)
+}
+
+// onSettingsChange 默认爬取间隔变化后重新配置爬取调度任务，并立即检查一次到期的公众号
+// 爬虫服务已按新间隔重新计算了各公众号的下次爬取时间，间隔缩短时无需等到下一分钟
+func (s *Scheduler) onSettingsChange(ctx context.Context, old, cur model.RuntimeSettings) {
+	if old.CrawlInterval == cur.CrawlInterval {
+		return
+	}
+	if err := s.setupCrawlTask(); err != nil {
+		logger.Error("重新配置爬取调度任务失败", zap.Error(err))
+		return
+	}
+	go s.leaderOnly(JobCrawlDispatch, s.dispatchDueAccounts)()
+}
+
+// FeishuCronExpr 根据通知周期和时间构建cron表达式（秒 分 时 日 月 周）
+// period 为 hourly 时每小时整点执行，否则每天在 notifyTime（HH:MM）执行
+func FeishuCronExpr(period, notifyTime string) (string, error) {
+	if period == "hourly" {
+		return "0 0 * * * *", nil
+	}
+
+	if notifyTime == "" {
+		notifyTime = "09:00"
+	}
+	t, err := time.Parse("15:04", notifyTime)
+	if err != nil {
+		return "", fmt.Errorf("通知时间格式错误，应为 HH:MM: %s", notifyTime)
+	}
+	return fmt.Sprintf("0 %d %d * * *", t.Minute(), t.Hour()), nil
+}
+
+// setupFeishuNotifyTask 配置飞书通知定时任务，替换已有的任务，未启用时移除
 func (s *Scheduler) setupFeishuNotifyTask() error {
 	ctx := context.Background()
 
@@ -78,22 +232,13 @@ func (s *Scheduler) setupFeishuNotifyTask() error {
 
 	if !config.Enabled {
 		logger.Info("飞书通知未启用，跳过配置定时任务")
+		s.removeJob(JobFeishuNotify)
 		return nil
 	}
 
-	// 构建cron表达式
-	var cronExpr string
-	if config.NotifyPeriod == "hourly" {
-		// 每小时执行一次
-		cronExpr = "0 0 * * * *"
-	} else {
-		// 每天在指定时间执行
-		// 解析时间字符串 "HH:MM"
-		if config.NotifyTime == "" {
-			config.NotifyTime = "09:00"
-		}
-		// 格式：秒 分 时 日 月 周
-		cronExpr = fmt.Sprintf("0 %s * * *", config.NotifyTime)
+	cronExpr, err := FeishuCronExpr(config.NotifyPeriod, config.NotifyTime)
+	if err != nil {
+		return err
 	}
 
 	logger.Info("配置飞书通知定时器",
@@ -101,12 +246,7 @@ func (s *Scheduler) setupFeishuNotifyTask() error {
 		zap.String("time", config.NotifyTime),
 		zap.String("cron_expr", cronExpr))
 
-	// 添加定时任务
-	_, err = s.cron.AddFunc(cronExpr, func() {
-		s.executeFeishuNotifyTask()
-	})
-
-	if err != nil {
+	if err := s.registerJob(JobFeishuNotify, "飞书通知", cronExpr, s.executeFeishuNotifyTask); err != nil {
 		return fmt.Errorf("添加飞书通知定时任务失败: %w", err)
 	}
 
@@ -120,18 +260,44 @@ func (s *Scheduler) Stop() {
 		<-ctx.Done()
 		logger.Info("定时任务调度器已停止")
 	}
+	if s.lease != nil {
+		s.lease.release()
+	}
 }
 
-// executeCrawlTask 执行爬取任务
-func (s *Scheduler) executeCrawlTask() {
-	logger.Info("========== 开始执行定时爬取任务 ==========")
-
+// dispatchDueAccounts 爬取已到爬取时间的公众号
+func (s *Scheduler) dispatchDueAccounts() {
 	ctx := context.Background()
-	if err := s.crawlerService.FetchAllAccounts(ctx); err != nil {
+	if err := s.crawlerService.FetchDueAccounts(ctx, model.CrawlTriggerCron); err != nil {
+		if errors.Is(err, service.ErrCrawlRunning) {
+			// 已有任务在执行，到期的公众号留到下一次检查，避免加倍请求微信
+			logger.Debug("已有爬取任务正在执行，推迟本次调度")
+			return
+		}
 		logger.Error("定时爬取任务执行失败", zap.Error(err))
 	}
+}
 
-	logger.Info("========== 定时爬取任务执行完成 ==========")
+// retryMissingContent 重新获取缺失的文章正文
+func (s *Scheduler) retryMissingContent() {
+	if _, err := s.crawlerService.RetryMissingContent(context.Background()); err != nil {
+		logger.Warn("重试获取文章正文失败", zap.Error(err))
+	}
+}
+
+// verifyArticles 复查最近的文章，发现删除或屏蔽时发送飞书提醒
+func (s *Scheduler) verifyArticles() {
+	ctx := context.Background()
+	events, err := s.crawlerService.VerifyArticles(ctx)
+	if err != nil {
+		logger.Warn("复查文章状态失败", zap.Error(err))
+	}
+	if len(events) == 0 {
+		return
+	}
+	if err := s.feishuService.SendRemovedNotification(ctx, events); err != nil {
+		logger.Error("发送文章删除提醒失败", zap.Error(err))
+	}
 }
 
 // executeFeishuNotifyTask 执行飞书通知任务
@@ -146,38 +312,8 @@ func (s *Scheduler) executeFeishuNotifyTask() {
 	logger.Info("========== 飞书通知任务执行完成 ==========")
 }
 
-// RunOnce 立即执行一次爬取任务（用于测试或手动触发）
-func (s *Scheduler) RunOnce() {
-	logger.Info("手动触发爬取任务")
-	s.executeCrawlTask()
-}
-
 // ReloadFeishuTask 重新加载飞书通知任务（配置更新后调用）
 func (s *Scheduler) ReloadFeishuTask() error {
 	logger.Info("重新加载飞书通知定时任务")
 	return s.setupFeishuNotifyTask()
 }
-
-func (s *Scheduler) BuildCronExpr(interval int) (string, error) {
-	if interval < 5 || interval > 1440 {
-		return "", fmt.Errorf("interval must be between 5 and 1440 minutes")
-	}
-
-	// 小于 60 分钟：直接使用 */N
-	if interval < 60 {
-		return fmt.Sprintf("0 */%d * * * *", interval), nil
-	}
-
-	// 大于或等于 60 分钟
-	hours := interval / 60   // 每 N 小时执行
-	minutes := interval % 60 // 偏移分钟，例如 90 分钟 = 1 小时 + 30 分钟
-
-	if minutes == 0 {
-		// 整小时：每 N 小时执行一次
-		return fmt.Sprintf("0 0 */%d * * *", hours), nil
-	}
-
-	// 带分钟偏移：例如 90 分钟 = 每1小时的第 30 分钟执行
-	// 但要确保 interval 最终是 N 小时 + offset，要让策略逻辑能接受
-	return fmt.Sprintf("0 %d */%d * * *", minutes, hours), nil
-}
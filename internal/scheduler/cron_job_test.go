@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wechat-crawler/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "scheduler-test")
+	if err := logger.Init(filepath.Join(dir, "test.log"), "error"); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestFeishuCronExpr(t *testing.T) {
+	cases := []struct {
+		period, notifyTime string
+		want               string
+	}{
+		{"daily", "09:00", "0 0 9 * * *"},
+		{"daily", "18:30", "0 30 18 * * *"},
+		{"daily", "", "0 0 9 * * *"},
+		{"hourly", "18:30", "0 0 * * * *"},
+	}
+	for _, tc := range cases {
+		got, err := FeishuCronExpr(tc.period, tc.notifyTime)
+		if err != nil || got != tc.want {
+			t.Errorf("%s %s: got %q (%v), want %q", tc.period, tc.notifyTime, got, err, tc.want)
+		}
+	}
+
+	if _, err := FeishuCronExpr("daily", "9点"); err == nil {
+		t.Error("非法的通知时间应返回错误")
+	}
+}
+
+// 验证同名任务重新注册时替换原任务，而不是新增一个
+func TestRegisterJobReplaces(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, 0, 0)
+
+	if err := s.registerJob(JobFeishuNotify, "飞书通知", "0 0 9 * * *", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.registerJob(JobFeishuNotify, "飞书通知", "0 30 18 * * *", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.cron.Entries()); n != 1 {
+		t.Fatalf("重新注册后应只有1个任务, got %d", n)
+	}
+
+	// 表达式非法时保留原任务
+	if err := s.registerJob(JobFeishuNotify, "飞书通知", "bad", func() {}); err == nil {
+		t.Fatal("非法表达式应返回错误")
+	}
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].Spec != "0 30 18 * * *" {
+		t.Fatalf("应保留原任务: %+v", jobs)
+	}
+
+	s.removeJob(JobFeishuNotify)
+	if n := len(s.cron.Entries()); n != 0 {
+		t.Fatalf("移除后不应有任务, got %d", n)
+	}
+}
@@ -0,0 +1,254 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// LeaseConfig 多实例部署时的主实例租约配置
+// 多个实例共用一个数据库时，只有持有租约的实例执行定时任务，其余实例作为备用
+type LeaseConfig struct {
+	Name  string        // 租约名称，共用数据库的实例需一致
+	Owner string        // 本实例ID，为空时使用 主机名-进程号
+	TTL   time.Duration // 租约有效期，主实例异常退出后备用实例最多等待该时长接管
+}
+
+// LeaderStatus 主实例租约状态
+type LeaderStatus struct {
+	Enabled   bool      `json:"enabled"`
+	Instance  string    `json:"instance"`   // 本实例ID
+	IsLeader  bool      `json:"is_leader"`  // 本实例是否为主实例
+	Leader    string    `json:"leader"`     // 当前主实例ID，没有主实例时为空
+	ExpiresAt time.Time `json:"expires_at"` // 当前租约的过期时间
+}
+
+// leaseStore 租约存储
+type leaseStore interface {
+	TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, owner string) error
+	Get(ctx context.Context, name string) (*model.Lease, error)
+}
+
+// leaderLease 主实例租约，定期续约，未持有时不断尝试接管
+type leaderLease struct {
+	store      leaseStore
+	name       string
+	owner      string
+	ttl        time.Duration
+	validUntil atomic.Int64 // 本地认为租约有效的截止时间（UnixNano），续约失败后到期即放弃
+	mu         sync.Mutex   // 保证获得/失去租约的回调按顺序执行
+	held       bool
+	onElected  func(ctx context.Context) // 成为主实例时调用
+	onLost     func()                    // 失去主实例身份时调用
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// newLeaderLease 创建主实例租约
+func newLeaderLease(store leaseStore, cfg LeaseConfig) *leaderLease {
+	owner := cfg.Owner
+	if owner == "" {
+		hostname, _ := os.Hostname()
+		owner = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	name := cfg.Name
+	if name == "" {
+		name = "scheduler"
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &leaderLease{
+		store: store,
+		name:  name,
+		owner: owner,
+		ttl:   ttl,
+	}
+}
+
+// isLeader 本实例是否持有未过期的租约（不访问数据库）
+func (l *leaderLease) isLeader() bool {
+	return time.Now().UnixNano() < l.validUntil.Load()
+}
+
+// ensure 获取或续约租约，返回本实例是否为主实例
+func (l *leaderLease) ensure(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 以请求发出前的时间计算本地有效期，保证早于数据库中的过期时间
+	start := time.Now()
+	ok, err := l.store.TryAcquire(ctx, l.name, l.owner, l.ttl)
+	if err != nil {
+		logger.Warn("续约主实例租约失败", zap.String("lease", l.name), zap.Error(err))
+		// 数据库暂时不可用时在本地有效期内保持身份，到期后放弃
+		ok = l.isLeader()
+	} else if ok {
+		l.validUntil.Store(start.Add(l.ttl).UnixNano())
+	}
+
+	if ok && !l.held {
+		l.held = true
+		logger.Info("成为主实例，开始执行定时任务", zap.String("lease", l.name), zap.String("instance", l.owner))
+		if l.onElected != nil {
+			l.onElected(ctx)
+		}
+	} else if !ok && l.held {
+		l.held = false
+		l.validUntil.Store(0)
+		logger.Warn("失去主实例租约，停止执行定时任务", zap.String("lease", l.name), zap.String("instance", l.owner))
+		if l.onLost != nil {
+			l.onLost()
+		}
+	}
+	return ok
+}
+
+// start 启动续约循环，每 1/3 有效期续约一次
+func (l *leaderLease) start() {
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		l.ensure(context.Background())
+		for {
+			select {
+			case <-ticker.C:
+				l.ensure(context.Background())
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+}
+
+// release 停止续约并释放租约，备用实例无需等待过期即可接管
+func (l *leaderLease) release() {
+	if l.stop == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.held {
+		return
+	}
+	l.held = false
+	l.validUntil.Store(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.store.Release(ctx, l.name, l.owner); err != nil {
+		logger.Warn("释放主实例租约失败", zap.String("lease", l.name), zap.Error(err))
+		return
+	}
+	logger.Info("已释放主实例租约", zap.String("lease", l.name), zap.String("instance", l.owner))
+}
+
+// status 获取租约状态
+func (l *leaderLease) status(ctx context.Context) LeaderStatus {
+	status := LeaderStatus{
+		Enabled:  true,
+		Instance: l.owner,
+		IsLeader: l.isLeader(),
+	}
+	lease, err := l.store.Get(ctx, l.name)
+	if err != nil {
+		logger.Warn("获取主实例租约失败", zap.Error(err))
+		return status
+	}
+	if lease != nil && lease.ExpiresAt.After(time.Now()) {
+		status.Leader = lease.Owner
+		status.ExpiresAt = lease.ExpiresAt
+	}
+	return status
+}
+
+// UseLease 启用主实例租约，需在 Start 之前调用
+// 启用后只有持有租约的实例执行定时任务，onElected 在本实例成为主实例时调用（如恢复中断的任务）
+func (s *Scheduler) UseLease(cfg LeaseConfig, onElected func(ctx context.Context)) {
+	lease := newLeaderLease(repository.NewLeaseRepo(), cfg)
+	lease.onElected = func(ctx context.Context) {
+		// 作为备用实例期间其他实例可能修改过设置，接管定时任务前重新加载
+		s.reloadSettings(ctx)
+		if onElected != nil {
+			onElected(ctx)
+		}
+	}
+	lease.onLost = s.cancelRunningCrawl
+	s.lease = lease
+}
+
+// reloadSettings 重新加载数据库中的运行时设置，失败时继续使用当前设置
+func (s *Scheduler) reloadSettings(ctx context.Context) {
+	if err := s.settings.Reload(ctx); err != nil {
+		logger.Warn("重新加载运行时设置失败", zap.Error(err))
+	}
+}
+
+// pollSettings 主实例定期检查设置的修改时间，使在备用实例上保存的设置生效
+func (s *Scheduler) pollSettings() {
+	if s.lease == nil || !s.lease.isLeader() {
+		return
+	}
+	s.reloadSettings(context.Background())
+}
+
+// leaderOnly 包装定时任务，执行前确认本实例持有租约，备用实例跳过
+func (s *Scheduler) leaderOnly(name string, job func()) func() {
+	return func() {
+		if s.lease != nil && !s.lease.ensure(context.Background()) {
+			logger.Debug("非主实例，跳过定时任务", zap.String("job", name))
+			return
+		}
+		job()
+	}
+}
+
+// cancelRunningCrawl 失去租约后取消本实例正在执行的爬取任务，避免与新的主实例重复爬取
+func (s *Scheduler) cancelRunningCrawl() {
+	if s.crawlerService == nil || !s.crawlerService.CrawlProgress().Running {
+		return
+	}
+	if err := s.crawlerService.CancelCrawl("lease-lost"); err != nil {
+		logger.Warn("取消爬取任务失败", zap.Error(err))
+	}
+}
+
+// CheckLeader 确认本实例可以执行爬取任务（供手动和API触发使用），未启用租约时始终可以
+// 启用租约时获取或续约租约，备用实例返回 false 和当前主实例的状态
+func (s *Scheduler) CheckLeader(ctx context.Context) (LeaderStatus, bool) {
+	if s.lease == nil {
+		return LeaderStatus{IsLeader: true}, true
+	}
+	if s.lease.ensure(ctx) {
+		return LeaderStatus{}, true
+	}
+	return s.lease.status(ctx), false
+}
+
+// LeaderStatus 获取主实例租约状态，未启用租约时本实例始终为主实例
+func (s *Scheduler) LeaderStatus(ctx context.Context) LeaderStatus {
+	if s.lease == nil {
+		return LeaderStatus{IsLeader: true}
+	}
+	return s.lease.status(ctx)
+}
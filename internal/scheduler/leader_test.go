@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+)
+
+// memoryLeaseStore 内存中的租约存储，过期时间由 now 控制
+type memoryLeaseStore struct {
+	mu     sync.Mutex
+	now    time.Time
+	leases map[string]*model.Lease
+}
+
+func newMemoryLeaseStore() *memoryLeaseStore {
+	return &memoryLeaseStore{now: time.Now(), leases: make(map[string]*model.Lease)}
+}
+
+func (m *memoryLeaseStore) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease := m.leases[name]
+	if lease != nil && lease.Owner != owner && lease.ExpiresAt.After(m.now) {
+		return false, nil
+	}
+	m.leases[name] = &model.Lease{Name: name, Owner: owner, HeartbeatAt: m.now, ExpiresAt: m.now.Add(ttl)}
+	return true, nil
+}
+
+func (m *memoryLeaseStore) Release(ctx context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lease := m.leases[name]; lease != nil && lease.Owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+func (m *memoryLeaseStore) Get(ctx context.Context, name string) (*model.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leases[name], nil
+}
+
+func (m *memoryLeaseStore) advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+// 验证同一时间只有一个实例持有租约，过期后备用实例接管，原主实例续约失败后放弃身份
+func TestLeaderLeaseFailover(t *testing.T) {
+	store := newMemoryLeaseStore()
+	ctx := context.Background()
+
+	var elected []string
+	lost := 0
+	newLease := func(owner string) *leaderLease {
+		l := newLeaderLease(store, LeaseConfig{Owner: owner, TTL: time.Minute})
+		l.onElected = func(context.Context) { elected = append(elected, owner) }
+		l.onLost = func() { lost++ }
+		return l
+	}
+	a, b := newLease("a"), newLease("b")
+
+	if !a.ensure(ctx) || b.ensure(ctx) {
+		t.Fatal("应只有先获取的实例持有租约")
+	}
+	if !a.ensure(ctx) || !a.isLeader() {
+		t.Fatal("持有者应能续约")
+	}
+
+	// 主实例停止续约，租约过期后备用实例接管
+	store.advance(2 * time.Minute)
+	if !b.ensure(ctx) {
+		t.Fatal("租约过期后备用实例应接管")
+	}
+	if a.ensure(ctx) {
+		t.Fatal("租约被接管后原主实例不应续约成功")
+	}
+	if a.isLeader() || lost != 1 {
+		t.Fatalf("原主实例应放弃主实例身份, lost=%d", lost)
+	}
+	if len(elected) != 2 || elected[0] != "a" || elected[1] != "b" {
+		t.Fatalf("成为主实例的回调不正确: %v", elected)
+	}
+}
+
+// 验证未持有租约时跳过定时任务
+func TestLeaderOnly(t *testing.T) {
+	store := newMemoryLeaseStore()
+	store.leases["scheduler"] = &model.Lease{Name: "scheduler", Owner: "other", ExpiresAt: store.now.Add(time.Minute)}
+
+	s := NewScheduler(nil, nil, nil, 0, 0)
+	s.lease = newLeaderLease(store, LeaseConfig{Owner: "self", TTL: time.Minute})
+
+	runs := 0
+	job := s.leaderOnly(JobCrawlDispatch, func() { runs++ })
+	job()
+	if runs != 0 {
+		t.Fatal("备用实例不应执行定时任务")
+	}
+
+	store.advance(2 * time.Minute)
+	job()
+	if runs != 1 {
+		t.Fatal("接管租约后应执行定时任务")
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/extract"
+	"wechat-crawler/pkg/logger"
+	"wechat-crawler/pkg/search"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// extractBatchSize 为历史文章生成内容时每批处理的文章数
+const extractBatchSize = 100
+
+// extractContent 由正文HTML生成清洗后的HTML、Markdown、纯文本、统计信息和检索词
+// 启用图片归档时同时归档正文图片和封面，生成的HTML和Markdown使用归档后的地址
+// 正文为空时只生成标题、作者和摘要的检索词；处理失败时只记录日志并返回空的统计信息，不影响文章保存
+func (s *CrawlerService) extractContent(ctx context.Context, article *model.Article, content string) model.ArticleExtract {
+	if content == "" {
+		return model.ArticleExtract{SearchTokens: search.Tokens(article.Title, article.Author, article.Digest)}
+	}
+
+	result, err := extract.Process(content)
+	if err != nil {
+		logger.Warn("处理文章正文失败", zap.Error(err))
+		return model.ArticleExtract{
+			Meta:         &model.ArticleMeta{},
+			SearchTokens: search.Tokens(article.Title, article.Author, article.Digest),
+		}
+	}
+
+	var archived model.ArticleExtract
+	if s.media != nil && s.media.ImagesEnabled() {
+		archived = s.archiveMedia(ctx, result, article.Cover)
+	}
+
+	meta := &model.ArticleMeta{
+		WordCount:      result.Meta.WordCount,
+		ImageCount:     result.Meta.ImageCount,
+		Images:         result.Meta.Images,
+		ReadingMinutes: result.Meta.ReadingMinutes,
+	}
+	for _, link := range result.Meta.Links {
+		meta.Links = append(meta.Links, model.ArticleLink{Text: link.Text, URL: link.URL})
+	}
+	for _, heading := range result.Meta.Headings {
+		meta.Headings = append(meta.Headings, model.ArticleHeading{Level: heading.Level, Text: heading.Text})
+	}
+	return model.ArticleExtract{
+		CleanHTML:       result.HTML,
+		Markdown:        result.Markdown,
+		Text:            result.Text,
+		Meta:            meta,
+		LocalCover:      archived.LocalCover,
+		MediaArchivedAt: archived.MediaArchivedAt,
+		MediaFailed:     archived.MediaFailed,
+		SearchTokens:    search.Tokens(article.Title, article.Author, article.Digest, result.Text),
+	}
+}
+
+// archiveMedia 归档正文图片和封面，并把正文中的图片替换为归档后的地址
+// 返回的结果只包含封面地址和归档信息
+func (s *CrawlerService) archiveMedia(ctx context.Context, result *extract.Result, cover string) model.ArticleExtract {
+	urls, failed := s.media.ArchiveImages(ctx, result.Meta.Images)
+	if err := result.RewriteImages(urls); err != nil {
+		logger.Warn("替换正文图片地址失败", zap.Error(err))
+	}
+
+	archived := model.ArticleExtract{MediaArchivedAt: time.Now(), MediaFailed: failed}
+	if cover != "" {
+		local, err := s.media.ArchiveImage(ctx, cover)
+		if err != nil {
+			logger.Warn("归档封面失败", zap.String("url", cover), zap.Error(err))
+			archived.MediaFailed++
+		}
+		archived.LocalCover = local
+	}
+	return archived
+}
+
+// ExtractMissingContent 为已有正文但尚未生成Markdown、纯文本等内容，或尚未生成检索词的文章补充生成（启动时在后台执行）
+// 启用图片归档时同时为尚未归档或归档失败的文章归档图片
+func (s *CrawlerService) ExtractMissingContent(ctx context.Context) (int, error) {
+	processed := 0
+	var lastID primitive.ObjectID
+	for {
+		articles, err := s.articleRepo.ListMissingExtract(ctx, lastID, s.media != nil && s.media.ImagesEnabled(), extractBatchSize)
+		if err != nil {
+			return processed, fmt.Errorf("查询待处理文章失败: %w", err)
+		}
+		if len(articles) == 0 {
+			break
+		}
+
+		for _, article := range articles {
+			if err := s.articleRepo.UpdateExtract(ctx, article.ID, s.extractContent(ctx, article, article.Content)); err != nil {
+				return processed, fmt.Errorf("保存文章内容失败: %w", err)
+			}
+			lastID = article.ID
+			processed++
+		}
+	}
+
+	if processed > 0 {
+		logger.Info("已为历史文章生成Markdown、纯文本和检索词", zap.Int("count", processed))
+	}
+	return processed, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"wechat-crawler/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxTagLength    = 20  // 标签最多的字数
+	maxTagsPerBatch = 10  // 一次最多添加或移除的标签数
+	maxMarkArticles = 500 // 一次批量操作最多的文章数
+)
+
+// parseArticleIDs 解析批量操作的文章ID
+func parseArticleIDs(ids []string) ([]primitive.ObjectID, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("请选择文章")
+	}
+	if len(ids) > maxMarkArticles {
+		return nil, fmt.Errorf("一次最多操作%d篇文章", maxMarkArticles)
+	}
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文章ID: %s", id)
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	return objectIDs, nil
+}
+
+// normalizeTags 去掉标签首尾的空白、合并连续空白并去重，空标签忽略
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("标签「%s」超过%d个字", tag, maxTagLength)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTagsPerBatch {
+		return nil, fmt.Errorf("一次最多操作%d个标签", maxTagsPerBatch)
+	}
+	return result, nil
+}
+
+// MarkArticlesRead 把文章标记为用户已读或未读，返回状态发生变化的文章数
+func (s *CrawlerService) MarkArticlesRead(ctx context.Context, user string, ids []string, read bool) (int64, error) {
+	if user == "" {
+		return 0, fmt.Errorf("未登录")
+	}
+	objectIDs, err := parseArticleIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	return s.articleRepo.MarkRead(ctx, objectIDs, user, read)
+}
+
+// MarkAccountRead 把公众号的全部文章标记为用户已读，返回新标记的文章数
+func (s *CrawlerService) MarkAccountRead(ctx context.Context, user, accountID string) (int64, error) {
+	if user == "" {
+		return 0, fmt.Errorf("未登录")
+	}
+	objectID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return 0, fmt.Errorf("无效的ID")
+	}
+	return s.articleRepo.MarkAccountRead(ctx, objectID, user)
+}
+
+// StarArticles 设置或取消用户对文章的星标
+func (s *CrawlerService) StarArticles(ctx context.Context, user string, ids []string, starred bool) error {
+	if user == "" {
+		return fmt.Errorf("未登录")
+	}
+	objectIDs, err := parseArticleIDs(ids)
+	if err != nil {
+		return err
+	}
+	return s.articleRepo.SetStarred(ctx, objectIDs, user, starred)
+}
+
+// TagArticles 给文章添加和移除标签，标签所有用户共用
+func (s *CrawlerService) TagArticles(ctx context.Context, ids []string, add, remove []string) error {
+	objectIDs, err := parseArticleIDs(ids)
+	if err != nil {
+		return err
+	}
+	if add, err = normalizeTags(add); err != nil {
+		return err
+	}
+	if remove, err = normalizeTags(remove); err != nil {
+		return err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return fmt.Errorf("请输入标签")
+	}
+
+	if len(remove) > 0 {
+		if err := s.articleRepo.RemoveTags(ctx, objectIDs, remove); err != nil {
+			return err
+		}
+	}
+	if len(add) > 0 {
+		if err := s.articleRepo.AddTags(ctx, objectIDs, add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListArticleTags 所有标签及使用的文章数，按文章数倒序
+func (s *CrawlerService) ListArticleTags(ctx context.Context) ([]model.TagCount, error) {
+	return s.articleRepo.ListTags(ctx)
+}
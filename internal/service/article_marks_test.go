@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{" 待审 ", "行业  动态", "", "待审", "行业 动态", "AI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[待审 行业 动态 AI]" || len(got) != 3 {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := normalizeTags([]string{strings.Repeat("标", maxTagLength+1)}); err == nil {
+		t.Errorf("超长标签应返回错误")
+	}
+	many := make([]string, maxTagsPerBatch+1)
+	for i := range many {
+		many[i] = fmt.Sprint("标签", i)
+	}
+	if _, err := normalizeTags(many); err == nil {
+		t.Errorf("标签过多应返回错误")
+	}
+}
+
+func TestParseArticleIDs(t *testing.T) {
+	if _, err := parseArticleIDs(nil); err == nil {
+		t.Errorf("没有选择文章应返回错误")
+	}
+	if _, err := parseArticleIDs([]string{"bad"}); err == nil {
+		t.Errorf("无效ID应返回错误")
+	}
+	ids, err := parseArticleIDs([]string{"650000000000000000000001", "650000000000000000000002"})
+	if err != nil || len(ids) != 2 || ids[1].Hex() != "650000000000000000000002" {
+		t.Errorf("got %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/logger"
+	"wechat-crawler/pkg/textdiff"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// RevisionDiff 两个正文版本的并排对比结果
+type RevisionDiff struct {
+	Article *model.Article           `json:"article"`
+	From    *model.ArticleRevision   `json:"from"` // 旧版本（不含正文HTML）
+	To      *model.ArticleRevision   `json:"to"`   // 新版本（不含正文HTML）
+	Rows    []textdiff.Row           `json:"rows"`
+	Stats   textdiff.Stats           `json:"stats"`
+	List    []*model.ArticleRevision `json:"revisions"` // 文章的所有版本，便于切换对比
+}
+
+// newRevision 根据正文HTML生成一个版本
+func newRevision(articleID primitive.ObjectID, number int, content, source string, createdAt time.Time) *model.ArticleRevision {
+	lines := textdiff.NormalizeHTML(content)
+	return &model.ArticleRevision{
+		ArticleID: articleID,
+		Revision:  number,
+		Content:   content,
+		Text:      textdiff.Join(lines),
+		TextHash:  textdiff.Hash(lines),
+		Source:    source,
+		CreatedAt: createdAt,
+	}
+}
+
+// recordRevision 复查时获取到正文后调用，规范化文本与最新版本不同时保存为新版本并更新文章正文
+// 文章首次发现修改时先把采集时的正文保存为第1版；正文尚未采集到的文章由正文重试任务处理，这里跳过
+func (s *CrawlerService) recordRevision(ctx context.Context, article *model.Article, content string, now time.Time) (bool, error) {
+	if content == "" {
+		return false, nil
+	}
+
+	latest, err := s.revisionRepo.Latest(ctx, article.ID)
+	if err != nil {
+		return false, fmt.Errorf("查询最新版本失败: %w", err)
+	}
+	if latest == nil {
+		// 复查列表不含正文，首次对比时读取采集时的正文
+		stored, err := s.articleRepo.FindByID(ctx, article.ID)
+		if err != nil {
+			return false, fmt.Errorf("查询文章失败: %w", err)
+		}
+		if stored.Content == "" {
+			return false, nil
+		}
+		latest = newRevision(article.ID, 1, stored.Content, model.RevisionSourceCrawl, stored.CreatedAt)
+	}
+
+	revision := newRevision(article.ID, latest.Revision+1, content, model.RevisionSourceVerify, now)
+	if revision.TextHash == latest.TextHash {
+		return false, nil
+	}
+
+	if latest.ID.IsZero() {
+		if err := s.revisionRepo.Create(ctx, latest); err != nil {
+			return false, fmt.Errorf("保存采集时的正文失败: %w", err)
+		}
+	}
+	if err := s.revisionRepo.Create(ctx, revision); err != nil {
+		return false, fmt.Errorf("保存新版本失败: %w", err)
+	}
+	if err := s.articleRepo.UpdateRevision(ctx, article.ID, content, s.extractContent(ctx, article, content), revision.Revision, now); err != nil {
+		return false, fmt.Errorf("更新文章正文失败: %w", err)
+	}
+
+	article.Revisions = revision.Revision
+	article.ContentChangedAt = now
+	logger.Info("发现文章正文被修改",
+		zap.String("account", article.AccountName),
+		zap.String("title", article.Title),
+		zap.Int("revision", revision.Revision))
+	return true, nil
+}
+
+// ListRevisions 获取文章的所有正文版本（不含正文HTML），未发现修改的文章返回空列表
+func (s *CrawlerService) ListRevisions(ctx context.Context, articleID string) (*model.Article, []*model.ArticleRevision, error) {
+	article, err := s.GetArticle(ctx, articleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, err := s.revisionRepo.ListByArticle(ctx, article.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询文章版本失败: %w", err)
+	}
+	return article, revisions, nil
+}
+
+// GetRevision 获取文章的指定版本，包含正文HTML
+func (s *CrawlerService) GetRevision(ctx context.Context, articleID string, number int) (*model.ArticleRevision, error) {
+	objectID, err := primitive.ObjectIDFromHex(articleID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID")
+	}
+	revision, err := s.revisionRepo.Get(ctx, objectID, number)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("版本不存在")
+	}
+	return revision, err
+}
+
+// CompareRevisions 并排对比文章的两个版本的规范化文本
+// to 为0时对比最新版本，from 为0时对比 to 的上一个版本
+func (s *CrawlerService) CompareRevisions(ctx context.Context, articleID string, from, to int) (*RevisionDiff, error) {
+	article, revisions, err := s.ListRevisions(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) < 2 {
+		return nil, fmt.Errorf("文章没有修改记录")
+	}
+
+	if to == 0 {
+		to = revisions[len(revisions)-1].Revision
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || from == to {
+		return nil, fmt.Errorf("版本号无效")
+	}
+
+	oldRev, err := s.GetRevision(ctx, articleID, from)
+	if err != nil {
+		return nil, err
+	}
+	newRev, err := s.GetRevision(ctx, articleID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, stats := textdiff.SideBySide(textdiff.Split(oldRev.Text), textdiff.Split(newRev.Text))
+	oldRev.Content, oldRev.Text = "", ""
+	newRev.Content, newRev.Text = "", ""
+	article.Content = ""
+	return &RevisionDiff{
+		Article: article,
+		From:    oldRev,
+		To:      newRev,
+		Rows:    rows,
+		Stats:   stats,
+		List:    revisions,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/search"
+)
+
+// snippetWidth 搜索结果中正文片段的长度（字）
+const snippetWidth = 120
+
+// ErrInvalidSearch 搜索条件不合法
+var ErrInvalidSearch = errors.New("搜索条件不合法")
+
+// SearchHit 全文搜索结果
+type SearchHit struct {
+	Article *model.Article `json:"article"`
+	Title   string         `json:"title"`   // 标题，关键词用 <mark> 标记（已转义的HTML）
+	Snippet string         `json:"snippet"` // 正文（没有正文时为摘要）中关键词附近的片段，关键词用 <mark> 标记（已转义的HTML）
+}
+
+// SearchArticles 按 filter.Keyword 全文搜索文章并高亮关键词，按发布时间倒序
+// 没有关键词时按其他条件筛选，标题和片段不含高亮
+func (s *CrawlerService) SearchArticles(ctx context.Context, filter model.ArticleFilter, page, pageSize int64) ([]*SearchHit, int64, error) {
+	query, err := search.Parse(filter.Keyword)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidSearch, err)
+	}
+
+	articles, total, err := s.articleRepo.ListWithFilter(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var terms []string
+	if query != nil {
+		terms = query.Terms()
+	}
+	hits := make([]*SearchHit, 0, len(articles))
+	for _, article := range articles {
+		body := article.Text
+		if body == "" {
+			body = article.Digest
+		}
+		hits = append(hits, &SearchHit{
+			Article: article,
+			Title:   search.Highlight(article.Title, terms),
+			Snippet: search.Snippet(body, terms, snippetWidth),
+		})
+	}
+	return hits, total, nil
+}
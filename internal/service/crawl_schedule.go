@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// 单个公众号爬取间隔的范围（分钟）
+const (
+	minAccountInterval = 5
+	maxAccountInterval = 30 * 24 * 60 // 30天
+)
+
+// ValidateSchedule 校验公众号的爬取计划
+func ValidateSchedule(schedule model.CrawlSchedule) error {
+	if schedule.Cron != "" {
+		if _, err := cron.ParseStandard(schedule.Cron); err != nil {
+			return fmt.Errorf("cron表达式格式错误（分 时 日 月 周）: %w", err)
+		}
+	}
+	if schedule.Interval != 0 && (schedule.Interval < minAccountInterval || schedule.Interval > maxAccountInterval) {
+		return fmt.Errorf("爬取间隔应在 %d 到 %d 分钟之间", minAccountInterval, maxAccountInterval)
+	}
+	if schedule.Priority < 0 || schedule.Priority > 100 {
+		return fmt.Errorf("优先级应在 0 到 100 之间")
+	}
+	return nil
+}
+
+// nextCrawlTime 根据爬取计划计算下次爬取时间
+// cron表达式优先，其次是公众号自己的间隔，都未设置时按发文规律调度，没有发文规律时使用全局默认间隔
+func nextCrawlTime(schedule model.CrawlSchedule, pattern *model.PostingPattern, from time.Time, defaultInterval time.Duration) time.Time {
+	if schedule.Cron != "" {
+		if sched, err := cron.ParseStandard(schedule.Cron); err == nil {
+			return sched.Next(from)
+		}
+	}
+	if schedule.Interval > 0 {
+		return from.Add(time.Duration(schedule.Interval) * time.Minute)
+	}
+	if pattern.Ready() {
+		return nextAdaptiveCrawlTime(pattern, from, defaultInterval)
+	}
+	return from.Add(defaultInterval)
+}
+
+// dueAccounts 筛选已到爬取时间的公众号
+func dueAccounts(accounts []*model.WeChatAccount, now time.Time) []*model.WeChatAccount {
+	var due []*model.WeChatAccount
+	for _, account := range accounts {
+		if account.NextCrawlAt.IsZero() || !account.NextCrawlAt.After(now) {
+			due = append(due, account)
+		}
+	}
+	return due
+}
+
+// sortByPriority 按优先级从高到低排序，优先级相同时先到期的在前
+func sortByPriority(accounts []*model.WeChatAccount) {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		if accounts[i].Schedule.Priority != accounts[j].Schedule.Priority {
+			return accounts[i].Schedule.Priority > accounts[j].Schedule.Priority
+		}
+		return accounts[i].NextCrawlAt.Before(accounts[j].NextCrawlAt)
+	})
+}
+
+// UpdateSchedule 更新公众号的爬取计划，并按新计划重新计算下次爬取时间
+func (s *CrawlerService) UpdateSchedule(ctx context.Context, id string, schedule model.CrawlSchedule) (*model.WeChatAccount, error) {
+	if err := ValidateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID")
+	}
+
+	account, err := s.wechatRepo.FindByID(ctx, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("公众号不存在")
+	}
+
+	// 从未爬取过的公众号保持尽快爬取
+	var next time.Time
+	if !account.LastCrawlAt.IsZero() {
+		next = nextCrawlTime(schedule, s.patternFor(account), account.LastCrawlAt, s.crawlInterval())
+	}
+	if err := s.wechatRepo.UpdateSchedule(ctx, objectID, schedule, next); err != nil {
+		return nil, fmt.Errorf("保存爬取计划失败: %w", err)
+	}
+
+	logger.Info("更新公众号爬取计划",
+		zap.String("account", account.Name),
+		zap.Int("interval", schedule.Interval),
+		zap.String("cron", schedule.Cron),
+		zap.Int("priority", schedule.Priority),
+		zap.Time("next_crawl_at", next))
+
+	account.Schedule = schedule
+	account.NextCrawlAt = next
+	return account, nil
+}
+
+// applySettings 运行时设置变化后生效：更新请求超时时间，
+// 默认间隔变化时，使用默认间隔的公众号按新间隔重新计算下次爬取时间
+func (s *CrawlerService) applySettings(ctx context.Context, old, cur model.RuntimeSettings) {
+	if old.Timeout != cur.Timeout {
+		s.pool.SetTimeout(time.Duration(cur.Timeout) * time.Second)
+		logger.Info("请求超时时间已更新", zap.Int("timeout", cur.Timeout))
+	}
+	if old.CrawlInterval == cur.CrawlInterval {
+		return
+	}
+
+	accounts, err := s.wechatRepo.List(ctx)
+	if err != nil {
+		logger.Warn("获取公众号列表失败，未重新计算下次爬取时间", zap.Error(err))
+		return
+	}
+	rescheduled := 0
+	for _, account := range accounts {
+		// 设置了自己的间隔或cron的公众号不受影响，从未爬取过的公众号本就会尽快爬取
+		if account.Schedule.Cron != "" || account.Schedule.Interval > 0 || account.LastCrawlAt.IsZero() {
+			continue
+		}
+		next := nextCrawlTime(account.Schedule, s.patternFor(account), account.LastCrawlAt, s.crawlInterval())
+		if err := s.wechatRepo.UpdateCrawlTime(ctx, account.ID, account.LastCrawlAt, next); err != nil {
+			logger.Warn("更新公众号下次爬取时间失败", zap.String("account", account.Name), zap.Error(err))
+			continue
+		}
+		rescheduled++
+	}
+	logger.Info("已按新的默认间隔重新计算下次爬取时间", zap.Int("accounts", rescheduled))
+}
+
+// crawlInterval 当前的全局默认爬取间隔
+func (s *CrawlerService) crawlInterval() time.Duration {
+	return time.Duration(s.settings.Current().CrawlInterval) * time.Minute
+}
+
+// articleFetchCount 当前每次获取的文章数
+func (s *CrawlerService) articleFetchCount() int {
+	return s.settings.Current().FetchCount
+}
+
+// NextScheduledAccount 获取下一个到期的公众号，没有公众号时返回nil
+func (s *CrawlerService) NextScheduledAccount(ctx context.Context) (*model.WeChatAccount, error) {
+	accounts, err := s.wechatRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *model.WeChatAccount
+	for _, account := range accounts {
+		if next == nil || account.NextCrawlAt.Before(next.NextCrawlAt) {
+			next = account
+		}
+	}
+	return next, nil
+}
+
+// advanceSchedule 爬取结束后记录爬取时间并计算下次爬取时间
+func (s *CrawlerService) advanceSchedule(ctx context.Context, account *model.WeChatAccount) {
+	now := time.Now()
+	next := nextCrawlTime(account.Schedule, s.patternFor(account), now, s.crawlInterval())
+	if err := s.wechatRepo.UpdateCrawlTime(ctx, account.ID, now, next); err != nil {
+		logger.Warn("更新公众号下次爬取时间失败", zap.String("account", account.Name), zap.Error(err))
+		return
+	}
+	account.LastCrawlAt = now
+	account.NextCrawlAt = next
+}
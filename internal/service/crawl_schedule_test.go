@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+)
+
+// 验证下次爬取时间的计算：cron优先，其次公众号间隔，最后默认间隔
+func TestNextCrawlTime(t *testing.T) {
+	from := time.Date(2025, 10, 28, 10, 20, 0, 0, time.Local)
+	defaultInterval := 480 * time.Minute
+
+	cases := []struct {
+		name     string
+		schedule model.CrawlSchedule
+		want     time.Time
+	}{
+		{"默认间隔", model.CrawlSchedule{}, from.Add(defaultInterval)},
+		{"公众号间隔", model.CrawlSchedule{Interval: 60}, from.Add(time.Hour)},
+		{"cron优先", model.CrawlSchedule{Interval: 60, Cron: "30 9,18 * * *"}, time.Date(2025, 10, 28, 18, 30, 0, 0, time.Local)},
+	}
+	for _, tc := range cases {
+		if got := nextCrawlTime(tc.schedule, nil, from, defaultInterval); !got.Equal(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// 验证只选出到期的公众号，并按优先级排序
+func TestDueAccountsByPriority(t *testing.T) {
+	now := time.Now()
+	accounts := []*model.WeChatAccount{
+		{Name: "低优先级", NextCrawlAt: now.Add(-time.Hour)},
+		{Name: "未到期", NextCrawlAt: now.Add(time.Hour), Schedule: model.CrawlSchedule{Priority: 100}},
+		{Name: "高优先级", NextCrawlAt: now.Add(-time.Minute), Schedule: model.CrawlSchedule{Priority: 10}},
+		{Name: "新订阅"},
+	}
+
+	due := dueAccounts(accounts, now)
+	sortByPriority(due)
+
+	var names []string
+	for _, account := range due {
+		names = append(names, account.Name)
+	}
+	want := []string{"高优先级", "新订阅", "低优先级"}
+	if len(names) != len(want) {
+		t.Fatalf("到期公众号不正确: got %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("排序不正确: got %v, want %v", names, want)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := []model.CrawlSchedule{
+		{},
+		{Interval: 60, Priority: 5},
+		{Cron: "0 8-22 * * *"},
+	}
+	for _, schedule := range valid {
+		if err := ValidateSchedule(schedule); err != nil {
+			t.Errorf("%+v 应通过校验: %v", schedule, err)
+		}
+	}
+
+	invalid := []model.CrawlSchedule{
+		{Interval: 1},
+		{Cron: "0 0 8 * * *"}, // 不支持秒字段
+		{Priority: -1},
+	}
+	for _, schedule := range invalid {
+		if err := ValidateSchedule(schedule); err == nil {
+			t.Errorf("%+v 应校验失败", schedule)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// BackfillConfig 历史文章回溯的默认配置
+type BackfillConfig struct {
+	PageSize  int           // 每页获取的文章数
+	PageDelay time.Duration // 翻页间隔，避免请求过快被封控
+	MaxCount  int           // 默认最多回溯的文章数，0表示不限制
+	UntilTime int64         // 默认截止发布时间，0表示不限制
+}
+
+// BackfillOptions 单次回溯的参数（零值表示使用默认配置）
+type BackfillOptions struct {
+	UntilTime int64 // 截止发布时间，早于该时间停止回溯
+	MaxCount  int   // 最多回溯的文章数
+	Reset     bool  // 是否丢弃已有游标，从第一页重新开始
+}
+
+// StartBackfill 触发指定公众号的历史文章回溯（异步执行）
+// 若上次回溯未完成且未要求重置，则从持久化的游标处继续
+func (s *CrawlerService) StartBackfill(ctx context.Context, accountID string, opts BackfillOptions) (*model.BackfillState, error) {
+	objectID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID")
+	}
+
+	account, err := s.wechatRepo.FindByID(ctx, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("公众号不存在")
+	}
+
+	if !s.markBackfilling(account.ID) {
+		return nil, fmt.Errorf("该公众号正在回溯历史文章，请勿重复触发")
+	}
+
+	state := account.Backfill
+	if opts.Reset || state == nil || state.Status == model.BackfillStatusDone {
+		state = &model.BackfillState{
+			UntilTime: s.backfill.UntilTime,
+			MaxCount:  s.backfill.MaxCount,
+			StartedAt: time.Now(),
+		}
+	}
+	if opts.UntilTime > 0 {
+		state.UntilTime = opts.UntilTime
+	}
+	if opts.MaxCount > 0 {
+		state.MaxCount = opts.MaxCount
+	}
+	state.Status = model.BackfillStatusRunning
+	state.Error = ""
+	state.UpdatedAt = time.Now()
+
+	if err := s.wechatRepo.UpdateBackfill(ctx, account.ID, state); err != nil {
+		s.unmarkBackfilling(account.ID)
+		return nil, fmt.Errorf("保存回溯进度失败: %w", err)
+	}
+	account.Backfill = state
+
+	logger.Info("开始回溯历史文章",
+		zap.String("account", account.Name),
+		zap.Int("begin", state.Begin),
+		zap.Int64("until_time", state.UntilTime),
+		zap.Int("max_count", state.MaxCount))
+
+	// 使用独立的context，不依赖HTTP请求的生命周期
+	go s.runBackfill(context.Background(), account)
+
+	return state, nil
+}
+
+// ResumeBackfills 恢复服务重启前未完成的回溯任务
+func (s *CrawlerService) ResumeBackfills(ctx context.Context) {
+	accounts, err := s.wechatRepo.ListBackfilling(ctx)
+	if err != nil {
+		logger.Error("查询未完成的回溯任务失败", zap.Error(err))
+		return
+	}
+
+	for _, account := range accounts {
+		if !s.markBackfilling(account.ID) {
+			continue
+		}
+		logger.Info("恢复历史文章回溯",
+			zap.String("account", account.Name),
+			zap.Int("begin", account.Backfill.Begin))
+		go s.runBackfill(ctx, account)
+	}
+}
+
+// runBackfill 按 begin 偏移逐页回溯，每页处理完成后持久化游标
+func (s *CrawlerService) runBackfill(ctx context.Context, account *model.WeChatAccount) {
+	defer s.unmarkBackfilling(account.ID)
+
+	state := account.Backfill
+	pageSize := s.backfill.PageSize
+	if pageSize <= 0 {
+		pageSize = 5
+	}
+
+	finish := func(status string, err error) {
+		state.Status = status
+		state.UpdatedAt = time.Now()
+		state.FinishedAt = state.UpdatedAt
+		if err != nil {
+			state.Error = err.Error()
+		}
+		if saveErr := s.wechatRepo.UpdateBackfill(ctx, account.ID, state); saveErr != nil {
+			logger.Error("保存回溯进度失败", zap.String("account", account.Name), zap.Error(saveErr))
+		}
+		// 回溯到的历史文章用于统计发文规律
+		if state.Saved > 0 && s.adaptive.Enabled {
+			if err := s.RefreshPostingPattern(ctx, account); err != nil {
+				logger.Warn("统计公众号发文规律失败", zap.String("account", account.Name), zap.Error(err))
+			}
+		}
+		logger.Info("历史文章回溯结束",
+			zap.String("account", account.Name),
+			zap.String("status", status),
+			zap.Int("scanned", state.Scanned),
+			zap.Int("saved", state.Saved),
+			zap.Error(err))
+	}
+
+	for {
+		if state.MaxCount > 0 && state.Scanned >= state.MaxCount {
+			finish(model.BackfillStatusDone, nil)
+			return
+		}
+		if state.Total > 0 && state.Begin >= state.Total {
+			finish(model.BackfillStatusDone, nil)
+			return
+		}
+
+		page, err := s.pool.FetchArticlePage(ctx, account.FakeID, state.Begin, pageSize)
+		if err != nil {
+			// 频率限制：等待冷却结束后重试当前页
+			if crawler.IsFreqControl(err) {
+				if err := s.waitRateLimitCooldown(ctx); err != nil {
+					finish(model.BackfillStatusFailed, err)
+					return
+				}
+				continue
+			}
+			finish(model.BackfillStatusFailed, fmt.Errorf("获取文章列表失败: %w", err))
+			return
+		}
+		if page.Total > 0 {
+			state.Total = page.Total
+		}
+		if len(page.Items) == 0 {
+			finish(model.BackfillStatusDone, nil)
+			return
+		}
+
+		// 过滤超出截止时间和数量上限的文章
+		items := page.Items
+		reachedEnd := false
+		for i, item := range items {
+			if state.UntilTime > 0 && item.CreateTime > 0 && item.CreateTime < state.UntilTime {
+				items = items[:i]
+				reachedEnd = true
+				break
+			}
+		}
+		if state.MaxCount > 0 && state.Scanned+len(items) >= state.MaxCount {
+			items = items[:state.MaxCount-state.Scanned]
+			reachedEnd = true
+		}
+
+		var newArticles []*model.Article
+		var pauseErr error
+		for _, item := range items {
+			article, err := s.buildArticle(ctx, account, item)
+			if err != nil {
+				pauseErr = err
+				break
+			}
+			if article != nil {
+				newArticles = append(newArticles, article)
+			}
+		}
+
+		// 与定时爬取并发保存同一篇文章时不会重复，只统计本次新增的文章
+		saved := 0
+		if len(newArticles) > 0 {
+			created, _, err := s.articleRepo.BatchUpsert(ctx, newArticles)
+			if err != nil {
+				finish(model.BackfillStatusFailed, fmt.Errorf("保存文章失败: %w", err))
+				return
+			}
+			saved = len(created)
+		}
+
+		// 正文请求触发频率限制：游标不前进，冷却结束后重试当前页（已保存的文章会被去重跳过）
+		if pauseErr != nil {
+			state.Saved += saved
+			if err := s.waitRateLimitCooldown(ctx); err != nil {
+				finish(model.BackfillStatusFailed, err)
+				return
+			}
+			continue
+		}
+
+		state.Begin += len(page.Items)
+		state.Scanned += len(items)
+		state.Saved += saved
+		state.UpdatedAt = time.Now()
+		if err := s.wechatRepo.UpdateBackfill(ctx, account.ID, state); err != nil {
+			logger.Warn("保存回溯游标失败", zap.String("account", account.Name), zap.Error(err))
+		}
+
+		logger.Info("回溯历史文章进度",
+			zap.String("account", account.Name),
+			zap.Int("begin", state.Begin),
+			zap.Int("total", state.Total),
+			zap.Int("saved", state.Saved))
+
+		if reachedEnd {
+			finish(model.BackfillStatusDone, nil)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			finish(model.BackfillStatusFailed, ctx.Err())
+			return
+		case <-time.After(s.backfill.PageDelay):
+		}
+	}
+}
+
+// markBackfilling 标记公众号进入回溯，已在回溯中时返回false
+func (s *CrawlerService) markBackfilling(id primitive.ObjectID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backfilling[id] {
+		return false
+	}
+	s.backfilling[id] = true
+	return true
+}
+
+// unmarkBackfilling 清除公众号的回溯标记
+func (s *CrawlerService) unmarkBackfilling(id primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.backfilling, id)
+}
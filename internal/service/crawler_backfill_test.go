@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+)
+
+// waitBackfill 等待回溯结束，返回保存的回溯状态
+func waitBackfill(t *testing.T, svc *CrawlerService, account *model.WeChatAccount) *model.BackfillState {
+	t.Helper()
+	var state *model.BackfillState
+	waitFor(t, "回溯结束", func() bool {
+		got, err := svc.GetAccount(context.Background(), account.ID.Hex())
+		if err != nil {
+			t.Fatalf("查询公众号失败: %v", err)
+		}
+		state = got.Backfill
+		return state != nil && state.Status != model.BackfillStatusRunning
+	})
+	// 回溯状态在标记清除前保存，等待标记清除后才能再次触发
+	waitFor(t, "回溯标记清除", func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return !svc.backfilling[account.ID]
+	})
+	return state
+}
+
+// 验证按页回溯全部历史文章，游标和总数与模拟后台一致
+func TestBackfillPaging(t *testing.T) {
+	svc, mp := newFakeCrawler(t, BackfillConfig{PageSize: 5}, nil)
+	account, _ := addFakeAccount(t, svc, mp, "回溯公众号", 12)
+
+	if _, err := svc.StartBackfill(context.Background(), account.ID.Hex(), BackfillOptions{}); err != nil {
+		t.Fatalf("触发回溯失败: %v", err)
+	}
+	state := waitBackfill(t, svc, account)
+
+	if state.Status != model.BackfillStatusDone || state.Error != "" {
+		t.Fatalf("回溯状态: got %s (%s), want done", state.Status, state.Error)
+	}
+	if state.Begin != 12 || state.Total != 12 || state.Scanned != 12 || state.Saved != 12 {
+		t.Errorf("回溯进度: begin=%d total=%d scanned=%d saved=%d, want 12",
+			state.Begin, state.Total, state.Scanned, state.Saved)
+	}
+	if got := countArticles(t, svc, account); got != 12 {
+		t.Errorf("保存的文章数: got %d, want 12", got)
+	}
+
+	// 已完成的回溯再次触发时从第一页重新开始，已保存的文章不会重复保存
+	if _, err := svc.StartBackfill(context.Background(), account.ID.Hex(), BackfillOptions{}); err != nil {
+		t.Fatalf("再次触发回溯失败: %v", err)
+	}
+	state = waitBackfill(t, svc, account)
+	if state.Scanned != 12 || state.Saved != 0 {
+		t.Errorf("再次回溯: scanned=%d saved=%d, want 12/0", state.Scanned, state.Saved)
+	}
+	if got := countArticles(t, svc, account); got != 12 {
+		t.Errorf("再次回溯后的文章数: got %d, want 12", got)
+	}
+}
+
+// 验证服务重启后从持久化的游标处继续回溯
+func TestBackfillResume(t *testing.T) {
+	svc, mp := newFakeCrawler(t, BackfillConfig{PageSize: 5}, nil)
+	account, _ := addFakeAccount(t, svc, mp, "回溯公众号", 12)
+
+	// 模拟上次回溯处理完第一页后服务重启
+	interrupted := &model.BackfillState{
+		Status:    model.BackfillStatusRunning,
+		Begin:     5,
+		Total:     12,
+		Scanned:   5,
+		StartedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := svc.wechatRepo.UpdateBackfill(context.Background(), account.ID, interrupted); err != nil {
+		t.Fatalf("保存回溯进度失败: %v", err)
+	}
+
+	svc.ResumeBackfills(context.Background())
+	state := waitBackfill(t, svc, account)
+
+	if state.Status != model.BackfillStatusDone {
+		t.Fatalf("回溯状态: got %s (%s), want done", state.Status, state.Error)
+	}
+	if state.Begin != 12 || state.Scanned != 12 || state.Saved != 7 {
+		t.Errorf("回溯进度: begin=%d scanned=%d saved=%d, want 12/12/7", state.Begin, state.Scanned, state.Saved)
+	}
+	// 游标之前的文章不会重新获取
+	if got := countArticles(t, svc, account); got != 7 {
+		t.Errorf("保存的文章数: got %d, want 7", got)
+	}
+}
+
+// 验证回溯到截止时间或数量上限时停止
+func TestBackfillLimits(t *testing.T) {
+	t.Run("截止时间", func(t *testing.T) {
+		svc, mp := newFakeCrawler(t, BackfillConfig{PageSize: 5}, nil)
+		account, articles := addFakeAccount(t, svc, mp, "回溯公众号", 12)
+
+		// 只回溯最新的7篇，第8篇早于截止时间
+		until := articles[6].CreateTime
+		if _, err := svc.StartBackfill(context.Background(), account.ID.Hex(), BackfillOptions{UntilTime: until}); err != nil {
+			t.Fatalf("触发回溯失败: %v", err)
+		}
+		state := waitBackfill(t, svc, account)
+
+		if state.Status != model.BackfillStatusDone {
+			t.Fatalf("回溯状态: got %s (%s), want done", state.Status, state.Error)
+		}
+		if state.Scanned != 7 || state.Saved != 7 {
+			t.Errorf("回溯进度: scanned=%d saved=%d, want 7", state.Scanned, state.Saved)
+		}
+		if state.Begin != 10 {
+			t.Errorf("游标: got %d, want 10（停在截止时间所在的页）", state.Begin)
+		}
+		if got := countArticles(t, svc, account); got != 7 {
+			t.Errorf("保存的文章数: got %d, want 7", got)
+		}
+	})
+
+	t.Run("数量上限", func(t *testing.T) {
+		svc, mp := newFakeCrawler(t, BackfillConfig{PageSize: 5, MaxCount: 20}, nil)
+		account, _ := addFakeAccount(t, svc, mp, "回溯公众号", 12)
+
+		// 单次参数覆盖默认配置
+		if _, err := svc.StartBackfill(context.Background(), account.ID.Hex(), BackfillOptions{MaxCount: 7}); err != nil {
+			t.Fatalf("触发回溯失败: %v", err)
+		}
+		state := waitBackfill(t, svc, account)
+
+		if state.Status != model.BackfillStatusDone {
+			t.Fatalf("回溯状态: got %s (%s), want done", state.Status, state.Error)
+		}
+		if state.MaxCount != 7 || state.Scanned != 7 || state.Saved != 7 {
+			t.Errorf("回溯进度: max=%d scanned=%d saved=%d, want 7", state.MaxCount, state.Scanned, state.Saved)
+		}
+		if got := countArticles(t, svc, account); got != 7 {
+			t.Errorf("保存的文章数: got %d, want 7", got)
+		}
+	})
+}
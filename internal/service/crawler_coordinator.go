@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrCrawlRunning 已有爬取任务在执行，同一时间只允许一个任务访问微信
+	ErrCrawlRunning = errors.New("已有爬取任务正在执行")
+	// ErrNoCrawlRunning 当前没有正在执行的爬取任务
+	ErrNoCrawlRunning = errors.New("当前没有正在执行的爬取任务")
+)
+
+// CrawlProgress 当前爬取任务的执行进度
+type CrawlProgress struct {
+	Running        bool      `json:"running"`
+	RunID          string    `json:"run_id"`          // 对应 crawl_runs 中的执行记录
+	Trigger        string    `json:"trigger"`         // 触发来源：cron/manual/api
+	Operator       string    `json:"operator"`        // 手动触发的管理员
+	Total          int       `json:"total"`           // 待爬取的公众号数量
+	Current        int       `json:"current"`         // 正在爬取第几个公众号（从1开始）
+	CurrentAccount string    `json:"current_account"` // 正在爬取的公众号名称
+	NewArticles    int       `json:"new_articles"`    // 已新增的文章数
+	Cancelling     bool      `json:"cancelling"`      // 已请求取消，等待当前公众号处理结束
+	StartedAt      time.Time `json:"started_at"`
+	Elapsed        int64     `json:"elapsed"` // 已执行时长（秒）
+}
+
+// crawlCoordinator 爬取任务协调器，保证同一时间只有一个爬取任务在执行
+type crawlCoordinator struct {
+	progress CrawlProgress
+	cancel   context.CancelFunc
+}
+
+// StartCrawl 在后台启动一次爬取任务，已有任务在执行时返回 ErrCrawlRunning
+func (s *CrawlerService) StartCrawl(trigger, operator string) (CrawlProgress, error) {
+	ctx, err := s.beginCrawl(context.Background(), trigger, operator)
+	if err != nil {
+		return s.CrawlProgress(), err
+	}
+
+	// 使用独立的context，不依赖HTTP请求的生命周期
+	go func() {
+		defer s.endCrawl()
+		if err := s.crawlAllAccounts(ctx, trigger, operator); err != nil {
+			logger.Error("爬取任务执行失败", zap.String("trigger", trigger), zap.Error(err))
+		}
+	}()
+
+	return s.CrawlProgress(), nil
+}
+
+// CrawlProgress 获取当前爬取任务的执行进度
+func (s *CrawlerService) CrawlProgress() CrawlProgress {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	progress := s.crawl.progress
+	if progress.Running {
+		progress.Elapsed = int64(time.Since(progress.StartedAt).Seconds())
+	}
+	return progress
+}
+
+// CancelCrawl 取消正在执行的爬取任务，当前公众号处理结束后停止，剩余公众号记为跳过
+func (s *CrawlerService) CancelCrawl(operator string) error {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	if !s.crawl.progress.Running {
+		return ErrNoCrawlRunning
+	}
+
+	logger.Info("取消爬取任务",
+		zap.String("operator", operator),
+		zap.String("run_id", s.crawl.progress.RunID))
+	s.crawl.progress.Cancelling = true
+	s.crawl.cancel()
+	return nil
+}
+
+// beginCrawl 占用协调器，返回可取消的context
+func (s *CrawlerService) beginCrawl(parent context.Context, trigger, operator string) (context.Context, error) {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	if s.crawl.progress.Running {
+		logger.Warn("已有爬取任务正在执行，拒绝新的任务",
+			zap.String("trigger", trigger),
+			zap.String("running_trigger", s.crawl.progress.Trigger),
+			zap.String("run_id", s.crawl.progress.RunID))
+		return nil, ErrCrawlRunning
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	s.crawl = crawlCoordinator{
+		progress: CrawlProgress{
+			Running:   true,
+			Trigger:   trigger,
+			Operator:  operator,
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	return ctx, nil
+}
+
+// endCrawl 释放协调器
+func (s *CrawlerService) endCrawl() {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	if s.crawl.cancel != nil {
+		s.crawl.cancel()
+	}
+	s.crawl = crawlCoordinator{}
+}
+
+// updateCrawlProgress 更新执行进度
+func (s *CrawlerService) updateCrawlProgress(update func(p *CrawlProgress)) {
+	s.crawlMu.Lock()
+	defer s.crawlMu.Unlock()
+
+	if s.crawl.progress.Running {
+		update(&s.crawl.progress)
+	}
+}
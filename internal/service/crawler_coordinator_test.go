@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"wechat-crawler/internal/model"
+)
+
+// articleGate 阻塞模拟后台的文章正文请求，直到请求被取消
+// 第一次阻塞时通知 entered，用于在爬取进行中检查进度
+type articleGate struct {
+	once    sync.Once
+	entered chan struct{}
+}
+
+func newArticleGate() *articleGate {
+	return &articleGate{entered: make(chan struct{})}
+}
+
+func (g *articleGate) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/s" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		g.once.Do(func() { close(g.entered) })
+		<-r.Context().Done()
+	})
+}
+
+// 验证爬取过程中的进度、同一时间只允许一个任务，以及取消后剩余公众号记为跳过
+func TestCrawlCoordinatorCancel(t *testing.T) {
+	gate := newArticleGate()
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, gate.wrap)
+	addFakeAccount(t, svc, mp, "公众号A", 2)
+	addFakeAccount(t, svc, mp, "公众号B", 2)
+
+	if err := svc.CancelCrawl("admin"); !errors.Is(err, ErrNoCrawlRunning) {
+		t.Fatalf("没有任务时取消: got %v, want ErrNoCrawlRunning", err)
+	}
+
+	progress, err := svc.StartCrawl("manual", "admin")
+	if err != nil {
+		t.Fatalf("启动爬取任务失败: %v", err)
+	}
+	if !progress.Running || progress.Trigger != "manual" || progress.Operator != "admin" {
+		t.Errorf("启动后的进度: got %+v", progress)
+	}
+	<-gate.entered
+
+	// 正在获取第一个公众号的文章正文
+	progress = svc.CrawlProgress()
+	if !progress.Running || progress.RunID == "" || progress.Total != 2 || progress.Current != 1 || progress.CurrentAccount == "" {
+		t.Errorf("执行中的进度: got %+v", progress)
+	}
+	if _, err := svc.StartCrawl("cron", ""); !errors.Is(err, ErrCrawlRunning) {
+		t.Errorf("重复启动: got %v, want ErrCrawlRunning", err)
+	}
+	if err := svc.FetchAllAccounts(context.Background(), "api", ""); !errors.Is(err, ErrCrawlRunning) {
+		t.Errorf("重复同步爬取: got %v, want ErrCrawlRunning", err)
+	}
+
+	if err := svc.CancelCrawl("admin"); err != nil {
+		t.Fatalf("取消爬取任务失败: %v", err)
+	}
+	if progress := svc.CrawlProgress(); progress.Running && !progress.Cancelling {
+		t.Errorf("取消后的进度: got %+v, want cancelling", progress)
+	}
+	waitFor(t, "任务结束", func() bool { return !svc.CrawlProgress().Running })
+
+	run, err := svc.GetCrawlRun(context.Background(), progress.RunID)
+	if err != nil {
+		t.Fatalf("查询执行记录失败: %v", err)
+	}
+	if run.Status != model.CrawlRunCancelled {
+		t.Errorf("任务状态: got %s, want cancelled", run.Status)
+	}
+	if len(run.Accounts) != 2 {
+		t.Fatalf("公众号结果数: got %d, want 2", len(run.Accounts))
+	}
+	if got := run.Accounts[0]; got.AccountName != progress.CurrentAccount || got.Status != model.CrawlAccountSkipped || got.Error != "任务已取消" {
+		t.Errorf("处理中的公众号: got %+v, want skipped", got)
+	}
+	if got := run.Accounts[1]; got.Status != model.CrawlAccountSkipped || got.Error != "任务已取消，已跳过" {
+		t.Errorf("剩余的公众号: got %+v, want skipped", got)
+	}
+
+	// 任务结束后可以重新启动
+	if err := svc.CancelCrawl("admin"); !errors.Is(err, ErrNoCrawlRunning) {
+		t.Errorf("任务结束后取消: got %v, want ErrNoCrawlRunning", err)
+	}
+	if _, err := svc.StartCrawl("manual", "admin"); err != nil {
+		t.Fatalf("任务结束后启动: %v", err)
+	}
+	if err := svc.CancelCrawl("admin"); err != nil && !errors.Is(err, ErrNoCrawlRunning) {
+		t.Fatalf("取消爬取任务失败: %v", err)
+	}
+	waitFor(t, "任务结束", func() bool { return !svc.CrawlProgress().Running })
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ContentRetryConfig 正文获取失败后的重试配置
+type ContentRetryConfig struct {
+	MaxAttempts int           // 最多获取次数（含首次），用尽后不再重试
+	BaseDelay   time.Duration // 首次重试的等待时间，之后每次翻倍
+	MaxDelay    time.Duration // 重试等待时间上限
+	BatchSize   int           // 每轮最多重试的文章数
+}
+
+// fetchRetryDelay 计算第 attempts 次获取失败后的重试等待时间（指数退避）
+func fetchRetryDelay(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// markFetchFailed 记录一次正文获取失败，未用尽重试次数时安排下次重试
+func (s *CrawlerService) markFetchFailed(article *model.Article, err error, now time.Time) {
+	article.FetchError = err.Error()
+	if article.FetchAttempts >= s.retry.MaxAttempts {
+		article.FetchStatus = model.ArticleFetchFailed
+		article.NextFetchAt = time.Time{}
+		return
+	}
+	article.FetchStatus = model.ArticleFetchPending
+	article.NextFetchAt = now.Add(fetchRetryDelay(article.FetchAttempts, s.retry.BaseDelay, s.retry.MaxDelay))
+}
+
+// RetryMissingContent 重新获取已到重试时间的缺失正文（供调度器定时调用）
+// 爬取任务执行中、登录态失效或处于频率限制冷却期时跳过本轮，返回成功补全的文章数
+func (s *CrawlerService) RetryMissingContent(ctx context.Context) (int, error) {
+	if s.CrawlProgress().Running {
+		logger.Debug("爬取任务正在执行，跳过本轮正文重试")
+		return 0, nil
+	}
+	if s.pool.SessionStatus().Expired || s.pool.RateLimitStatus().Paused {
+		logger.Debug("登录态失效或处于频率限制冷却期，跳过本轮正文重试")
+		return 0, nil
+	}
+	if !s.retrying.CompareAndSwap(false, true) {
+		return 0, nil
+	}
+	defer s.retrying.Store(false)
+
+	articles, err := s.articleRepo.ListPendingFetch(ctx, time.Now(), int64(s.retry.BatchSize))
+	if err != nil {
+		return 0, fmt.Errorf("查询待重试文章失败: %w", err)
+	}
+	if len(articles) == 0 {
+		return 0, nil
+	}
+
+	logger.Info("开始重试获取文章正文", zap.Int("count", len(articles)))
+	recovered := 0
+	for _, article := range articles {
+		if ctx.Err() != nil {
+			return recovered, ctx.Err()
+		}
+
+		content, snapshots, err := s.fetchContent(ctx, article.ContentURL)
+		// 频率限制和登录态失效与文章本身无关，不计入重试次数，留到下一轮
+		if crawler.IsFreqControl(err) || crawler.IsSessionExpired(err) {
+			logger.Warn("重试获取正文中断", zap.Int("recovered", recovered), zap.Error(err))
+			return recovered, err
+		}
+
+		article.FetchAttempts++
+		if err == nil {
+			if err := s.articleRepo.UpdateContent(ctx, article.ID, content, s.extractContent(ctx, article, content), article.FetchAttempts); err != nil {
+				logger.Warn("保存重试获取的正文失败", zap.String("title", article.Title), zap.Error(err))
+				continue
+			}
+			if len(snapshots) > 0 {
+				if err := s.articleRepo.AddSnapshots(ctx, article.ID, snapshots); err != nil {
+					logger.Warn("保存页面快照记录失败", zap.String("title", article.Title), zap.Error(err))
+				}
+			}
+			recovered++
+			logger.Info("重试获取正文成功",
+				zap.String("title", article.Title),
+				zap.Int("attempts", article.FetchAttempts))
+			continue
+		}
+
+		if isArticleDeleted(err) {
+			// 文章已删除，保留元数据，不再重试
+			article.FetchStatus = model.ArticleFetchFailed
+			article.FetchError = err.Error()
+			article.NextFetchAt = time.Time{}
+		} else {
+			s.markFetchFailed(article, err, time.Now())
+		}
+		logger.Warn("重试获取正文失败",
+			zap.String("title", article.Title),
+			zap.Int("attempts", article.FetchAttempts),
+			zap.String("status", article.FetchStatus),
+			zap.Time("next_fetch_at", article.NextFetchAt),
+			zap.Error(err))
+
+		if err := s.articleRepo.UpdateFetchFailure(ctx, article.ID, article.FetchStatus, article.FetchAttempts, article.FetchError, article.NextFetchAt); err != nil {
+			logger.Warn("记录正文获取失败状态失败", zap.String("title", article.Title), zap.Error(err))
+		}
+	}
+
+	logger.Info("重试获取文章正文完成",
+		zap.Int("count", len(articles)),
+		zap.Int("recovered", recovered))
+	return recovered, nil
+}
+
+// CountMissingContent 统计正文缺失的文章数量
+func (s *CrawlerService) CountMissingContent(ctx context.Context) (int64, error) {
+	return s.articleRepo.CountMissingContent(ctx)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+)
+
+// 验证重试等待时间按次数翻倍，并且不超过上限
+func TestFetchRetryDelay(t *testing.T) {
+	base := 10 * time.Minute
+	max := time.Hour
+
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 40 * time.Minute},
+		{4, time.Hour},
+		{10, time.Hour},
+	}
+	for _, tc := range cases {
+		if got := fetchRetryDelay(tc.attempts, base, max); got != tc.want {
+			t.Errorf("第%d次失败: got %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+// 验证重试次数用尽后不再安排重试
+func TestMarkFetchFailed(t *testing.T) {
+	s := &CrawlerService{retry: ContentRetryConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}}
+	now := time.Now()
+	timeout := errors.New("context deadline exceeded")
+
+	article := &model.Article{FetchAttempts: 2}
+	s.markFetchFailed(article, timeout, now)
+	if article.FetchStatus != model.ArticleFetchPending || !article.NextFetchAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("未用尽次数时应等待重试: status=%s next=%v", article.FetchStatus, article.NextFetchAt)
+	}
+
+	article.FetchAttempts = 3
+	s.markFetchFailed(article, timeout, now)
+	if article.FetchStatus != model.ArticleFetchFailed || !article.NextFetchAt.IsZero() {
+		t.Fatalf("用尽次数后不应再重试: status=%s next=%v", article.FetchStatus, article.NextFetchAt)
+	}
+	if article.FetchError != timeout.Error() {
+		t.Fatalf("应记录失败原因: %s", article.FetchError)
+	}
+}
+
+// 验证只有数据源明确识别的删除、屏蔽才跳过文章，页面缺少正文等其他失败进入重试
+func TestContentMissingIsRetried(t *testing.T) {
+	var broken atomic.Bool // 为true时文章页面缺少 #js_content（加载不完整或页面结构变化）
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/s" && broken.Load() {
+				fmt.Fprint(w, `<html><head><title>文章标题</title></head><body><div id="loading"></div></body></html>`)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+
+	broken.Store(true)
+	account, _ := addFakeAccount(t, svc, mp, "测试公众号", 1)
+	articles, err := svc.FetchLatestArticles(ctx, account)
+	if err != nil {
+		t.Fatalf("采集文章失败: %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("缺少正文的文章应保存元数据: got %d 篇, want 1", len(articles))
+	}
+	saved, err := svc.GetArticle(ctx, articles[0].ID.Hex())
+	if err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	if saved.FetchStatus != model.ArticleFetchPending || saved.NextFetchAt.IsZero() || !strings.Contains(saved.FetchError, "元素不存在") {
+		t.Errorf("应等待重试: status=%s next=%v error=%q", saved.FetchStatus, saved.NextFetchAt, saved.FetchError)
+	}
+
+	for _, err := range []error{
+		fmt.Errorf("获取文章失败: %w: 该内容已被发布者删除", crawler.ErrArticleDeleted),
+		fmt.Errorf("%w: 此内容因违规无法查看", crawler.ErrArticleBlocked),
+	} {
+		if !isArticleDeleted(err) {
+			t.Errorf("isArticleDeleted(%v) = false, want true", err)
+		}
+	}
+	for _, err := range []error{
+		errors.New("文章内容元素不存在，可能文章已删除或页面结构变化"),
+		errors.New("公众号不存在"),
+		context.DeadlineExceeded,
+	} {
+		if isArticleDeleted(err) {
+			t.Errorf("isArticleDeleted(%v) = true, want false", err)
+		}
+	}
+}
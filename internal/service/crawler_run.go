@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// GetCrawlRuns 分页获取爬取任务执行记录
+func (s *CrawlerService) GetCrawlRuns(ctx context.Context, trigger string, page, pageSize int64) ([]*model.CrawlRun, int64, error) {
+	return s.runRepo.List(ctx, trigger, page, pageSize)
+}
+
+// GetCrawlRun 获取单次爬取任务的执行记录
+func (s *CrawlerService) GetCrawlRun(ctx context.Context, id string) (*model.CrawlRun, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID")
+	}
+
+	run, err := s.runRepo.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("执行记录不存在")
+	}
+	return run, err
+}
+
+// RecoverCrawlRuns 服务启动时将上次未结束的执行记录标记为中断
+func (s *CrawlerService) RecoverCrawlRuns(ctx context.Context) {
+	count, err := s.runRepo.MarkInterrupted(ctx)
+	if err != nil {
+		logger.Warn("标记中断的爬取任务失败", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("已将服务重启前未结束的爬取任务标记为中断", zap.Int64("count", count))
+	}
+}
+
+// 以下方法记录执行过程，写入失败只记录日志，不影响爬取本身
+
+// startCrawlRun 创建执行记录，失败时返回nil
+func (s *CrawlerService) startCrawlRun(ctx context.Context, trigger, operator string) *model.CrawlRun {
+	run := &model.CrawlRun{
+		Trigger:  trigger,
+		Operator: operator,
+		Status:   model.CrawlRunRunning,
+	}
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		logger.Warn("创建爬取任务记录失败", zap.Error(err))
+		return nil
+	}
+	return run
+}
+
+// setCrawlRunTotal 记录待爬取的公众号数量
+func (s *CrawlerService) setCrawlRunTotal(ctx context.Context, run *model.CrawlRun, total int) {
+	if run == nil {
+		return
+	}
+	run.Total = total
+	if err := s.runRepo.UpdateTotal(ctx, run.ID, total); err != nil {
+		logger.Warn("更新爬取任务记录失败", zap.Error(err))
+	}
+}
+
+// recordCrawlAccount 记录单个公众号的爬取结果
+func (s *CrawlerService) recordCrawlAccount(ctx context.Context, run *model.CrawlRun, account *model.WeChatAccount, startedAt time.Time, newCount int, err error) {
+	if run == nil {
+		return
+	}
+
+	result := model.CrawlRunAccount{
+		AccountID:   account.ID,
+		AccountName: account.Name,
+		Status:      model.CrawlAccountSuccess,
+		NewCount:    newCount,
+		StartedAt:   startedAt,
+		FinishedAt:  time.Now(),
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		// 处理过程中任务被取消，已获取的文章已保存
+		result.Status = model.CrawlAccountSkipped
+		result.Error = "任务已取消"
+	case err != nil:
+		result.Status = model.CrawlAccountFailed
+		result.Error = err.Error()
+	}
+
+	s.addCrawlRunResult(ctx, run, result)
+}
+
+// skipCrawlAccounts 记录因登录态失效或频率限制而跳过的公众号
+func (s *CrawlerService) skipCrawlAccounts(ctx context.Context, run *model.CrawlRun, accounts []*model.WeChatAccount, reason error) {
+	if run == nil {
+		return
+	}
+
+	msg := "已跳过"
+	switch {
+	case errors.Is(reason, context.Canceled):
+		msg = "任务已取消，已跳过"
+	case crawler.IsSessionExpired(reason):
+		msg = "登录态失效，已跳过"
+	case crawler.IsFreqControl(reason):
+		msg = "触发频率限制，已跳过"
+	}
+
+	now := time.Now()
+	for _, account := range accounts {
+		s.addCrawlRunResult(ctx, run, model.CrawlRunAccount{
+			AccountID:   account.ID,
+			AccountName: account.Name,
+			Status:      model.CrawlAccountSkipped,
+			Error:       msg,
+			StartedAt:   now,
+			FinishedAt:  now,
+		})
+	}
+}
+
+// addCrawlRunResult 追加公众号结果
+func (s *CrawlerService) addCrawlRunResult(ctx context.Context, run *model.CrawlRun, result model.CrawlRunAccount) {
+	run.Accounts = append(run.Accounts, result)
+	run.NewArticles += result.NewCount
+	if err := s.runRepo.AddAccountResult(ctx, run.ID, result); err != nil {
+		logger.Warn("记录公众号爬取结果失败", zap.String("account", result.AccountName), zap.Error(err))
+	}
+}
+
+// finishCrawlRun 结束执行记录
+func (s *CrawlerService) finishCrawlRun(ctx context.Context, run *model.CrawlRun, status string, runErr error) {
+	if run == nil {
+		return
+	}
+
+	run.Status = status
+	run.FinishedAt = time.Now()
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+
+	if err := s.runRepo.Finish(ctx, run.ID, run.Status, run.Error); err != nil {
+		logger.Warn("结束爬取任务记录失败", zap.Error(err))
+	}
+
+	logger.Info("爬取任务执行记录已保存",
+		zap.String("run_id", run.ID.Hex()),
+		zap.String("status", run.Status),
+		zap.Int("new_articles", run.NewArticles),
+		zap.Duration("elapsed", run.FinishedAt.Sub(run.StartedAt)))
+}
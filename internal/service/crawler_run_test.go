@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"wechat-crawler/internal/model"
+)
+
+// latestCrawlRun 获取最近一次爬取任务的执行记录
+func latestCrawlRun(t *testing.T, svc *CrawlerService) *model.CrawlRun {
+	t.Helper()
+	runs, total, err := svc.GetCrawlRuns(context.Background(), "", 1, 1)
+	if err != nil {
+		t.Fatalf("查询执行记录失败: %v", err)
+	}
+	if total == 0 || len(runs) == 0 {
+		t.Fatalf("没有执行记录")
+	}
+	run, err := svc.GetCrawlRun(context.Background(), runs[0].ID.Hex())
+	if err != nil {
+		t.Fatalf("查询执行记录失败: %v", err)
+	}
+	return run
+}
+
+// accountResults 按公众号名称索引执行记录中的结果
+func accountResults(run *model.CrawlRun) map[string]model.CrawlRunAccount {
+	results := make(map[string]model.CrawlRunAccount)
+	for _, result := range run.Accounts {
+		results[result.AccountName] = result
+	}
+	return results
+}
+
+// 验证所有公众号爬取成功时记录每个公众号的新增文章数
+func TestCrawlRunSuccess(t *testing.T) {
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, nil)
+	addFakeAccount(t, svc, mp, "公众号A", 3)
+	addFakeAccount(t, svc, mp, "公众号B", 2)
+
+	if err := svc.FetchAllAccounts(context.Background(), "manual", "admin"); err != nil {
+		t.Fatalf("爬取失败: %v", err)
+	}
+
+	run := latestCrawlRun(t, svc)
+	if run.Status != model.CrawlRunSuccess || run.Error != "" {
+		t.Errorf("任务状态: got %s (%s), want success", run.Status, run.Error)
+	}
+	if run.Trigger != "manual" || run.Operator != "admin" {
+		t.Errorf("触发来源: got %s/%s, want manual/admin", run.Trigger, run.Operator)
+	}
+	if run.Total != 2 || run.NewArticles != 5 || run.FinishedAt.IsZero() {
+		t.Errorf("执行记录: total=%d new=%d finished=%v, want 2/5/非空", run.Total, run.NewArticles, run.FinishedAt)
+	}
+	results := accountResults(run)
+	for name, want := range map[string]int{"公众号A": 3, "公众号B": 2} {
+		if got := results[name]; got.Status != model.CrawlAccountSuccess || got.NewCount != want {
+			t.Errorf("%s: got %s/%d, want success/%d", name, got.Status, got.NewCount, want)
+		}
+	}
+
+	// 没有新文章时仍记录为成功
+	if err := svc.FetchAllAccounts(context.Background(), "cron", ""); err != nil {
+		t.Fatalf("再次爬取失败: %v", err)
+	}
+	run = latestCrawlRun(t, svc)
+	if run.Status != model.CrawlRunSuccess || run.Trigger != "cron" || run.NewArticles != 0 {
+		t.Errorf("再次爬取: got %s/%s/%d, want success/cron/0", run.Status, run.Trigger, run.NewArticles)
+	}
+}
+
+// 验证单个公众号失败时继续爬取其余公众号，任务记为部分成功
+func TestCrawlRunPartial(t *testing.T) {
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, nil)
+	addFakeAccount(t, svc, mp, "公众号A", 2)
+	// 模拟后台中不存在的公众号，获取文章列表会失败
+	missing := &model.WeChatAccount{Name: "已注销", FakeID: "missing", Status: 1}
+	if err := svc.wechatRepo.Create(context.Background(), missing); err != nil {
+		t.Fatalf("保存公众号失败: %v", err)
+	}
+
+	if err := svc.FetchAllAccounts(context.Background(), "manual", "admin"); err != nil {
+		t.Fatalf("爬取失败: %v", err)
+	}
+
+	run := latestCrawlRun(t, svc)
+	if run.Status != model.CrawlRunPartial {
+		t.Errorf("任务状态: got %s, want partial", run.Status)
+	}
+	results := accountResults(run)
+	if got := results["公众号A"]; got.Status != model.CrawlAccountSuccess || got.NewCount != 2 {
+		t.Errorf("公众号A: got %s/%d, want success/2", got.Status, got.NewCount)
+	}
+	if got := results["已注销"]; got.Status != model.CrawlAccountFailed || got.Error == "" {
+		t.Errorf("已注销: got %s (%s), want failed", got.Status, got.Error)
+	}
+}
+
+// 验证触发频率限制后跳过剩余公众号
+func TestCrawlRunFreqControl(t *testing.T) {
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, nil)
+	addFakeAccount(t, svc, mp, "公众号A", 2)
+	addFakeAccount(t, svc, mp, "公众号B", 2)
+	mp.SetFreqControl(true)
+
+	if err := svc.FetchAllAccounts(context.Background(), "manual", "admin"); err == nil {
+		t.Fatalf("触发频率限制时应返回错误")
+	}
+
+	run := latestCrawlRun(t, svc)
+	if run.Status != model.CrawlRunPartial || run.Error == "" {
+		t.Errorf("任务状态: got %s (%s), want partial", run.Status, run.Error)
+	}
+	if len(run.Accounts) != 2 {
+		t.Fatalf("公众号结果数: got %d, want 2", len(run.Accounts))
+	}
+	if got := run.Accounts[0]; got.Status != model.CrawlAccountFailed {
+		t.Errorf("%s: got %s, want failed", got.AccountName, got.Status)
+	}
+	if got := run.Accounts[1]; got.Status != model.CrawlAccountSkipped || got.Error != "触发频率限制，已跳过" {
+		t.Errorf("%s: got %s (%s), want skipped", got.AccountName, got.Status, got.Error)
+	}
+}
+
+// 验证服务重启后未结束的执行记录标记为中断
+func TestRecoverCrawlRuns(t *testing.T) {
+	svc, _ := newFakeCrawler(t, BackfillConfig{}, nil)
+	ctx := context.Background()
+
+	running := svc.startCrawlRun(ctx, "cron", "")
+	if running == nil {
+		t.Fatalf("创建执行记录失败")
+	}
+	finished := svc.startCrawlRun(ctx, "manual", "admin")
+	svc.finishCrawlRun(ctx, finished, model.CrawlRunSuccess, nil)
+
+	svc.RecoverCrawlRuns(ctx)
+
+	got, err := svc.GetCrawlRun(ctx, running.ID.Hex())
+	if err != nil || got.Status != model.CrawlRunInterrupted || got.FinishedAt.IsZero() {
+		t.Errorf("未结束的记录: got %+v, %v, want interrupted", got, err)
+	}
+	got, err = svc.GetCrawlRun(ctx, finished.ID.Hex())
+	if err != nil || got.Status != model.CrawlRunSuccess {
+		t.Errorf("已结束的记录: got %+v, %v, want success", got, err)
+	}
+
+	if _, err := svc.GetCrawlRun(ctx, "ffffffffffffffffffffffff"); err == nil {
+		t.Errorf("不存在的执行记录应返回错误")
+	}
+}
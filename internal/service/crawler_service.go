@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"wechat-crawler/internal/crawler"
 	"wechat-crawler/internal/model"
@@ -12,29 +14,55 @@ import (
 	"wechat-crawler/pkg/logger"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
 // CrawlerService 爬虫业务逻辑服务
 type CrawlerService struct {
-	browser     *crawler.Browser
-	wechatRepo  *repository.WeChatAccountRepo
-	articleRepo *repository.ArticleRepo
-	concurrent  int
-	fetchCount  int
-	mu          sync.Mutex
+	pool         *crawler.SessionPool // 运营者会话池，按策略为每个请求分配会话
+	wechatRepo   repository.WeChatAccountRepo
+	articleRepo  repository.ArticleRepo
+	revisionRepo repository.ArticleRevisionRepo
+	runRepo      repository.CrawlRunRepo
+	concurrent   int
+	settings     *SettingsService // 运行时设置，默认爬取间隔和每次获取的文章数实时读取
+	backfill     BackfillConfig
+	retry        ContentRetryConfig
+	adaptive     AdaptiveConfig
+	verify       VerifyConfig
+	media        *MediaService               // 图片归档和页面快照，都未启用时为nil
+	retrying     atomic.Bool                 // 正文重试是否正在执行
+	verifying    atomic.Bool                 // 文章复查是否正在执行
+	backfilling  map[primitive.ObjectID]bool // 正在回溯历史文章的公众号
+	mu           sync.Mutex
+	crawl        crawlCoordinator // 当前爬取任务，同一时间只允许一个
+	crawlMu      sync.Mutex
 }
 
 // NewCrawlerService 创建爬虫服务实例
-func NewCrawlerService(browser *crawler.Browser, concurrent int) *CrawlerService {
-	return &CrawlerService{
-		browser:     browser,
-		wechatRepo:  repository.NewWeChatAccountRepo(),
-		articleRepo: repository.NewArticleRepo(),
-		concurrent:  concurrent,
-		fetchCount:  10, // 每次获取最新10篇文章
+// 默认爬取间隔、每次获取的文章数和请求超时时间从 settings 实时读取，修改后立即生效
+func NewCrawlerService(pool *crawler.SessionPool, concurrent int, settings *SettingsService, backfill BackfillConfig, retry ContentRetryConfig, adaptive AdaptiveConfig, verify VerifyConfig) *CrawlerService {
+	s := &CrawlerService{
+		pool:         pool,
+		wechatRepo:   repository.NewWeChatAccountRepo(),
+		articleRepo:  repository.NewArticleRepo(),
+		revisionRepo: repository.NewArticleRevisionRepo(),
+		runRepo:      repository.NewCrawlRunRepo(),
+		concurrent:   concurrent,
+		settings:     settings,
+		backfill:     backfill,
+		retry:        retry,
+		adaptive:     adaptive,
+		verify:       verify,
+		backfilling:  make(map[primitive.ObjectID]bool),
 	}
+	settings.OnChange(s.applySettings)
+	return s
+}
+
+// UseMedia 启用图片归档和页面快照，采集和更新正文时把正文图片、封面和页面快照保存到文件存储
+func (s *CrawlerService) UseMedia(media *MediaService) {
+	s.media = media
 }
 
 // AddAccount 添加公众号订阅
@@ -48,7 +76,7 @@ func (s *CrawlerService) AddAccount(ctx context.Context, name, alias string) (*m
 	}
 
 	// 搜索公众号获取FakeID
-	fakeID, err := s.browser.SearchAccount(name)
+	fakeID, err := s.pool.SearchAccount(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("搜索公众号失败: %w", err)
 	}
@@ -95,7 +123,7 @@ func (s *CrawlerService) FetchLatestArticles(ctx context.Context, account *model
 		zap.String("fakeID", account.FakeID))
 
 	// 获取文章列表
-	articleList, err := s.browser.FetchArticles(account.FakeID, s.fetchCount)
+	articleList, err := s.pool.FetchArticles(ctx, account.FakeID, s.articleFetchCount())
 	if err != nil {
 		return nil, fmt.Errorf("获取文章列表失败: %w", err)
 	}
@@ -107,107 +135,292 @@ func (s *CrawlerService) FetchLatestArticles(ctx context.Context, account *model
 
 	// 检查是否有新文章
 	var newArticles []*model.Article
+	var pauseErr error
 	for _, item := range articleList {
-		// 如果文章URL等于last_article，说明之前的文章都已采集过
-		if account.LastArticle != "" && item.ContentURL == account.LastArticle {
-			logger.Info("已到达上次采集位置", zap.String("url", item.ContentURL))
+		// 任务被取消时停止处理剩余文章，已获取的文章照常保存
+		if ctx.Err() != nil {
+			pauseErr = ctx.Err()
 			break
 		}
 
-		// 检查文章是否已存在
-		exists, err := s.articleRepo.ExistsByContentURL(ctx, item.ContentURL)
-		if err != nil {
-			logger.Warn("检查文章是否存在失败", zap.Error(err))
-			continue
-		}
-
-		if exists {
-			logger.Debug("文章已存在，跳过", zap.String("title", item.Title))
-			continue
+		// 如果文章URL等于last_article，说明之前的文章都已采集过（按规范化链接比较）
+		if account.LastArticle != "" && model.ArticleURLKey(item.ContentURL) == model.ArticleURLKey(account.LastArticle) {
+			logger.Info("已到达上次采集位置", zap.String("url", item.ContentURL))
+			break
 		}
 
-		// 获取文章详细内容
-		content, err := s.browser.FetchArticleContent(item.ContentURL)
+		article, err := s.buildArticle(ctx, account, item)
 		if err != nil {
-			// 判断是否是文章已删除的错误
-			errMsg := err.Error()
-			if strings.Contains(errMsg, "已删除") ||
-				strings.Contains(errMsg, "不存在") ||
-				strings.Contains(errMsg, "元素不存在") {
-				logger.Warn("文章已删除或不可访问，跳过",
-					zap.String("title", item.Title),
-					zap.String("url", item.ContentURL),
-					zap.Error(err))
-				continue // 跳过已删除的文章，不保存
-			}
-			// 其他错误（如网络超时等），记录警告但保存文章元数据
-			logger.Warn("获取文章内容失败，仅保存元数据",
-				zap.String("title", item.Title),
-				zap.String("url", item.ContentURL),
-				zap.Error(err))
-			content = "" // 保存空内容
+			// 触发频率限制，停止处理剩余文章
+			pauseErr = err
+			break
 		}
-
-		// 构造文章对象
-		article := &model.Article{
-			AccountID:   account.ID,
-			AccountName: account.Name,
-			Title:       item.Title,
-			Author:      item.Author,
-			Digest:      item.Digest,
-			Content:     content,
-			ContentURL:  item.ContentURL,
-			Cover:       item.Cover,
-			SourceURL:   item.SourceURL,
-			PublishTime: item.CreateTime,
+		if article == nil {
+			continue
 		}
 
 		newArticles = append(newArticles, article)
 	}
 
-	// 批量保存新文章
+	// 批量保存新文章（任务被取消时已获取的文章照常保存）
+	// 其他任务同时采集到的文章不会重复保存，只有本次新增的文章计入结果
+	var created []*model.Article
 	if len(newArticles) > 0 {
-		if err := s.articleRepo.BatchCreate(ctx, newArticles); err != nil {
+		ctx := context.WithoutCancel(ctx)
+		var existing []*model.Article
+		var err error
+		created, existing, err = s.articleRepo.BatchUpsert(ctx, newArticles)
+		if err != nil {
 			return nil, fmt.Errorf("保存文章失败: %w", err)
 		}
+		if len(existing) > 0 {
+			logger.Info("部分文章已由其他任务保存，跳过",
+				zap.String("account", account.Name),
+				zap.Int("count", len(existing)))
+		}
 
 		// 更新公众号的最后文章URL
-		latestArticleURL := newArticles[0].ContentURL
-		if err := s.wechatRepo.UpdateLastArticle(ctx, account.ID, latestArticleURL); err != nil {
-			logger.Warn("更新最后文章URL失败", zap.Error(err))
+		// 中途被频率限制打断或任务被取消时不更新，下次从头检查，已保存的文章会被去重跳过
+		if pauseErr == nil && len(created) > 0 {
+			latestArticleURL := created[0].ContentURL
+			if err := s.wechatRepo.UpdateLastArticle(ctx, account.ID, latestArticleURL); err != nil {
+				logger.Warn("更新最后文章URL失败", zap.Error(err))
+			}
 		}
-
+	}
+	if len(created) > 0 {
 		logger.Info("保存新文章成功",
 			zap.String("account", account.Name),
-			zap.Int("count", len(newArticles)))
+			zap.Int("count", len(created)))
 	} else {
 		logger.Info("没有新文章", zap.String("account", account.Name))
 	}
 
-	return newArticles, nil
+	if pauseErr != nil {
+		return created, fmt.Errorf("获取文章内容失败: %w", pauseErr)
+	}
+	return created, nil
 }
 
-// FetchAllAccounts 爬取所有订阅的公众号
-func (s *CrawlerService) FetchAllAccounts(ctx context.Context) error {
-	logger.Info("开始执行定时爬取任务")
+// buildArticle 检查文章是否已采集，未采集时获取正文并构造文章对象
+// 返回nil表示文章已存在或已被删除，应跳过；仅在触发频率限制或任务被取消时返回错误
+func (s *CrawlerService) buildArticle(ctx context.Context, account *model.WeChatAccount, item *model.ArticleListItem) (*model.Article, error) {
+	// 检查文章是否已存在
+	exists, err := s.articleRepo.ExistsByContentURL(ctx, item.ContentURL)
+	if err != nil {
+		logger.Warn("检查文章是否存在失败", zap.Error(err))
+		return nil, nil
+	}
+
+	if exists {
+		logger.Debug("文章已存在，跳过", zap.String("title", item.Title))
+		return nil, nil
+	}
 
-	// 获取所有公众号
+	// 获取文章详细内容
+	article := &model.Article{
+		AccountID:     account.ID,
+		AccountName:   account.Name,
+		Title:         item.Title,
+		Author:        item.Author,
+		Digest:        item.Digest,
+		ContentURL:    item.ContentURL,
+		Cover:         item.Cover,
+		SourceURL:     item.SourceURL,
+		PublishTime:   item.CreateTime,
+		FetchStatus:   model.ArticleFetchSuccess,
+		FetchAttempts: 1,
+	}
+	content, snapshots, err := s.fetchContent(ctx, item.ContentURL)
+	if err != nil {
+		// 触发频率限制或任务被取消，停止处理剩余文章
+		if crawler.IsFreqControl(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		// 判断是否是文章已删除的错误
+		if isArticleDeleted(err) {
+			logger.Warn("文章已删除或不可访问，跳过",
+				zap.String("title", item.Title),
+				zap.String("url", item.ContentURL),
+				zap.Error(err))
+			return nil, nil // 跳过已删除的文章，不保存
+		}
+		// 其他错误（如网络超时等），保存文章元数据，由后台重试获取正文
+		logger.Warn("获取文章内容失败，仅保存元数据，稍后重试",
+			zap.String("title", item.Title),
+			zap.String("url", item.ContentURL),
+			zap.Error(err))
+		s.markFetchFailed(article, err, time.Now())
+		article.ArticleExtract = s.extractContent(ctx, article, "")
+		return article, nil
+	}
+
+	article.Content = content
+	article.ArticleExtract = s.extractContent(ctx, article, content)
+	article.Snapshots = snapshots
+	return article, nil
+}
+
+// fetchContent 获取文章正文，启用页面快照时在同一次页面加载中保存快照
+func (s *CrawlerService) fetchContent(ctx context.Context, articleURL string) (string, []model.ArticleSnapshot, error) {
+	if s.media == nil || len(s.media.SnapshotFormats()) == 0 {
+		content, err := s.pool.FetchArticleContent(ctx, articleURL)
+		return content, nil, err
+	}
+
+	content, snapshots, err := s.pool.FetchArticleSnapshot(ctx, articleURL, s.media.SnapshotFormats())
+	if err != nil {
+		return "", nil, err
+	}
+	return content, s.media.SaveSnapshots(ctx, snapshots), nil
+}
+
+// isArticleDeleted 判断正文获取失败是否因为文章已删除或被屏蔽，这类文章无需重试
+// 只认数据源根据页面明确识别的删除、屏蔽错误；页面加载不完整、结构变化等其他错误都进入重试
+func isArticleDeleted(err error) bool {
+	return errors.Is(err, crawler.ErrArticleDeleted) || errors.Is(err, crawler.ErrArticleBlocked)
+}
+
+// FetchAllAccounts 同步爬取所有订阅的公众号，已有任务在执行时返回 ErrCrawlRunning
+// trigger 为触发来源（cron/manual/api），operator 为手动触发的管理员
+func (s *CrawlerService) FetchAllAccounts(ctx context.Context, trigger, operator string) error {
+	ctx, err := s.beginCrawl(ctx, trigger, operator)
+	if err != nil {
+		return err
+	}
+	defer s.endCrawl()
+
+	return s.crawlAllAccounts(ctx, trigger, operator)
+}
+
+// crawlAllAccounts 不论是否到期，爬取所有公众号（手动触发）
+func (s *CrawlerService) crawlAllAccounts(ctx context.Context, trigger, operator string) error {
 	accounts, err := s.wechatRepo.List(ctx)
 	if err != nil {
 		logger.Error("获取公众号列表失败", zap.Error(err))
+	}
+	return s.crawlAccounts(ctx, trigger, operator, accounts, err)
+}
+
+// FetchDueAccounts 爬取已到爬取时间的公众号（供调度器定时调用）
+// 没有到期的公众号，或登录态失效、所有会话都在冷却中时直接返回，不产生执行记录
+func (s *CrawlerService) FetchDueAccounts(ctx context.Context, trigger string) error {
+	if s.pool.SessionStatus().Expired || s.pool.RateLimitStatus().Paused {
+		logger.Debug("登录态失效或处于频率限制冷却期，跳过本次调度")
+		return nil
+	}
+
+	ctx, err := s.beginCrawl(ctx, trigger, "")
+	if err != nil {
 		return err
 	}
+	defer s.endCrawl()
+
+	accounts, err := s.wechatRepo.List(ctx)
+	if err != nil {
+		logger.Error("获取公众号列表失败", zap.Error(err))
+		return err
+	}
+
+	due := dueAccounts(accounts, time.Now())
+	if len(due) == 0 {
+		return nil
+	}
+	return s.crawlAccounts(ctx, trigger, "", due, nil)
+}
+
+// crawlAccounts 按优先级顺序爬取公众号，执行过程记录到 crawl_runs，调用方需先占用协调器
+// listErr 为获取公众号列表时的错误，用于记录失败的执行记录
+func (s *CrawlerService) crawlAccounts(ctx context.Context, trigger, operator string, accounts []*model.WeChatAccount, listErr error) error {
+	logger.Info("开始执行定时爬取任务", zap.String("trigger", trigger))
+
+	// 取消任务后仍需写入执行记录
+	recordCtx := context.WithoutCancel(ctx)
+	run := s.startCrawlRun(recordCtx, trigger, operator)
+	if run != nil {
+		s.updateCrawlProgress(func(p *CrawlProgress) { p.RunID = run.ID.Hex() })
+	}
+
+	if listErr != nil {
+		s.finishCrawlRun(recordCtx, run, model.CrawlRunFailed, listErr)
+		return listErr
+	}
 
 	if len(accounts) == 0 {
 		logger.Info("没有订阅的公众号")
+		s.finishCrawlRun(recordCtx, run, model.CrawlRunSuccess, nil)
 		return nil
 	}
+	sortByPriority(accounts)
+	s.setCrawlRunTotal(recordCtx, run, len(accounts))
+	s.updateCrawlProgress(func(p *CrawlProgress) { p.Total = len(accounts) })
+
+	// 登录态已失效时所有请求都会失败，直接跳过本次任务
+	if status := s.pool.SessionStatus(); status.Expired {
+		logger.Warn("公众号登录态已失效，跳过本次爬取，请在管理后台重新扫码登录",
+			zap.Time("expired_at", status.ExpiredAt))
+		s.finishCrawlRun(recordCtx, run, model.CrawlRunFailed, crawler.ErrSessionExpired)
+		return crawler.ErrSessionExpired
+	}
 
 	logger.Info("待爬取公众号数量", zap.Int("count", len(accounts)))
+	runStatus := model.CrawlRunSuccess
 	//顺序爬取公号，否则会被封控
-	for _, account := range accounts {
+	for i, account := range accounts {
+		// 管理后台取消任务后不再处理剩余公众号
+		if ctx.Err() != nil {
+			logger.Warn("爬取任务已取消，跳过剩余公众号", zap.Int("skipped", len(accounts)-i))
+			s.skipCrawlAccounts(recordCtx, run, accounts[i:], ctx.Err())
+			s.finishCrawlRun(recordCtx, run, model.CrawlRunCancelled, ctx.Err())
+			return ctx.Err()
+		}
+		s.updateCrawlProgress(func(p *CrawlProgress) {
+			p.Current = i + 1
+			p.CurrentAccount = account.Name
+		})
+
+		startedAt := time.Now()
 		articles, err := s.FetchLatestArticles(ctx, account)
+		s.recordCrawlAccount(recordCtx, run, account, startedAt, len(articles), err)
+		s.updateCrawlProgress(func(p *CrawlProgress) { p.NewArticles += len(articles) })
+
+		// 有新文章时重新统计发文规律，用于计算下次爬取时间
+		if len(articles) > 0 && s.adaptive.Enabled {
+			if err := s.RefreshPostingPattern(recordCtx, account); err != nil {
+				logger.Warn("统计公众号发文规律失败", zap.String("account", account.Name), zap.Error(err))
+			}
+		}
+
+		// 登录态失效、频率限制或取消时该公众号未检查完，保持到期状态，下次调度优先重试
+		interrupted := errors.Is(err, context.Canceled) || crawler.IsSessionExpired(err) || crawler.IsFreqControl(err)
+		if !interrupted {
+			s.advanceSchedule(recordCtx, account)
+		}
+
 		if err != nil {
+			runStatus = model.CrawlRunPartial
+			if errors.Is(err, context.Canceled) {
+				continue
+			}
+			if crawler.IsSessionExpired(err) {
+				logger.Warn("公众号登录态已失效，跳过剩余公众号",
+					zap.String("account", account.Name),
+					zap.Int("skipped", len(accounts)-i-1))
+				s.skipCrawlAccounts(recordCtx, run, accounts[i+1:], err)
+				s.finishCrawlRun(recordCtx, run, runStatus, err)
+				return err
+			}
+			// 触发频率限制后整个爬虫处于冷却期，剩余公众号不再请求
+			if crawler.IsFreqControl(err) {
+				logger.Warn("触发微信频率限制，跳过剩余公众号",
+					zap.String("account", account.Name),
+					zap.Int("skipped", len(accounts)-i-1),
+					zap.Time("paused_until", s.pool.PausedUntil()))
+				s.skipCrawlAccounts(recordCtx, run, accounts[i+1:], err)
+				s.finishCrawlRun(recordCtx, run, runStatus, err)
+				return err
+			}
 			logger.Error("爬取公众号失败", zap.String("account", account.Name), zap.Error(err))
 			continue
 		}
@@ -217,6 +430,10 @@ func (s *CrawlerService) FetchAllAccounts(ctx context.Context) error {
 				zap.Int("count", len(articles)))
 		}
 	}
+	if ctx.Err() != nil {
+		runStatus = model.CrawlRunCancelled
+	}
+	s.finishCrawlRun(recordCtx, run, runStatus, ctx.Err())
 
 	// // 使用goroutine和channel控制并发爬取
 	// semaphore := make(chan struct{}, s.concurrent)
@@ -253,6 +470,75 @@ func (s *CrawlerService) FetchAllAccounts(ctx context.Context) error {
 	return nil
 }
 
+// RateLimitStatus 获取限流器状态（是否处于频率限制冷却期）
+func (s *CrawlerService) RateLimitStatus() crawler.RateLimitStatus {
+	return s.pool.RateLimitStatus()
+}
+
+// ResumeRateLimit 手动解除频率限制暂停
+func (s *CrawlerService) ResumeRateLimit() {
+	s.pool.Resume()
+}
+
+// SessionStatus 获取公众号后台登录态
+func (s *CrawlerService) SessionStatus() crawler.SessionStatus {
+	return s.pool.SessionStatus()
+}
+
+// SessionHealth 获取会话池中各会话的健康状况
+func (s *CrawlerService) SessionHealth() []crawler.SessionHealth {
+	return s.pool.Sessions()
+}
+
+// StartWeChatLogin 为指定会话发起管理后台扫码登录，名称为空时使用第一个会话
+func (s *CrawlerService) StartWeChatLogin(session string) error {
+	loginer, err := s.qrLoginer(session)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("发起公众号后台扫码登录", zap.String("session", session))
+	return loginer.StartQRLogin()
+}
+
+// WeChatLoginState 获取指定会话的扫码登录进度
+func (s *CrawlerService) WeChatLoginState(session string) (crawler.QRLoginState, error) {
+	loginer, err := s.qrLoginer(session)
+	if err != nil {
+		return crawler.QRLoginState{}, err
+	}
+	return loginer.QRLoginState(), nil
+}
+
+// qrLoginer 获取支持扫码登录的会话
+func (s *CrawlerService) qrLoginer(session string) (crawler.QRLoginer, error) {
+	ps, err := s.pool.Session(session)
+	if err != nil {
+		return nil, err
+	}
+	loginer, ok := ps.Source.(crawler.QRLoginer)
+	if !ok {
+		return nil, fmt.Errorf("当前数据源不支持扫码登录")
+	}
+	return loginer, nil
+}
+
+// waitRateLimitCooldown 等待频率限制冷却结束
+func (s *CrawlerService) waitRateLimitCooldown(ctx context.Context) error {
+	wait := time.Until(s.pool.PausedUntil())
+	if wait <= 0 {
+		return nil
+	}
+
+	logger.Info("等待频率限制冷却结束", zap.Duration("wait", wait))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
 // GetArticleList 获取文章列表
 func (s *CrawlerService) GetArticleList(ctx context.Context, accountID string, page, pageSize int64) ([]*model.Article, int64, error) {
 	if accountID != "" {
@@ -269,8 +555,21 @@ func (s *CrawlerService) GetArticleList(ctx context.Context, accountID string, p
 }
 
 // GetArticleListWithFilter 获取文章列表（支持筛选条件）
-func (s *CrawlerService) GetArticleListWithFilter(ctx context.Context, accountID string, keyword string, startTime, endTime int64, page, pageSize int64) ([]*model.Article, int64, error) {
-	return s.articleRepo.ListWithFilter(ctx, accountID, keyword, startTime, endTime, page, pageSize)
+func (s *CrawlerService) GetArticleListWithFilter(ctx context.Context, filter model.ArticleFilter, page, pageSize int64) ([]*model.Article, int64, error) {
+	return s.articleRepo.ListWithFilter(ctx, filter, page, pageSize)
+}
+
+// GetArticle 根据ID获取文章
+func (s *CrawlerService) GetArticle(ctx context.Context, id string) (*model.Article, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID")
+	}
+	article, err := s.articleRepo.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("文章不存在")
+	}
+	return article, err
 }
 
 // GetAccount 获取公众号详情
@@ -282,7 +581,7 @@ func (s *CrawlerService) GetAccount(ctx context.Context, id string) (*model.WeCh
 
 	account, err := s.wechatRepo.FindByID(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, fmt.Errorf("公众号不存在")
 		}
 		return nil, err
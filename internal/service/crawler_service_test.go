@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+)
+
+// 验证基于模拟数据源的完整采集流程：添加公众号 -> 获取文章列表和正文 -> 保存 -> 增量采集
+func TestCrawlerServiceWithFakeSource(t *testing.T) {
+	var pageDown atomic.Bool // 为true时文章页面返回502
+	svc, mp := newFakeCrawler(t, BackfillConfig{}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/s" && pageDown.Load() {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+	account, _ := addFakeAccount(t, svc, mp, "测试公众号", 3)
+
+	if _, err := svc.AddAccount(ctx, "测试公众号", ""); err == nil {
+		t.Errorf("重复添加公众号应返回错误")
+	}
+
+	// crawl 重新读取公众号（上次采集位置）后采集最新文章
+	crawl := func() []*model.Article {
+		t.Helper()
+		current, err := svc.GetAccount(ctx, account.ID.Hex())
+		if err != nil {
+			t.Fatalf("查询公众号失败: %v", err)
+		}
+		articles, err := svc.FetchLatestArticles(ctx, current)
+		if err != nil {
+			t.Fatalf("采集文章失败: %v", err)
+		}
+		return articles
+	}
+
+	articles := crawl()
+	if len(articles) != 3 {
+		t.Fatalf("首次采集: got %d 篇, want 3", len(articles))
+	}
+	for _, article := range articles {
+		saved, err := svc.GetArticle(ctx, article.ID.Hex())
+		if err != nil {
+			t.Fatalf("查询文章失败: %v", err)
+		}
+		if saved.FetchStatus != model.ArticleFetchSuccess || !strings.Contains(saved.Content, saved.Title+"正文") {
+			t.Errorf("%s: 正文未保存: status=%s content=%q", saved.Title, saved.FetchStatus, saved.Content)
+		}
+		if saved.AccountName != "测试公众号" || saved.PublishTime == 0 || saved.Text == "" {
+			t.Errorf("%s: 文章信息不完整: %+v", saved.Title, saved)
+		}
+	}
+
+	// 没有新文章时从上次采集位置停止
+	if articles := crawl(); len(articles) != 0 {
+		t.Errorf("无新文章时: got %d 篇, want 0", len(articles))
+	}
+
+	// 新发布的文章被采集，已删除的文章跳过
+	deleted := &crawler.FakeArticle{Title: "已删除", Content: "<p>已删除正文</p>", CreateTime: time.Now().Unix()}
+	fresh := &crawler.FakeArticle{Title: "新文章", Content: "<p>新文章正文</p>", CreateTime: time.Now().Unix() + 1}
+	for _, article := range []*crawler.FakeArticle{deleted, fresh} {
+		if err := mp.AddArticle(account.FakeID, article); err != nil {
+			t.Fatalf("添加模拟文章失败: %v", err)
+		}
+	}
+	if err := mp.SetArticleState(deleted.Mid, true, false); err != nil {
+		t.Fatal(err)
+	}
+	articles = crawl()
+	if len(articles) != 1 || articles[0].Title != "新文章" {
+		t.Fatalf("增量采集: got %d 篇, want 只有新文章", len(articles))
+	}
+
+	// 文章页面返回错误状态码时只保存元数据，等待后台重试
+	pageDown.Store(true)
+	if err := mp.AddArticle(account.FakeID, &crawler.FakeArticle{Title: "页面错误", Content: "<p>正文</p>", CreateTime: time.Now().Unix() + 2}); err != nil {
+		t.Fatalf("添加模拟文章失败: %v", err)
+	}
+	articles = crawl()
+	if len(articles) != 1 {
+		t.Fatalf("页面错误时: got %d 篇, want 1", len(articles))
+	}
+	saved, err := svc.GetArticle(ctx, articles[0].ID.Hex())
+	if err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	if saved.FetchStatus == model.ArticleFetchSuccess || saved.Content != "" || !strings.Contains(saved.FetchError, "502") {
+		t.Errorf("页面错误时: status=%s content=%q error=%q", saved.FetchStatus, saved.Content, saved.FetchError)
+	}
+
+	if got := countArticles(t, svc, account); got != 5 {
+		t.Errorf("保存的文章数: got %d, want 5", got)
+	}
+}
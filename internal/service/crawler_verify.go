@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// VerifyConfig 文章在线状态复查配置
+type VerifyConfig struct {
+	WindowDays   int           // 复查最近多少天发布的文章
+	RecheckAfter time.Duration // 同一篇文章两次复查的最小间隔
+	BatchSize    int           // 每轮最多复查的文章数
+}
+
+// ArticleStatusEvent 复查发现的文章状态变化
+type ArticleStatusEvent struct {
+	Article *model.Article            `json:"article"`
+	Change  model.ArticleStatusChange `json:"change"`
+}
+
+// articleStatusOf 根据正文获取结果判断文章的在线状态
+// 网络错误、页面结构变化等无法判断的情况返回 false，不改变文章状态
+func articleStatusOf(err error) (string, bool) {
+	switch {
+	case err == nil:
+		return model.ArticleStatusLive, true
+	case errors.Is(err, crawler.ErrArticleDeleted):
+		return model.ArticleStatusDeleted, true
+	case errors.Is(err, crawler.ErrArticleBlocked):
+		return model.ArticleStatusBlocked, true
+	default:
+		return "", false
+	}
+}
+
+// VerifyArticles 复查最近发布的文章是否仍可访问（供调度器定时调用），返回本轮发现的状态变化
+// 文章被删除或屏蔽时保留已采集的正文，只记录状态变化；正文被修改时保存为新版本；爬取任务执行中、登录态失效或处于频率限制冷却期时跳过本轮
+func (s *CrawlerService) VerifyArticles(ctx context.Context) ([]ArticleStatusEvent, error) {
+	if s.CrawlProgress().Running {
+		logger.Debug("爬取任务正在执行，跳过本轮文章复查")
+		return nil, nil
+	}
+	if s.pool.SessionStatus().Expired || s.pool.RateLimitStatus().Paused {
+		logger.Debug("登录态失效或处于频率限制冷却期，跳过本轮文章复查")
+		return nil, nil
+	}
+	if !s.verifying.CompareAndSwap(false, true) {
+		return nil, nil
+	}
+	defer s.verifying.Store(false)
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -s.verify.WindowDays).Unix()
+	articles, err := s.articleRepo.ListForVerify(ctx, since, now.Add(-s.verify.RecheckAfter), int64(s.verify.BatchSize))
+	if err != nil {
+		return nil, fmt.Errorf("查询待复查文章失败: %w", err)
+	}
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	logger.Info("开始复查文章状态", zap.Int("count", len(articles)))
+	var events []ArticleStatusEvent
+	for _, article := range articles {
+		if ctx.Err() != nil {
+			return events, ctx.Err()
+		}
+
+		content, err := s.pool.FetchArticleContent(ctx, article.ContentURL)
+		// 频率限制和登录态失效与文章本身无关，留到下一轮
+		if crawler.IsFreqControl(err) || crawler.IsSessionExpired(err) {
+			logger.Warn("文章复查中断", zap.Int("changed", len(events)), zap.Error(err))
+			return events, err
+		}
+
+		status, ok := articleStatusOf(err)
+		if !ok {
+			logger.Warn("复查文章失败，无法判断状态",
+				zap.String("title", article.Title),
+				zap.Error(err))
+			continue
+		}
+
+		checkedAt := time.Now()
+		if status == model.ArticleStatusLive {
+			if _, err := s.recordRevision(ctx, article, content, checkedAt); err != nil {
+				logger.Warn("保存文章正文版本失败", zap.String("title", article.Title), zap.Error(err))
+			}
+		}
+		if status == article.CurrentStatus() {
+			if err := s.articleRepo.UpdateChecked(ctx, article.ID, status, checkedAt); err != nil {
+				logger.Warn("记录文章复查时间失败", zap.String("title", article.Title), zap.Error(err))
+			}
+			continue
+		}
+
+		change := model.ArticleStatusChange{
+			From: article.CurrentStatus(),
+			To:   status,
+			At:   checkedAt,
+		}
+		if err != nil {
+			change.Reason = err.Error()
+		}
+		if err := s.articleRepo.UpdateStatus(ctx, article.ID, change); err != nil {
+			logger.Warn("记录文章状态变化失败", zap.String("title", article.Title), zap.Error(err))
+			continue
+		}
+
+		article.Status = status
+		article.StatusChangedAt = checkedAt
+		article.LastCheckedAt = checkedAt
+		article.StatusHistory = append(article.StatusHistory, change)
+		events = append(events, ArticleStatusEvent{Article: article, Change: change})
+
+		logger.Info("文章状态变化",
+			zap.String("account", article.AccountName),
+			zap.String("title", article.Title),
+			zap.String("from", change.From),
+			zap.String("to", change.To),
+			zap.String("reason", change.Reason))
+	}
+
+	logger.Info("文章复查完成",
+		zap.Int("count", len(articles)),
+		zap.Int("changed", len(events)))
+	return events, nil
+}
+
+// CountRemovedArticles 统计已删除或被屏蔽的文章数量
+func (s *CrawlerService) CountRemovedArticles(ctx context.Context) (int64, error) {
+	return s.articleRepo.CountRemoved(ctx)
+}
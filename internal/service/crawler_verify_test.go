@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+)
+
+// 验证只有明确的删除、屏蔽页面才会改变文章状态，网络错误等不作判断
+func TestArticleStatusOf(t *testing.T) {
+	cases := []struct {
+		err    error
+		want   string
+		wantOK bool
+	}{
+		{nil, model.ArticleStatusLive, true},
+		{fmt.Errorf("获取文章失败: %w: 该内容已被发布者删除", crawler.ErrArticleDeleted), model.ArticleStatusDeleted, true},
+		{fmt.Errorf("%w: 此内容因违规无法查看", crawler.ErrArticleBlocked), model.ArticleStatusBlocked, true},
+		{context.DeadlineExceeded, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := articleStatusOf(tc.err)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("%v: got (%q, %v), want (%q, %v)", tc.err, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -15,8 +15,8 @@ import (
 
 // FeishuService 飞书通知服务
 type FeishuService struct {
-	feishuRepo  *repository.FeishuConfigRepo
-	articleRepo *repository.ArticleRepo
+	feishuRepo  repository.FeishuConfigRepo
+	articleRepo repository.ArticleRepo
 }
 
 // NewFeishuService 创建飞书服务实例
@@ -86,7 +86,7 @@ func (s *FeishuService) SendArticleNotification(ctx context.Context) error {
 	}
 
 	// 查询文章
-	articles, _, err := s.articleRepo.ListWithFilter(ctx, "", "", startTime, 0, 1, 100)
+	articles, _, err := s.articleRepo.ListWithFilter(ctx, model.ArticleFilter{StartTime: startTime}, 1, 100)
 	if err != nil {
 		return fmt.Errorf("查询文章失败: %w", err)
 	}
@@ -116,3 +116,32 @@ func (s *FeishuService) SendArticleNotification(ctx context.Context) error {
 	return nil
 }
 
+
+// SendRemovedNotification 发送文章被删除或屏蔽的提醒，未启用通知或未开启删除提醒时跳过
+func (s *FeishuService) SendRemovedNotification(ctx context.Context, events []ArticleStatusEvent) error {
+	var removed []*model.Article
+	for _, event := range events {
+		if event.Article.IsGone() {
+			removed = append(removed, event.Article)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	config, err := s.feishuRepo.GetConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("获取飞书配置失败: %w", err)
+	}
+	if !config.Enabled || !config.NotifyRemoved || config.WebhookURL == "" {
+		return nil
+	}
+
+	notifier := feishu.NewFeishuNotifier(config.WebhookURL)
+	if err := notifier.SendRemovedArticleCard(removed); err != nil {
+		return fmt.Errorf("发送删除提醒失败: %w", err)
+	}
+
+	logger.Info("文章删除提醒发送成功", zap.Int("article_count", len(removed)))
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/database"
+	"wechat-crawler/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "service-test")
+	if err := logger.Init(filepath.Join(dir, "test.log"), "error"); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useTestSQL 使用临时的 SQLite 数据库作为全局数据库，测试结束后恢复
+// 之后创建的服务通过 repository.NewXxxRepo 使用该数据库
+func useTestSQL(t *testing.T) *database.SQLDB {
+	t.Helper()
+	db, err := database.OpenSQL(database.SQLConfig{
+		Driver:  database.DriverSQLite,
+		DSN:     filepath.Join(t.TempDir(), "test.db"),
+		Timeout: 5,
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if _, err := database.MigrateSQL(context.Background(), db, repository.SQLMigrations()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	prev := database.SQL
+	database.SQL = db
+	t.Cleanup(func() {
+		database.SQL = prev
+		db.Close()
+	})
+	return db
+}
+
+// fakeMPToken 测试用模拟公众号后台的token
+const fakeMPToken = "test-token"
+
+// newFakeCrawler 创建使用临时 SQLite 数据库和模拟公众号后台的爬虫服务
+// wrap 不为nil时包装模拟后台的请求处理，用于阻塞或观察请求
+func newFakeCrawler(t *testing.T, backfill BackfillConfig, wrap func(http.Handler) http.Handler) (*CrawlerService, *crawler.FakeMPServer) {
+	t.Helper()
+	useTestSQL(t)
+
+	mp := crawler.NewFakeMPServer(fakeMPToken)
+	var handler http.Handler = mp
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	limiter := crawler.NewRateLimiter(crawler.RateLimitConfig{})
+	source := crawler.NewFakeSource(server.URL, fakeMPToken, 5, limiter)
+	pool := crawler.NewSessionPool(crawler.PoolStrategyShard, crawler.NewPoolSession("test", source, limiter))
+	settings := NewSettingsService(model.RuntimeSettings{CrawlInterval: 60, FetchCount: 10, Timeout: 30})
+
+	retry := ContentRetryConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, BatchSize: 10}
+	svc := NewCrawlerService(pool, 1, settings, backfill, retry, AdaptiveConfig{}, VerifyConfig{})
+	return svc, mp
+}
+
+// addFakeAccount 在模拟后台创建公众号并发布 count 篇文章（每小时一篇，最新的一篇在1小时前），
+// 然后通过爬虫服务订阅该公众号
+func addFakeAccount(t *testing.T, svc *CrawlerService, mp *crawler.FakeMPServer, name string, count int) (*model.WeChatAccount, []*crawler.FakeArticle) {
+	t.Helper()
+	fake := mp.AddAccount(name, "")
+	now := time.Now().Truncate(time.Hour)
+	articles := make([]*crawler.FakeArticle, count)
+	for i := range articles {
+		articles[i] = &crawler.FakeArticle{
+			Title:      fmt.Sprintf("%s第%d篇", name, count-i),
+			Content:    fmt.Sprintf("<p>%s第%d篇正文</p>", name, count-i),
+			CreateTime: now.Add(-time.Duration(i+1) * time.Hour).Unix(),
+		}
+		if err := mp.AddArticle(fake.FakeID, articles[i]); err != nil {
+			t.Fatalf("添加模拟文章失败: %v", err)
+		}
+	}
+
+	account, err := svc.AddAccount(context.Background(), name, "")
+	if err != nil {
+		t.Fatalf("添加公众号失败: %v", err)
+	}
+	return account, articles
+}
+
+// countArticles 统计公众号已保存的文章数
+func countArticles(t *testing.T, svc *CrawlerService, account *model.WeChatAccount) int64 {
+	t.Helper()
+	_, total, err := svc.GetArticleList(context.Background(), account.ID.Hex(), 1, 1)
+	if err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	return total
+}
+
+// waitFor 轮询直到 cond 返回true，超时后测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
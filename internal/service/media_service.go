@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"wechat-crawler/internal/crawler"
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/blobstore"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// MediaRoute 归档文件的访问路径前缀
+const MediaRoute = "/media/"
+
+// mediaUserAgent 下载图片时使用的 User-Agent
+const mediaUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// imageExtensions 支持归档的图片类型及扩展名，按文件内容识别
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// errMediaHost 图片地址不在允许下载的域名内
+var errMediaHost = errors.New("只允许下载微信图片服务器（*.qpic.cn、mmbiz.qlogo.cn）上的图片")
+
+// allowedMediaHost 是否允许下载该域名的图片
+// 公众号正文图片和封面都在微信图片服务器上，只允许这些域名，避免文章中的地址让服务请求内网
+func allowedMediaHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return strings.HasSuffix(host, ".qpic.cn") || host == "mmbiz.qlogo.cn"
+}
+
+// checkMediaURL 检查图片地址的协议和域名
+func checkMediaURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("不支持的图片地址: %s", u.Redacted())
+	}
+	if !allowedMediaHost(u.Hostname()) {
+		return fmt.Errorf("%w: %s", errMediaHost, u.Hostname())
+	}
+	return nil
+}
+
+// denyPrivateIP 拒绝连接内网、回环、链路本地等非公网地址
+// 在建立连接时检查解析后的地址，域名解析到内网时同样拒绝
+func denyPrivateIP(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("拒绝连接非公网地址: %s", ip)
+	}
+	return nil
+}
+
+// mediaTransport 每次请求（包括重定向后的请求）前检查图片地址
+type mediaTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *mediaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := checkMediaURL(req.URL); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// newMediaClient 创建下载图片的HTTP客户端，只允许访问微信图片服务器的公网地址
+func newMediaClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: denyPrivateIP}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 不经过代理，保证检查的是实际连接的地址
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: &mediaTransport{next: transport}}
+}
+
+// MediaConfig 图片归档和页面快照配置
+type MediaConfig struct {
+	Images    bool          // 是否归档正文图片和封面
+	Timeout   time.Duration // 单张图片的下载超时时间
+	MaxSize   int64         // 单张图片的大小上限（字节）
+	Snapshots []string      // 采集时保存的页面快照格式，为空表示不保存
+}
+
+// MediaService 文章图片归档和页面快照服务，文件保存到文件存储，按内容摘要去重
+type MediaService struct {
+	store     blobstore.Store
+	repo      repository.MediaRepo
+	client    *http.Client
+	images    bool
+	timeout   time.Duration
+	maxSize   int64
+	snapshots []string
+}
+
+// NewMediaService 创建图片归档服务
+func NewMediaService(store blobstore.Store, cfg MediaConfig) *MediaService {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 10 << 20
+	}
+	return &MediaService{
+		store:     store,
+		repo:      repository.NewMediaRepo(),
+		client:    newMediaClient(),
+		images:    cfg.Images,
+		timeout:   cfg.Timeout,
+		maxSize:   cfg.MaxSize,
+		snapshots: cfg.Snapshots,
+	}
+}
+
+// ImagesEnabled 是否归档正文图片和封面
+func (m *MediaService) ImagesEnabled() bool {
+	return m.images
+}
+
+// SnapshotFormats 采集时保存的页面快照格式
+func (m *MediaService) SnapshotFormats() []string {
+	return m.snapshots
+}
+
+// mediaKey 按内容摘要生成存储路径，摘要前两位作为子目录，避免单个目录下文件过多
+func mediaKey(hash, ext string) string {
+	return "images/" + hash[:2] + "/" + hash + ext
+}
+
+// snapshotKey 页面快照的存储路径，与图片一样按内容摘要命名
+func snapshotKey(hash, ext string) string {
+	return "snapshots/" + hash[:2] + "/" + hash + ext
+}
+
+// mediaURL 存储路径对应的访问地址
+func mediaURL(key string) string {
+	return MediaRoute + key
+}
+
+// ArchiveImage 下载图片并归档，返回本地访问地址
+// 同一地址只下载一次，不同地址的相同图片只保存一份
+func (m *MediaService) ArchiveImage(ctx context.Context, url string) (string, error) {
+	file, err := m.repo.FindByURL(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("查询归档记录失败: %w", err)
+	}
+	if file != nil {
+		return mediaURL(file.Key), nil
+	}
+
+	data, contentType, err := m.download(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	file, err = m.repo.FindByHash(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("查询归档记录失败: %w", err)
+	}
+	if file == nil {
+		file = &model.MediaFile{
+			Hash:        hash,
+			Key:         mediaKey(hash, imageExtensions[contentType]),
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			CreatedAt:   time.Now(),
+		}
+		if err := m.store.Put(ctx, file.Key, bytes.NewReader(data), contentType); err != nil {
+			return "", fmt.Errorf("保存图片失败: %w", err)
+		}
+	}
+	if err := m.repo.Save(ctx, file, url); err != nil {
+		return "", fmt.Errorf("保存归档记录失败: %w", err)
+	}
+	return mediaURL(file.Key), nil
+}
+
+// ArchiveImages 归档多张图片，返回原地址到本地地址的映射和归档失败的数量
+// 单张图片失败不影响其他图片，失败的图片保留原地址
+func (m *MediaService) ArchiveImages(ctx context.Context, urls []string) (map[string]string, int) {
+	result := make(map[string]string, len(urls))
+	failed := 0
+	for _, url := range urls {
+		if url == "" || result[url] != "" {
+			continue
+		}
+		local, err := m.ArchiveImage(ctx, url)
+		if err != nil {
+			failed++
+			logger.Warn("归档图片失败", zap.String("url", url), zap.Error(err))
+			continue
+		}
+		result[url] = local
+	}
+	return result, failed
+}
+
+// download 下载图片，按文件内容识别类型，只接受常见的图片格式
+func (m *MediaService) download(ctx context.Context, url string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", mediaUserAgent)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("下载图片失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("下载图片失败: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, m.maxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("读取图片失败: %w", err)
+	}
+	if int64(len(data)) > m.maxSize {
+		return nil, "", fmt.Errorf("图片超过 %d 字节", m.maxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, "", fmt.Errorf("不支持的图片类型: %s", contentType)
+	}
+	return data, contentType, nil
+}
+
+// SaveSnapshots 保存页面快照，返回保存成功的快照信息
+// 内容相同的快照只保存一份，单个快照失败不影响其他快照
+func (m *MediaService) SaveSnapshots(ctx context.Context, snapshots []*crawler.Snapshot) []model.ArticleSnapshot {
+	var saved []model.ArticleSnapshot
+	for _, snapshot := range snapshots {
+		sum := sha256.Sum256(snapshot.Data)
+		key := snapshotKey(hex.EncodeToString(sum[:]), snapshot.Ext())
+
+		exists, err := m.store.Exists(ctx, key)
+		if err == nil && !exists {
+			err = m.store.Put(ctx, key, bytes.NewReader(snapshot.Data), snapshot.ContentType())
+		}
+		if err != nil {
+			logger.Warn("保存页面快照失败", zap.String("format", snapshot.Format), zap.Error(err))
+			continue
+		}
+
+		saved = append(saved, model.ArticleSnapshot{
+			Format:      snapshot.Format,
+			Key:         key,
+			URL:         mediaURL(key),
+			ContentType: snapshot.ContentType(),
+			Size:        int64(len(snapshot.Data)),
+			CapturedAt:  time.Now(),
+		})
+	}
+	return saved
+}
+
+// Open 打开归档文件，key 为访问地址中 /media/ 之后的部分
+func (m *MediaService) Open(ctx context.Context, key string) (io.ReadCloser, *blobstore.Info, error) {
+	return m.store.Open(ctx, key)
+}
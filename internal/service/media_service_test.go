@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// 验证图片按内容识别类型，非图片和超过大小上限的文件不归档
+func TestMediaDownload(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image":
+			// 公众号图片地址常见 wx_fmt 参数与实际格式不符，类型以内容为准
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(png)
+		case "/page":
+			w.Write([]byte("<html><body>not found</body></html>"))
+		case "/large":
+			w.Write(append(png, bytes.Repeat([]byte{0}, 100)...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m := &MediaService{client: srv.Client(), timeout: time.Second, maxSize: 64}
+
+	data, contentType, err := m.download(context.Background(), srv.URL+"/image")
+	if err != nil {
+		t.Fatalf("download image: %v", err)
+	}
+	if contentType != "image/png" || !bytes.Equal(data, png) {
+		t.Errorf("download image: got %q (%d bytes)", contentType, len(data))
+	}
+
+	for _, path := range []string{"/page", "/large", "/missing"} {
+		if _, _, err := m.download(context.Background(), srv.URL+path); err == nil {
+			t.Errorf("download %s: expected error", path)
+		}
+	}
+
+	if got := mediaKey("abcdef", ".png"); got != "images/ab/abcdef.png" {
+		t.Errorf("mediaKey: got %q", got)
+	}
+}
+
+// 验证只下载微信图片服务器上的图片，拒绝连接内网地址
+func TestMediaURLRestrictions(t *testing.T) {
+	for raw, want := range map[string]bool{
+		"https://mmbiz.qpic.cn/mmbiz_jpg/abc/640?wx_fmt=jpeg": true,
+		"http://mmbiz.qpic.cn/mmbiz_png/abc/0":                true,
+		"https://mmecoa.qpic.cn/sz_mmecoa_png/abc/0":          true,
+		"https://MMBIZ.QPIC.CN./abc":                          true,
+		"https://mmbiz.qlogo.cn/mmbiz_png/abc/0":              true,
+		"https://qpic.cn/abc":                                 false,
+		"https://mmbiz.qpic.cn.example.com/abc":               false,
+		"https://wx.qlogo.cn/mmhead/abc/0":                    false,
+		"http://127.0.0.1:8080/admin":                         false,
+		"http://169.254.169.254/latest/meta-data/":            false,
+		"file:///etc/passwd":                                  false,
+		"ftp://mmbiz.qpic.cn/abc":                             false,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("parse %s: %v", raw, err)
+		}
+		if got := checkMediaURL(u) == nil; got != want {
+			t.Errorf("checkMediaURL(%s): got %v, want %v", raw, got, want)
+		}
+	}
+
+	for address, want := range map[string]bool{
+		"183.3.226.35:443":      true,
+		"[240e:ff:e020::1]:443": true,
+		"127.0.0.1:80":          false,
+		"10.0.0.8:80":           false,
+		"172.16.3.4:80":         false,
+		"192.168.1.1:443":       false,
+		"169.254.169.254:80":    false,
+		"0.0.0.0:80":            false,
+		"[::1]:80":              false,
+		"[fd00::1]:80":          false,
+		"[::ffff:127.0.0.1]:80": false,
+	} {
+		if got := denyPrivateIP("tcp", address, nil) == nil; got != want {
+			t.Errorf("denyPrivateIP(%s): got %v, want %v", address, got, want)
+		}
+	}
+
+	// 服务实际使用的客户端拒绝非微信图片域名，包括重定向后的地址
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://127.0.0.1/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	m := &MediaService{client: newMediaClient(), timeout: time.Second, maxSize: 64}
+	if _, _, err := m.download(context.Background(), srv.URL+"/image"); !errors.Is(err, errMediaHost) {
+		t.Errorf("download %s: got %v, want errMediaHost", srv.URL, err)
+	}
+
+	// 把微信图片域名指向测试服务器，重定向到内网地址时拒绝
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	m.client = &http.Client{Transport: &mediaTransport{next: transport}}
+	if _, _, err := m.download(context.Background(), "http://mmbiz.qpic.cn/image"); !errors.Is(err, errMediaHost) {
+		t.Errorf("download redirect: got %v, want errMediaHost", err)
+	}
+}
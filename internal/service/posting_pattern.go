@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// AdaptiveConfig 自适应调度配置
+type AdaptiveConfig struct {
+	Enabled     bool // 是否根据发文规律调度未设置爬取计划的公众号
+	MinSamples  int  // 至少需要的文章数，不足时使用默认间隔
+	HistoryDays int  // 统计最近多少天的文章，0表示不限制
+}
+
+const (
+	// patternHotShare 集中发文时段需覆盖的文章比例
+	patternHotShare = 0.8
+	// patternMaxHotHours 集中发文时段最多包含的小时数，超过说明没有明显规律
+	patternMaxHotHours = 8
+	// patternMaxSamples 最多统计的文章数
+	patternMaxSamples = 500
+	// adaptiveCheckDelay 进入发文时段后等待多久再检查，给公众号留出发文时间
+	adaptiveCheckDelay = 30 * time.Minute
+	// adaptiveMinHotInterval 发文时段内的最小检查间隔
+	adaptiveMinHotInterval = 30 * time.Minute
+	// adaptiveMaxColdInterval 非发文时段的最大检查间隔
+	adaptiveMaxColdInterval = 24 * time.Hour
+)
+
+// buildPostingPattern 根据文章发布时间统计发文规律，样本数少于 minSamples 时只统计分布不给出时段
+func buildPostingPattern(publishTimes []int64, minSamples int, loc *time.Location) *model.PostingPattern {
+	pattern := &model.PostingPattern{UpdatedAt: time.Now()}
+	for _, ts := range publishTimes {
+		if ts <= 0 {
+			continue
+		}
+		t := time.Unix(ts, 0).In(loc)
+		pattern.Hours[t.Hour()]++
+		pattern.Weekdays[t.Weekday()]++
+		pattern.Samples++
+	}
+	if pattern.Samples == 0 || pattern.Samples < minSamples {
+		return pattern
+	}
+
+	// 按发文数从多到少选取小时，直到覆盖大部分文章
+	hours := make([]int, 24)
+	for h := range hours {
+		hours[h] = h
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return pattern.Hours[hours[i]] > pattern.Hours[hours[j]]
+	})
+	covered := 0
+	for _, h := range hours {
+		if pattern.Hours[h] == 0 || float64(covered) >= patternHotShare*float64(pattern.Samples) {
+			break
+		}
+		pattern.HotHours = append(pattern.HotHours, h)
+		covered += pattern.Hours[h]
+	}
+	if len(pattern.HotHours) > patternMaxHotHours {
+		// 发文时间分散，没有可利用的规律
+		pattern.HotHours = nil
+		return pattern
+	}
+	sort.Ints(pattern.HotHours)
+
+	// 发文数不低于平均水平一半的星期视为经常发文
+	for d, n := range pattern.Weekdays {
+		if n > 0 && n*14 >= pattern.Samples {
+			pattern.ActiveWeekdays = append(pattern.ActiveWeekdays, d)
+		}
+	}
+	return pattern
+}
+
+// nextAdaptiveCrawlTime 根据发文规律计算下次爬取时间
+// 处于发文时段时加密检查，否则在下一个发文时段开始后检查，非发文时段放宽检查间隔
+func nextAdaptiveCrawlTime(pattern *model.PostingPattern, from time.Time, defaultInterval time.Duration) time.Time {
+	if pattern.IsHot(from) {
+		hotInterval := defaultInterval / 4
+		if hotInterval < adaptiveMinHotInterval {
+			hotInterval = adaptiveMinHotInterval
+		}
+		return from.Add(hotInterval)
+	}
+
+	coldInterval := defaultInterval * 2
+	if coldInterval > adaptiveMaxColdInterval {
+		coldInterval = adaptiveMaxColdInterval
+	}
+	if coldInterval < defaultInterval {
+		coldInterval = defaultInterval
+	}
+	limit := from.Add(coldInterval)
+
+	hour := time.Date(from.Year(), from.Month(), from.Day(), from.Hour(), 0, 0, 0, from.Location())
+	for t := hour.Add(time.Hour); t.Before(limit); t = t.Add(time.Hour) {
+		if pattern.IsHot(t) {
+			return t.Add(adaptiveCheckDelay)
+		}
+	}
+	return limit
+}
+
+// patternFor 返回用于调度的发文规律，未启用自适应调度或样本不足时返回nil
+func (s *CrawlerService) patternFor(account *model.WeChatAccount) *model.PostingPattern {
+	if !s.adaptive.Enabled || !account.Pattern.Ready() {
+		return nil
+	}
+	return account.Pattern
+}
+
+// AdaptiveEnabled 是否启用了自适应调度
+func (s *CrawlerService) AdaptiveEnabled() bool {
+	return s.adaptive.Enabled
+}
+
+// RefreshPostingPattern 根据已采集的文章重新统计公众号的发文规律
+func (s *CrawlerService) RefreshPostingPattern(ctx context.Context, account *model.WeChatAccount) error {
+	var since int64
+	if s.adaptive.HistoryDays > 0 {
+		since = time.Now().AddDate(0, 0, -s.adaptive.HistoryDays).Unix()
+	}
+
+	times, err := s.articleRepo.ListPublishTimes(ctx, account.ID, since, patternMaxSamples)
+	if err != nil {
+		return err
+	}
+
+	pattern := buildPostingPattern(times, s.adaptive.MinSamples, time.Local)
+	if err := s.wechatRepo.UpdatePattern(ctx, account.ID, pattern); err != nil {
+		return err
+	}
+	account.Pattern = pattern
+
+	logger.Debug("更新公众号发文规律",
+		zap.String("account", account.Name),
+		zap.Int("samples", pattern.Samples),
+		zap.String("summary", pattern.Summary()))
+	return nil
+}
+
+// RefreshPostingPatterns 重新统计所有公众号的发文规律（服务启动时调用）
+func (s *CrawlerService) RefreshPostingPatterns(ctx context.Context) {
+	if !s.adaptive.Enabled {
+		return
+	}
+
+	accounts, err := s.wechatRepo.List(ctx)
+	if err != nil {
+		logger.Warn("统计发文规律失败", zap.Error(err))
+		return
+	}
+	for _, account := range accounts {
+		if err := s.RefreshPostingPattern(ctx, account); err != nil {
+			logger.Warn("统计公众号发文规律失败", zap.String("account", account.Name), zap.Error(err))
+		}
+	}
+	logger.Info("公众号发文规律统计完成", zap.Int("accounts", len(accounts)))
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"wechat-crawler/internal/model"
+)
+
+// workdayPattern 构造工作日 8 点和 21 点发文的历史数据
+func workdayPattern(t *testing.T) *model.PostingPattern {
+	t.Helper()
+
+	var times []int64
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.Local) // 周一
+	for i := 0; i < 28; i++ {
+		d := day.AddDate(0, 0, i)
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		times = append(times, d.Add(8*time.Hour+10*time.Minute).Unix())
+		times = append(times, d.Add(21*time.Hour+5*time.Minute).Unix())
+	}
+	// 偶尔的周末推送不应改变规律
+	times = append(times, day.AddDate(0, 0, 5).Add(15*time.Hour).Unix())
+
+	return buildPostingPattern(times, 10, time.Local)
+}
+
+func TestBuildPostingPattern(t *testing.T) {
+	pattern := workdayPattern(t)
+
+	if pattern.Samples != 41 {
+		t.Fatalf("样本数不正确: %d", pattern.Samples)
+	}
+	if want := []int{8, 21}; !equalInts(pattern.HotHours, want) {
+		t.Fatalf("发文时段不正确: got %v, want %v", pattern.HotHours, want)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !equalInts(pattern.ActiveWeekdays, want) {
+		t.Fatalf("发文星期不正确: got %v, want %v", pattern.ActiveWeekdays, want)
+	}
+	if got := pattern.Summary(); got != "周一至周五 08时、21时" {
+		t.Fatalf("规律描述不正确: %s", got)
+	}
+
+	// 样本不足时只统计分布
+	few := buildPostingPattern([]int64{time.Now().Unix()}, 10, time.Local)
+	if few.Ready() {
+		t.Fatal("样本不足时不应用于调度")
+	}
+}
+
+// 验证发文时段内加密检查，时段外等到下一个发文时段
+func TestNextAdaptiveCrawlTime(t *testing.T) {
+	pattern := workdayPattern(t)
+	defaultInterval := 480 * time.Minute
+
+	cases := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{"发文时段内", time.Date(2025, 10, 28, 8, 40, 0, 0, time.Local), time.Date(2025, 10, 28, 10, 40, 0, 0, time.Local)},
+		{"等待晚间时段", time.Date(2025, 10, 28, 10, 40, 0, 0, time.Local), time.Date(2025, 10, 28, 21, 30, 0, 0, time.Local)},
+		{"周五晚到周一早超出上限", time.Date(2025, 10, 31, 22, 0, 0, 0, time.Local), time.Date(2025, 11, 1, 14, 0, 0, 0, time.Local)},
+	}
+	for _, tc := range cases {
+		if got := nextAdaptiveCrawlTime(pattern, tc.from, defaultInterval); !got.Equal(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// 设置了爬取间隔的公众号不受发文规律影响
+	from := time.Date(2025, 10, 28, 10, 40, 0, 0, time.Local)
+	if got := nextCrawlTime(model.CrawlSchedule{Interval: 60}, pattern, from, defaultInterval); !got.Equal(from.Add(time.Hour)) {
+		t.Errorf("爬取间隔应优先于发文规律: %v", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"wechat-crawler/internal/model"
+	"wechat-crawler/internal/repository"
+	"wechat-crawler/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 运行时设置的取值范围
+const (
+	minCrawlInterval = 5    // 默认爬取间隔下限（分钟）
+	maxCrawlInterval = 1440 // 默认爬取间隔上限（分钟）
+	minFetchCount    = 5
+	maxFetchCount    = 50
+	minTimeout       = 30  // 请求超时下限（秒）
+	maxTimeout       = 300 // 请求超时上限（秒）
+)
+
+// SettingsListener 设置变更回调，old 和 cur 都是生效中的完整设置
+type SettingsListener func(ctx context.Context, old, cur model.RuntimeSettings)
+
+// SettingsService 运行时设置服务
+// 配置文件提供默认值，管理后台修改的项保存在数据库中覆盖默认值，修改后通知各模块立即生效
+type SettingsService struct {
+	repo      repository.SettingsRepo
+	defaults  model.RuntimeSettings // 配置文件中的默认值
+	mu        sync.RWMutex
+	overrides model.RuntimeSettings // 数据库中保存的覆盖项
+	current   model.RuntimeSettings // 当前生效的设置
+	updatedBy string
+	updatedAt time.Time
+	listeners []SettingsListener
+	updateMu  sync.Mutex // 保证修改和通知按顺序进行
+}
+
+// NewSettingsService 创建运行时设置服务，defaults 为配置文件中的默认值
+func NewSettingsService(defaults model.RuntimeSettings) *SettingsService {
+	return &SettingsService{
+		repo:     repository.NewSettingsRepo(),
+		defaults: defaults,
+		current:  defaults,
+	}
+}
+
+// ValidateSettings 校验运行时设置
+func ValidateSettings(settings model.RuntimeSettings) error {
+	if settings.CrawlInterval < minCrawlInterval || settings.CrawlInterval > maxCrawlInterval {
+		return fmt.Errorf("爬取间隔应在 %d 到 %d 分钟之间", minCrawlInterval, maxCrawlInterval)
+	}
+	if settings.FetchCount < minFetchCount || settings.FetchCount > maxFetchCount {
+		return fmt.Errorf("获取文章数量应在 %d 到 %d 之间", minFetchCount, maxFetchCount)
+	}
+	if settings.Timeout < minTimeout || settings.Timeout > maxTimeout {
+		return fmt.Errorf("超时时间应在 %d 到 %d 秒之间", minTimeout, maxTimeout)
+	}
+	return nil
+}
+
+// mergeSettings 用覆盖项中非0的值替换默认值
+func mergeSettings(defaults, overrides model.RuntimeSettings) model.RuntimeSettings {
+	merged := defaults
+	if overrides.CrawlInterval > 0 {
+		merged.CrawlInterval = overrides.CrawlInterval
+	}
+	if overrides.FetchCount > 0 {
+		merged.FetchCount = overrides.FetchCount
+	}
+	if overrides.Timeout > 0 {
+		merged.Timeout = overrides.Timeout
+	}
+	return merged
+}
+
+// overridesOf 计算需要保存的覆盖项，与默认值相同的项不保存，以便配置文件修改后继续生效
+func overridesOf(defaults, settings model.RuntimeSettings) model.RuntimeSettings {
+	var overrides model.RuntimeSettings
+	if settings.CrawlInterval != defaults.CrawlInterval {
+		overrides.CrawlInterval = settings.CrawlInterval
+	}
+	if settings.FetchCount != defaults.FetchCount {
+		overrides.FetchCount = settings.FetchCount
+	}
+	if settings.Timeout != defaults.Timeout {
+		overrides.Timeout = settings.Timeout
+	}
+	return overrides
+}
+
+// diffSettings 返回发生变化的设置项名称
+func diffSettings(old, cur model.RuntimeSettings) []string {
+	var changed []string
+	if old.CrawlInterval != cur.CrawlInterval {
+		changed = append(changed, "crawl_interval")
+	}
+	if old.FetchCount != cur.FetchCount {
+		changed = append(changed, "fetch_count")
+	}
+	if old.Timeout != cur.Timeout {
+		changed = append(changed, "timeout")
+	}
+	return changed
+}
+
+// Load 从数据库加载保存的设置（服务启动时调用），保存的设置不合法时使用默认值
+func (s *SettingsService) Load(ctx context.Context) error {
+	record, err := s.repo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("读取运行时设置失败: %w", err)
+	}
+	if record == nil {
+		logger.Info("未保存过运行时设置，使用配置文件中的默认值")
+		return nil
+	}
+
+	current := mergeSettings(s.defaults, record.Overrides)
+	if err := ValidateSettings(current); err != nil {
+		logger.Warn("保存的运行时设置不合法，使用配置文件中的默认值", zap.Error(err))
+		return nil
+	}
+
+	s.mu.Lock()
+	s.overrides = record.Overrides
+	s.current = current
+	s.updatedBy = record.UpdatedBy
+	s.updatedAt = record.UpdatedAt
+	s.mu.Unlock()
+
+	logger.Info("加载运行时设置",
+		zap.Int("crawl_interval", current.CrawlInterval),
+		zap.Int("fetch_count", current.FetchCount),
+		zap.Int("timeout", current.Timeout))
+	return nil
+}
+
+// Reload 重新读取数据库中的设置，其他实例修改过设置时更新并通知各模块
+// 多实例部署时管理后台的请求可能落在备用实例上，主实例在获得租约时和定期调用，使修改对定时任务生效
+func (s *SettingsService) Reload(ctx context.Context) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	record, err := s.repo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("读取运行时设置失败: %w", err)
+	}
+	if record == nil {
+		record = &model.SettingsRecord{}
+	}
+
+	s.mu.RLock()
+	unchanged := record.UpdatedAt.Equal(s.updatedAt) && record.UpdatedBy == s.updatedBy
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cur := mergeSettings(s.defaults, record.Overrides)
+	if err := ValidateSettings(cur); err != nil {
+		return fmt.Errorf("保存的运行时设置不合法: %w", err)
+	}
+
+	s.mu.Lock()
+	old := s.current
+	s.overrides = record.Overrides
+	s.current = cur
+	s.updatedBy = record.UpdatedBy
+	s.updatedAt = record.UpdatedAt
+	listeners := append([]SettingsListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	changed := diffSettings(old, cur)
+	if len(changed) == 0 {
+		return nil
+	}
+	logger.Info("运行时设置已在其他实例修改，重新加载",
+		zap.String("updated_by", record.UpdatedBy),
+		zap.Strings("changed", changed),
+		zap.Int("crawl_interval", cur.CrawlInterval),
+		zap.Int("fetch_count", cur.FetchCount),
+		zap.Int("timeout", cur.Timeout))
+
+	for _, listener := range listeners {
+		listener(ctx, old, cur)
+	}
+	return nil
+}
+
+// OnChange 注册设置变更回调，回调在保存成功后按注册顺序同步执行
+func (s *SettingsService) OnChange(listener SettingsListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Current 当前生效的设置
+func (s *SettingsService) Current() model.RuntimeSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Defaults 配置文件中的默认值
+func (s *SettingsService) Defaults() model.RuntimeSettings {
+	return s.defaults
+}
+
+// Overrides 覆盖默认值的设置项，以及最后修改人和修改时间
+func (s *SettingsService) Overrides() (overrides model.RuntimeSettings, updatedBy string, updatedAt time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.overrides, s.updatedBy, s.updatedAt
+}
+
+// Update 修改设置并立即生效，settings 为完整的设置
+func (s *SettingsService) Update(ctx context.Context, operator string, settings model.RuntimeSettings) (model.RuntimeSettings, error) {
+	if err := ValidateSettings(settings); err != nil {
+		return s.Current(), err
+	}
+	return s.apply(ctx, operator, model.SettingsActionUpdate, overridesOf(s.defaults, settings))
+}
+
+// Reset 清除所有覆盖项，恢复为配置文件中的默认值
+func (s *SettingsService) Reset(ctx context.Context, operator string) (model.RuntimeSettings, error) {
+	return s.apply(ctx, operator, model.SettingsActionReset, model.RuntimeSettings{})
+}
+
+// apply 保存覆盖项、记录修改历史并通知各模块
+func (s *SettingsService) apply(ctx context.Context, operator, action string, overrides model.RuntimeSettings) (model.RuntimeSettings, error) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	old := s.Current()
+	cur := mergeSettings(s.defaults, overrides)
+	now := time.Now()
+
+	record := &model.SettingsRecord{
+		Overrides: overrides,
+		UpdatedBy: operator,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Save(ctx, record); err != nil {
+		return old, fmt.Errorf("保存设置失败: %w", err)
+	}
+
+	s.mu.Lock()
+	s.overrides = overrides
+	s.current = cur
+	s.updatedBy = operator
+	s.updatedAt = now
+	listeners := append([]SettingsListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	changed := diffSettings(old, cur)
+	logger.Info("更新运行时设置",
+		zap.String("operator", operator),
+		zap.String("action", action),
+		zap.Strings("changed", changed),
+		zap.Int("crawl_interval", cur.CrawlInterval),
+		zap.Int("fetch_count", cur.FetchCount),
+		zap.Int("timeout", cur.Timeout))
+
+	if len(changed) == 0 {
+		return cur, nil
+	}
+
+	change := &model.SettingsChange{
+		Operator:  operator,
+		Action:    action,
+		Before:    old,
+		After:     cur,
+		Changed:   changed,
+		CreatedAt: now,
+	}
+	if err := s.repo.AddHistory(ctx, change); err != nil {
+		logger.Warn("记录设置修改历史失败", zap.Error(err))
+	}
+
+	for _, listener := range listeners {
+		listener(ctx, old, cur)
+	}
+	return cur, nil
+}
+
+// History 分页获取设置修改记录
+func (s *SettingsService) History(ctx context.Context, page, pageSize int64) ([]*model.SettingsChange, int64, error) {
+	return s.repo.ListHistory(ctx, page, pageSize)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"wechat-crawler/internal/model"
+)
+
+func TestValidateSettings(t *testing.T) {
+	valid := model.RuntimeSettings{CrawlInterval: 60, FetchCount: 10, Timeout: 60}
+	if err := ValidateSettings(valid); err != nil {
+		t.Fatalf("合法设置不应报错: %v", err)
+	}
+
+	invalid := []model.RuntimeSettings{
+		{CrawlInterval: 1, FetchCount: 10, Timeout: 60},
+		{CrawlInterval: 60, FetchCount: 100, Timeout: 60},
+		{CrawlInterval: 60, FetchCount: 10, Timeout: 0},
+	}
+	for _, settings := range invalid {
+		if err := ValidateSettings(settings); err == nil {
+			t.Errorf("非法设置应报错: %+v", settings)
+		}
+	}
+}
+
+// 验证只保存与默认值不同的项，默认值修改后未覆盖的项跟随配置文件
+func TestSettingsOverrides(t *testing.T) {
+	defaults := model.RuntimeSettings{CrawlInterval: 10, FetchCount: 10, Timeout: 60}
+	settings := model.RuntimeSettings{CrawlInterval: 30, FetchCount: 10, Timeout: 120}
+
+	overrides := overridesOf(defaults, settings)
+	if want := (model.RuntimeSettings{CrawlInterval: 30, Timeout: 120}); overrides != want {
+		t.Fatalf("覆盖项不正确: got %+v, want %+v", overrides, want)
+	}
+
+	newDefaults := model.RuntimeSettings{CrawlInterval: 20, FetchCount: 15, Timeout: 90}
+	if got, want := mergeSettings(newDefaults, overrides), (model.RuntimeSettings{CrawlInterval: 30, FetchCount: 15, Timeout: 120}); got != want {
+		t.Fatalf("合并结果不正确: got %+v, want %+v", got, want)
+	}
+
+	changed := diffSettings(defaults, settings)
+	if len(changed) != 2 || changed[0] != "crawl_interval" || changed[1] != "timeout" {
+		t.Fatalf("变化项不正确: %v", changed)
+	}
+}
+
+// 验证在其他实例上保存的设置可以通过 Reload 生效并通知各模块
+func TestSettingsReload(t *testing.T) {
+	useTestSQL(t)
+	ctx := context.Background()
+	defaults := model.RuntimeSettings{CrawlInterval: 60, FetchCount: 10, Timeout: 60}
+
+	leader := NewSettingsService(defaults)
+	standby := NewSettingsService(defaults)
+	for _, s := range []*SettingsService{leader, standby} {
+		if err := s.Load(ctx); err != nil {
+			t.Fatalf("加载设置失败: %v", err)
+		}
+	}
+
+	var notified []model.RuntimeSettings
+	leader.OnChange(func(ctx context.Context, old, cur model.RuntimeSettings) {
+		notified = append(notified, cur)
+	})
+
+	// 没有修改时不通知
+	if err := leader.Reload(ctx); err != nil || len(notified) != 0 {
+		t.Fatalf("未修改时不应通知: %v, %v", notified, err)
+	}
+
+	updated := model.RuntimeSettings{CrawlInterval: 30, FetchCount: 10, Timeout: 60}
+	if _, err := standby.Update(ctx, "alice", updated); err != nil {
+		t.Fatalf("保存设置失败: %v", err)
+	}
+	if got := leader.Current(); got != defaults {
+		t.Fatalf("Reload 前不应变化: %+v", got)
+	}
+
+	if err := leader.Reload(ctx); err != nil {
+		t.Fatalf("重新加载设置失败: %v", err)
+	}
+	if got := leader.Current(); got != updated {
+		t.Errorf("Reload 后设置不正确: got %+v, want %+v", got, updated)
+	}
+	if _, updatedBy, _ := leader.Overrides(); updatedBy != "alice" {
+		t.Errorf("修改人不正确: %s", updatedBy)
+	}
+	if len(notified) != 1 || notified[0] != updated {
+		t.Errorf("应通知一次: %v", notified)
+	}
+
+	// 再次检查时设置未变化，不重复通知
+	if err := leader.Reload(ctx); err != nil || len(notified) != 1 {
+		t.Errorf("重复通知: %v, %v", notified, err)
+	}
+
+	// 恢复默认值同样生效
+	if _, err := standby.Reset(ctx, "bob"); err != nil {
+		t.Fatalf("恢复默认设置失败: %v", err)
+	}
+	if err := leader.Reload(ctx); err != nil || leader.Current() != defaults || len(notified) != 2 {
+		t.Errorf("恢复默认后: got %+v, %v", leader.Current(), err)
+	}
+}
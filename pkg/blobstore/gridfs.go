@@ -0,0 +1,98 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore MongoDB GridFS 存储，多实例部署时共享文件无需共享磁盘
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore 创建 GridFS 存储
+func NewGridFSStore(db *mongo.Database, bucketName string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+// Put 保存文件，同名的旧文件在新文件写入后删除
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	old, err := s.fileIDs(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	if _, err := s.bucket.UploadFromStream(key, r, opts); err != nil {
+		return err
+	}
+	for _, id := range old {
+		if err := s.bucket.DeleteContext(ctx, id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open 打开最新上传的同名文件
+func (s *GridFSStore) Open(ctx context.Context, key string) (io.ReadCloser, *Info, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, nil, err
+	}
+	stream, err := s.bucket.OpenDownloadStreamByName(key)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	file := stream.GetFile()
+	info := &Info{Size: file.Length, ContentType: contentTypeOf(key), ModTime: file.UploadDate}
+	if file.Metadata != nil {
+		if ct, ok := file.Metadata.Lookup("content_type").StringValueOK(); ok && ct != "" {
+			info.ContentType = ct
+		}
+	}
+	return stream, info, nil
+}
+
+// Exists 文件是否存在
+func (s *GridFSStore) Exists(ctx context.Context, key string) (bool, error) {
+	ids, err := s.fileIDs(ctx, key)
+	return len(ids) > 0, err
+}
+
+// fileIDs 同名文件的ID
+func (s *GridFSStore) fileIDs(ctx context.Context, key string) ([]interface{}, error) {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []interface{}
+	for cursor.Next(ctx) {
+		var file struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return nil, err
+		}
+		ids = append(ids, file.ID)
+	}
+	return ids, cursor.Err()
+}
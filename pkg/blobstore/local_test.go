@@ -0,0 +1,43 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "images/ab/abcd.png", strings.NewReader("png"), "image/png"); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if ok, err := store.Exists(ctx, "images/ab/abcd.png"); err != nil || !ok {
+		t.Fatalf("保存后应存在: %v %v", ok, err)
+	}
+
+	r, info, err := store.Open(ctx, "images/ab/abcd.png")
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "png" || info.Size != 3 || info.ContentType != "image/png" {
+		t.Fatalf("文件内容或信息不正确: %q %+v", data, info)
+	}
+
+	if _, _, err := store.Open(ctx, "images/none.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("不存在的文件应返回 ErrNotFound: %v", err)
+	}
+	for _, key := range []string{"../secret", "/etc/passwd", "images/../../x", "a//b", `a\b`, ""} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), ""); err == nil {
+			t.Errorf("非法路径应被拒绝: %q", key)
+		}
+	}
+}
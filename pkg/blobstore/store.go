@@ -0,0 +1,82 @@
+// Package blobstore 文件存储（归档的图片、快照等），支持本地文件系统和 MongoDB GridFS
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound 文件不存在
+var ErrNotFound = errors.New("文件不存在")
+
+// Info 文件信息
+type Info struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store 文件存储，key 为以 / 分隔的相对路径（如 images/ab/abcd.png）
+type Store interface {
+	// Put 保存文件，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Open 打开文件，不存在时返回 ErrNotFound，调用方负责关闭
+	Open(ctx context.Context, key string) (io.ReadCloser, *Info, error)
+	// Exists 文件是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Config 存储配置
+type Config struct {
+	Type         string // local 或 gridfs
+	LocalDir     string // 本地存储目录
+	GridFSBucket string // GridFS 桶名称
+}
+
+// New 按配置创建文件存储，db 用于 GridFS 存储
+func New(cfg Config, db *mongo.Database) (Store, error) {
+	switch cfg.Type {
+	case "", "local":
+		store, err := NewLocalStore(cfg.LocalDir)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "gridfs":
+		if db == nil {
+			return nil, errors.New("gridfs 存储需要使用MongoDB，database.driver 为 sqlite 或 postgres 时请使用 local 存储")
+		}
+		store, err := NewGridFSStore(db, cfg.GridFSBucket)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", cfg.Type)
+	}
+}
+
+// ValidateKey 检查路径是否合法，禁止绝对路径和 .. 等越出存储目录的路径
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("非法的文件路径: %s", key)
+	}
+	return nil
+}
+
+// contentTypeOf 根据扩展名推断文件类型
+func contentTypeOf(key string) string {
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
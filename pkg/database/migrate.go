@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationsCollection 记录已执行迁移的集合
+const MigrationsCollection = "schema_migrations"
+
+// Migration 数据库迁移，按版本号从小到大执行，每个版本只执行一次
+// 多个实例同时启动时同一迁移可能被重复执行，Up 需要可以安全地重复执行
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationRecord 已执行的迁移记录
+type MigrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	Duration    int64     `bson:"duration"` // 执行耗时（毫秒）
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// sortMigrations 按版本号排序并检查版本号是否有效，不修改传入的切片
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	versions := make([]int, len(sorted))
+	for i, m := range sorted {
+		if m.Up == nil {
+			return nil, fmt.Errorf("迁移 %d 缺少执行函数", m.Version)
+		}
+		versions[i] = m.Version
+	}
+	if err := checkVersions(versions); err != nil {
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// checkVersions 检查已排序的迁移版本号是否大于0且不重复
+func checkVersions(versions []int) error {
+	for i, v := range versions {
+		if v <= 0 {
+			return fmt.Errorf("迁移版本号必须大于0: %d", v)
+		}
+		if i > 0 && versions[i-1] == v {
+			return fmt.Errorf("迁移版本号重复: %d", v)
+		}
+	}
+	return nil
+}
+
+// appliedMigrations 查询已执行的迁移记录，按版本号索引
+func appliedMigrations(ctx context.Context, db *mongo.Database) (map[int]MigrationRecord, error) {
+	cursor, err := db.Collection(MigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []MigrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("解析迁移记录失败: %w", err)
+	}
+	applied := make(map[int]MigrationRecord, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// MigrationStatuses 查询所有迁移的执行状态，按版本号排序
+func MigrationStatuses(ctx context.Context, db *mongo.Database, migrations []Migration) ([]MigrationStatus, error) {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(sorted))
+	for i, m := range sorted {
+		record, ok := applied[m.Version]
+		statuses[i] = MigrationStatus{Migration: m, Applied: ok, AppliedAt: record.AppliedAt}
+	}
+	return statuses, nil
+}
+
+// Migrate 按版本号顺序执行尚未执行的迁移，返回本次执行的迁移
+// 某个迁移失败时停止，之后的迁移不执行，已成功的迁移保留记录
+func Migrate(ctx context.Context, db *mongo.Database, migrations []Migration) ([]Migration, error) {
+	statuses, err := MigrationStatuses(ctx, db, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, s := range statuses {
+		if s.Applied {
+			continue
+		}
+		start := time.Now()
+		if err := s.Up(ctx, db); err != nil {
+			return done, fmt.Errorf("执行迁移 %d（%s）失败: %w", s.Version, s.Description, err)
+		}
+		_, err := db.Collection(MigrationsCollection).InsertOne(ctx, MigrationRecord{
+			Version:     s.Version,
+			Description: s.Description,
+			AppliedAt:   time.Now(),
+			Duration:    time.Since(start).Milliseconds(),
+		})
+		// 其他实例已记录同一迁移时忽略
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return done, fmt.Errorf("记录迁移 %d 失败: %w", s.Version, err)
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// CreateIndexes 返回在指定集合上创建索引的迁移函数，索引已存在时不报错
+func CreateIndexes(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 验证迁移按版本号排序，版本号无效或重复时报错
+func TestSortMigrations(t *testing.T) {
+	up := func(ctx context.Context, db *mongo.Database) error { return nil }
+
+	migrations := []Migration{{Version: 3, Up: up}, {Version: 1, Up: up}, {Version: 2, Up: up}}
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		t.Fatalf("sortMigrations: %v", err)
+	}
+	for i, m := range sorted {
+		if m.Version != i+1 {
+			t.Errorf("sorted[%d]: got version %d", i, m.Version)
+		}
+	}
+	if migrations[0].Version != 3 {
+		t.Error("sortMigrations modified its input")
+	}
+
+	invalid := [][]Migration{
+		{{Version: 0, Up: up}},
+		{{Version: 1, Up: up}, {Version: 1, Up: up}},
+		{{Version: 1}},
+	}
+	for _, ms := range invalid {
+		if _, err := sortMigrations(ms); err == nil {
+			t.Errorf("sortMigrations(%+v): expected error", ms)
+		}
+	}
+}
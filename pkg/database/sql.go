@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL 驱动（pgx）
+	_ "modernc.org/sqlite"             // SQLite 驱动（纯Go实现，无需cgo）
+)
+
+// 支持的关系型数据库
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// SQL 全局关系型数据库连接，未启用时为nil
+var SQL *SQLDB
+
+// SQLConfig 关系型数据库配置
+type SQLConfig struct {
+	Driver  string // sqlite 或 postgres
+	DSN     string // sqlite 为数据库文件路径，postgres 为连接串
+	Timeout int    // 连接超时时间（秒）
+}
+
+// SQLDB 关系型数据库连接，查询语句统一使用 ? 作为参数占位符
+type SQLDB struct {
+	*sql.DB
+	Driver string
+}
+
+// OpenSQL 打开关系型数据库连接并检查是否可用
+func OpenSQL(cfg SQLConfig) (*SQLDB, error) {
+	var db *sql.DB
+	var err error
+	switch cfg.Driver {
+	case DriverSQLite:
+		if dir := filepath.Dir(cfg.DSN); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+			}
+		}
+		// 等待其他连接释放写锁，WAL 模式下读写互不阻塞
+		sep := "?"
+		if strings.Contains(cfg.DSN, "?") {
+			sep = "&"
+		}
+		db, err = sql.Open("sqlite", cfg.DSN+sep+"_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+		if err != nil {
+			return nil, err
+		}
+		// SQLite 同一时间只允许一个写入，使用单个连接避免 database is locked
+		db.SetMaxOpenConns(1)
+	case DriverPostgres:
+		db, err = sql.Open("pgx", cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Driver)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return &SQLDB{DB: db, Driver: cfg.Driver}, nil
+}
+
+// InitSQL 初始化全局关系型数据库连接
+func InitSQL(cfg SQLConfig) error {
+	db, err := OpenSQL(cfg)
+	if err != nil {
+		return err
+	}
+	SQL = db
+	return nil
+}
+
+// CloseSQL 关闭全局关系型数据库连接
+func CloseSQL() error {
+	if SQL == nil {
+		return nil
+	}
+	return SQL.Close()
+}
+
+// Rebind 把 ? 占位符转换为当前数据库的格式（PostgreSQL 为 $1, $2...）
+// 语句中不能包含作为普通字符的 ?
+func (db *SQLDB) Rebind(query string) string {
+	if db.Driver != DriverPostgres {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// NowMillis 数据库服务器当前时间（毫秒时间戳）的SQL表达式，同一条语句中取值相同
+// 用于多实例之间需要统一时钟的场景（如租约过期时间）
+func (db *SQLDB) NowMillis() string {
+	if db.Driver == DriverPostgres {
+		return `CAST(EXTRACT(EPOCH FROM now()) * 1000 AS BIGINT)`
+	}
+	return `CAST(unixepoch('subsec') * 1000 AS INTEGER)`
+}
+
+// SQLMigration 关系型数据库的迁移，Statements 在同一个事务中按顺序执行
+type SQLMigration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// SQLMigrationStatus 关系型数据库迁移的执行状态
+type SQLMigrationStatus struct {
+	SQLMigration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// SQLMigrationStatuses 查询所有迁移的执行状态，按版本号排序
+func SQLMigrationStatuses(ctx context.Context, db *SQLDB, migrations []SQLMigration) ([]SQLMigrationStatus, error) {
+	sorted := append([]SQLMigration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	versions := make([]int, len(sorted))
+	for i, m := range sorted {
+		versions[i] = m.Version
+	}
+	if err := checkVersions(versions); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+MigrationsCollection+` (
+		version BIGINT PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at BIGINT NOT NULL,
+		duration BIGINT NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM `+MigrationsCollection)
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer rows.Close()
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]SQLMigrationStatus, len(sorted))
+	for i, m := range sorted {
+		at, ok := applied[m.Version]
+		statuses[i] = SQLMigrationStatus{SQLMigration: m, Applied: ok}
+		if ok {
+			statuses[i].AppliedAt = time.UnixMilli(at)
+		}
+	}
+	return statuses, nil
+}
+
+// MigrateSQL 按版本号顺序执行尚未执行的迁移，返回本次执行的迁移
+// 每个迁移在独立的事务中执行并记录，失败时回滚该迁移并停止
+func MigrateSQL(ctx context.Context, db *SQLDB, migrations []SQLMigration) ([]SQLMigration, error) {
+	statuses, err := SQLMigrationStatuses(ctx, db, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []SQLMigration
+	for _, s := range statuses {
+		if s.Applied {
+			continue
+		}
+		if err := applySQLMigration(ctx, db, s.SQLMigration); err != nil {
+			return done, fmt.Errorf("执行迁移 %d（%s）失败: %w", s.Version, s.Description, err)
+		}
+		done = append(done, s.SQLMigration)
+	}
+	return done, nil
+}
+
+// applySQLMigration 在事务中执行一个迁移并记录
+func applySQLMigration(ctx context.Context, db *SQLDB, m SQLMigration) error {
+	start := time.Now()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, db.Rebind(`INSERT INTO `+MigrationsCollection+
+		` (version, description, applied_at, duration) VALUES (?, ?, ?, ?)`),
+		m.Version, m.Description, time.Now().UnixMilli(), time.Since(start).Milliseconds())
+	if err != nil {
+		return fmt.Errorf("记录迁移失败: %w", err)
+	}
+	return tx.Commit()
+}
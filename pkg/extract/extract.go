@@ -0,0 +1,207 @@
+// Package extract 从公众号文章正文HTML中提取清洗后的HTML、Markdown、纯文本和元数据
+package extract
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 阅读速度，用于估算阅读时长
+const (
+	cjkCharsPerMinute   = 400 // 中文每分钟阅读字数
+	latinWordsPerMinute = 200 // 英文每分钟阅读单词数
+)
+
+// Link 正文中的链接
+type Link struct {
+	Text string
+	URL  string
+}
+
+// Heading 正文中的标题
+type Heading struct {
+	Level int // 1-6
+	Text  string
+}
+
+// Metadata 正文的统计信息
+type Metadata struct {
+	WordCount      int       // 字数，中文按字、英文按单词计
+	ImageCount     int       // 图片数量
+	Images         []string  // 图片地址（已替换懒加载地址），按出现顺序
+	Links          []Link    // 链接，按出现顺序去重
+	Headings       []Heading // 标题
+	ReadingMinutes int       // 预计阅读时长（分钟），没有文字时为0
+}
+
+// Result 正文处理结果
+type Result struct {
+	HTML     string // 清洗后的HTML，去掉内联样式、脚本和不支持的元素，图片使用真实地址
+	Markdown string
+	Text     string // 纯文本，段落之间空一行
+	Meta     Metadata
+
+	root *html.Node // 清洗后的节点树，替换图片地址后重新渲染
+}
+
+// Process 处理正文HTML（通常是 #js_content 元素的 OuterHTML）
+func Process(rawHTML string) (*Result, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(rawHTML), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		for _, clean := range sanitize(n) {
+			root.AppendChild(clean)
+		}
+	}
+
+	// 去掉最外层的正文容器（#js_content）
+	if c := root.FirstChild; c != nil && c == root.LastChild && c.Type == html.ElementNode && c.Data == "div" {
+		root = c
+	}
+
+	text := render(root, false)
+	result := &Result{
+		Text: text,
+		Meta: collectMetadata(root, text),
+		root: root,
+	}
+	if err := result.renderHTML(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RewriteImages 按 urls 替换图片地址（如替换为本地归档的地址）并重新生成 HTML 和 Markdown
+// urls 中没有的图片保留原地址，Meta.Images 仍为原地址
+func (r *Result) RewriteImages(urls map[string]string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			for i, a := range n.Attr {
+				if a.Key == "src" && urls[a.Val] != "" {
+					n.Attr[i].Val = urls[a.Val]
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(r.root)
+	return r.renderHTML()
+}
+
+// renderHTML 由节点树生成清洗后的 HTML 和 Markdown
+func (r *Result) renderHTML() error {
+	var buf bytes.Buffer
+	for c := r.root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return err
+		}
+	}
+	r.HTML = buf.String()
+	r.Markdown = render(r.root, true)
+	return nil
+}
+
+// collectMetadata 从清洗后的节点树统计图片、链接、标题和字数
+func collectMetadata(root *html.Node, text string) Metadata {
+	var meta Metadata
+	seenLinks := make(map[string]bool)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "img":
+				meta.Images = append(meta.Images, attr(n, "src"))
+			case "a":
+				href := attr(n, "href")
+				if !seenLinks[href] {
+					seenLinks[href] = true
+					meta.Links = append(meta.Links, Link{Text: collapseSpaces(textContent(n)), URL: href})
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if title := collapseSpaces(textContent(n)); title != "" {
+					meta.Headings = append(meta.Headings, Heading{Level: int(n.Data[1] - '0'), Text: title})
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	cjk, words := countWords(text)
+	meta.ImageCount = len(meta.Images)
+	meta.WordCount = cjk + words
+	if meta.WordCount > 0 {
+		minutes := float64(cjk)/cjkCharsPerMinute + float64(words)/latinWordsPerMinute
+		meta.ReadingMinutes = int(math.Max(1, math.Ceil(minutes)))
+	}
+	return meta
+}
+
+// countWords 统计中日韩文字数和其他语言的单词数
+func countWords(text string) (cjk, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return cjk, words
+}
+
+// textContent 节点内的全部文本
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+// collapseSpaces 合并连续空白并去掉首尾空白
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// attr 获取节点属性
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
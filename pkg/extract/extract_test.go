@@ -0,0 +1,104 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleHTML = `<div class="rich_media_content" id="js_content" style="visibility: hidden;">
+<section style="margin: 0px;"><section><h2 style="font-size: 18px;"><span>一、背景</span></h2></section></section>
+<p style="line-height: 1.75em;"><span style="color: rgb(62, 62, 62);">公众号正文包含<strong>加粗</strong>和 Go 语言</span></p>
+<p><br></p>
+<p><img class="rich_pages" data-src="https://mmbiz.qpic.cn/a.png" src="data:image/svg+xml,placeholder" alt="示意图"></p>
+<script>alert(1)</script>
+<ul><li>第一项</li><li>第二项<br>补充</li></ul>
+<p><a href="https://example.com/doc" style="color:blue">参考文档</a><a href="javascript:void(0)">无效链接</a></p>
+<mpvoice name="音频"></mpvoice>
+</div>`
+
+func TestProcess(t *testing.T) {
+	result, err := Process(sampleHTML)
+	if err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+
+	for _, bad := range []string{"style=", "<script", "data:image", "<span", "<section", "javascript:", "mpvoice", "<br/></p>"} {
+		if strings.Contains(result.HTML, bad) {
+			t.Errorf("清洗后的HTML不应包含 %q: %s", bad, result.HTML)
+		}
+	}
+	if !strings.Contains(result.HTML, `<img src="https://mmbiz.qpic.cn/a.png" alt="示意图"/>`) {
+		t.Errorf("图片应使用 data-src 中的真实地址: %s", result.HTML)
+	}
+
+	wantMarkdown := strings.Join([]string{
+		"## 一、背景",
+		"公众号正文包含**加粗**和 Go 语言",
+		"![示意图](https://mmbiz.qpic.cn/a.png)",
+		"- 第一项\n- 第二项  \n  补充",
+		"[参考文档](https://example.com/doc)无效链接",
+	}, "\n\n")
+	if result.Markdown != wantMarkdown {
+		t.Errorf("Markdown 不正确:\n%s", result.Markdown)
+	}
+
+	wantText := "一、背景\n\n公众号正文包含加粗和 Go 语言\n\n- 第一项\n- 第二项\n  补充\n\n参考文档无效链接"
+	if result.Text != wantText {
+		t.Errorf("纯文本不正确:\n%s", result.Text)
+	}
+
+	meta := result.Meta
+	if meta.ImageCount != 1 || meta.Images[0] != "https://mmbiz.qpic.cn/a.png" {
+		t.Errorf("图片统计不正确: %+v", meta)
+	}
+	if !reflect.DeepEqual(meta.Links, []Link{{Text: "参考文档", URL: "https://example.com/doc"}}) {
+		t.Errorf("链接不正确: %+v", meta.Links)
+	}
+	if !reflect.DeepEqual(meta.Headings, []Heading{{Level: 2, Text: "一、背景"}}) {
+		t.Errorf("标题不正确: %+v", meta.Headings)
+	}
+	// 中文字符按字计，Go 按一个单词计
+	if cjk, words := countWords(result.Text); meta.WordCount != cjk+words || words != 1 {
+		t.Errorf("字数不正确: %d (cjk=%d, words=%d)", meta.WordCount, cjk, words)
+	}
+	if meta.ReadingMinutes != 1 {
+		t.Errorf("阅读时长不正确: %d", meta.ReadingMinutes)
+	}
+}
+
+func TestProcessTable(t *testing.T) {
+	result, err := Process(`<table><tbody><tr><th>名称</th><th>说明</th></tr><tr><td>a|b</td><td>值</td></tr></tbody></table>`)
+	if err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	want := "| 名称 | 说明 |\n| --- | --- |\n| a\\|b | 值 |"
+	if result.Markdown != want {
+		t.Errorf("表格 Markdown 不正确:\n%s", result.Markdown)
+	}
+	if result.Text != "名称\t说明\na|b\t值" {
+		t.Errorf("表格纯文本不正确: %q", result.Text)
+	}
+}
+
+// 验证替换图片地址后重新生成 HTML 和 Markdown，统计信息保留原地址
+func TestRewriteImages(t *testing.T) {
+	result, err := Process(`<p><img data-src="https://mmbiz.qpic.cn/a.png?wx_fmt=png&amp;from=appmsg"></p><p><img src="https://example.com/b.png"></p>`)
+	if err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	original := "https://mmbiz.qpic.cn/a.png?wx_fmt=png&from=appmsg"
+	if err := result.RewriteImages(map[string]string{original: "/media/images/ab/abcd.png"}); err != nil {
+		t.Fatalf("替换失败: %v", err)
+	}
+
+	if !strings.Contains(result.HTML, `<img src="/media/images/ab/abcd.png"/>`) || !strings.Contains(result.HTML, "https://example.com/b.png") {
+		t.Errorf("HTML 中的图片地址不正确: %s", result.HTML)
+	}
+	if result.Markdown != "![](/media/images/ab/abcd.png)\n\n![](https://example.com/b.png)" {
+		t.Errorf("Markdown 中的图片地址不正确: %s", result.Markdown)
+	}
+	if result.Meta.Images[0] != original {
+		t.Errorf("统计信息应保留原地址: %v", result.Meta.Images)
+	}
+}
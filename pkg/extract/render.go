@@ -0,0 +1,301 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockTags 块级元素，前后分段
+var blockTags = map[string]bool{
+	"p": true, "div": true, "blockquote": true, "pre": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true,
+	"table": true, "thead": true, "tbody": true, "tr": true,
+	"figure": true, "figcaption": true,
+}
+
+// markdownEscaper 转义文字中的 Markdown 标记
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+)
+
+// renderer 把清洗后的节点树转为 Markdown 或纯文本
+type renderer struct {
+	markdown bool
+}
+
+// render 渲染为 Markdown 或纯文本，段落之间空一行
+func render(root *html.Node, markdown bool) string {
+	r := renderer{markdown: markdown}
+	return strings.Join(r.blocks(root), "\n\n")
+}
+
+// blocks 渲染容器内的内容，块级元素单独成段，相邻的行内内容合并为一段
+func (r renderer) blocks(n *html.Node) []string {
+	var result []string
+	var inline strings.Builder
+	flush := func() {
+		if text := r.joinLines(inline.String()); text != "" {
+			result = append(result, text)
+		}
+		inline.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockTags[c.Data] {
+			flush()
+			result = append(result, r.block(c)...)
+		} else {
+			inline.WriteString(r.inline(c))
+		}
+	}
+	flush()
+	return result
+}
+
+// block 渲染块级元素
+func (r renderer) block(n *html.Node) []string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := collapseSpaces(r.inlineChildren(n))
+		if text == "" {
+			return nil
+		}
+		if r.markdown {
+			text = strings.Repeat("#", int(n.Data[1]-'0')) + " " + text
+		}
+		return []string{text}
+
+	case "ul", "ol":
+		if list := r.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+
+	case "blockquote":
+		inner := strings.Join(r.blocks(n), "\n\n")
+		if inner == "" || !r.markdown {
+			return nonEmpty(inner)
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			if line == "" {
+				lines[i] = ">"
+			} else {
+				lines[i] = "> " + line
+			}
+		}
+		return []string{strings.Join(lines, "\n")}
+
+	case "pre":
+		code := strings.Trim(textContent(n), "\n")
+		if code == "" || !r.markdown {
+			return nonEmpty(code)
+		}
+		return []string{"```\n" + code + "\n```"}
+
+	case "hr":
+		if r.markdown {
+			return []string{"---"}
+		}
+		return nil
+
+	case "table", "thead", "tbody", "tr":
+		return nonEmpty(r.table(n))
+
+	default:
+		return r.blocks(n)
+	}
+}
+
+// list 渲染列表，每项的后续行按标记宽度缩进
+func (r renderer) list(n *html.Node) string {
+	var items []string
+	index := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		var body string
+		if c.Type == html.ElementNode && c.Data == "li" {
+			body = strings.Join(r.blocks(c), "\n\n")
+		} else {
+			body = r.joinLines(r.inline(c))
+		}
+		if body == "" {
+			continue
+		}
+
+		index++
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(body, "\n")
+		for i := range lines {
+			if i == 0 {
+				lines[i] = marker + lines[i]
+			} else if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table 渲染表格，Markdown 以第一行作为表头，纯文本每行的单元格以制表符分隔
+func (r renderer) table(n *html.Node) string {
+	var rows [][]string
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if n.Data == "tr" {
+			if row := r.tableRow(n); len(row) > 0 {
+				rows = append(rows, row)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var lines []string
+	if !r.markdown {
+		for _, row := range rows {
+			lines = append(lines, strings.Join(row, "\t"))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableRow 渲染表格的一行
+func (r renderer) tableRow(tr *html.Node) []string {
+	var row []string
+	for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+		if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+			text := collapseSpaces(r.inlineChildren(cell))
+			if r.markdown {
+				text = strings.ReplaceAll(text, "|", `\|`)
+			}
+			row = append(row, text)
+		}
+	}
+	return row
+}
+
+// inline 渲染行内内容，换行元素输出为换行符
+func (r renderer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.FieldsFunc(n.Data, func(c rune) bool { return c == '\n' || c == '\r' || c == '\t' }), " ")
+		if r.markdown {
+			return markdownEscaper.Replace(text)
+		}
+		return text
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\n"
+	case "img":
+		if !r.markdown {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)", markdownEscaper.Replace(attr(n, "alt")), attr(n, "src"))
+	case "strong":
+		return r.wrap(n, "**")
+	case "em":
+		return r.wrap(n, "*")
+	case "del":
+		return r.wrap(n, "~~")
+	case "code":
+		code := textContent(n)
+		if r.markdown && strings.TrimSpace(code) != "" {
+			return "`" + code + "`"
+		}
+		return code
+	case "a":
+		text := strings.TrimSpace(r.inlineChildren(n))
+		if !r.markdown {
+			return text
+		}
+		if text == "" {
+			text = attr(n, "href")
+		}
+		return "[" + text + "](" + attr(n, "href") + ")"
+	default:
+		if blockTags[n.Data] {
+			// 行内元素中的块级元素（如链接中的段落）前后加空格
+			return " " + r.inlineChildren(n) + " "
+		}
+		return r.inlineChildren(n)
+	}
+}
+
+// inlineChildren 渲染子节点的行内内容
+func (r renderer) inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(r.inline(c))
+	}
+	return sb.String()
+}
+
+// wrap 用 Markdown 标记包裹强调内容，标记紧贴文字，首尾空白留在标记外
+func (r renderer) wrap(n *html.Node, marker string) string {
+	inner := r.inlineChildren(n)
+	text := strings.TrimSpace(inner)
+	if !r.markdown || text == "" || strings.Contains(text, "\n") {
+		return inner
+	}
+	start := strings.Index(inner, text)
+	return inner[:start] + marker + text + marker + inner[start+len(text):]
+}
+
+// joinLines 整理一段行内内容：合并空白、去掉空行，Markdown 中的换行使用硬换行
+func (r renderer) joinLines(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if r.markdown {
+		return strings.Join(lines, "  \n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nonEmpty 非空时返回单个段落
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
@@ -0,0 +1,172 @@
+package extract
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// droppedTags 连同内容一起丢弃的元素（脚本、样式、音视频卡片、表单等）
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "object": true, "embed": true, "svg": true, "canvas": true,
+	"video": true, "audio": true, "mpvoice": true, "mpvideo": true, "qqmusic": true,
+	"form": true, "input": true, "button": true, "select": true, "textarea": true,
+	"head": true, "title": true, "meta": true, "link": true,
+}
+
+// keptTags 保留的元素及允许的属性，其他元素（span、font等）去掉标签保留内容
+var keptTags = map[string][]string{
+	"p": nil, "div": nil, "blockquote": nil, "pre": nil, "code": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"ul": nil, "ol": nil, "li": nil,
+	"strong": nil, "em": nil, "u": nil, "del": nil, "sup": nil, "sub": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+	"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	"figure": nil, "figcaption": nil,
+	"a": {"href"},
+}
+
+// renamedTags 转换为语义相同的标准元素
+var renamedTags = map[string]string{
+	"section": "div",
+	"article": "div",
+	"b":       "strong",
+	"i":       "em",
+	"s":       "del",
+	"strike":  "del",
+}
+
+// contentTags 本身即为内容的元素，其他元素没有文字、图片和分隔线时丢弃（如只有换行的空段落）
+var contentTags = map[string]bool{"img": true, "hr": true}
+
+// sanitize 清洗节点，返回清洗后的节点（被拆掉标签的元素返回其子节点）
+func sanitize(n *html.Node) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+	case html.DocumentNode:
+		return sanitizeChildren(n)
+	default:
+		return nil
+	}
+
+	tag := n.Data
+	if droppedTags[tag] {
+		return nil
+	}
+	if renamed, ok := renamedTags[tag]; ok {
+		tag = renamed
+	}
+
+	switch tag {
+	case "img":
+		src := imageSource(n)
+		if src == "" {
+			return nil
+		}
+		img := element("img", html.Attribute{Key: "src", Val: src})
+		if alt := attr(n, "alt"); alt != "" {
+			img.Attr = append(img.Attr, html.Attribute{Key: "alt", Val: alt})
+		}
+		return []*html.Node{img}
+	case "br", "hr":
+		return []*html.Node{element(tag)}
+	case "a":
+		href := safeURL(attr(n, "href"))
+		if href == "" {
+			return sanitizeChildren(n)
+		}
+		a := element("a", html.Attribute{Key: "href", Val: href})
+		appendAll(a, sanitizeChildren(n))
+		if a.FirstChild == nil {
+			return nil
+		}
+		return []*html.Node{a}
+	}
+
+	allowed, ok := keptTags[tag]
+	if !ok {
+		return sanitizeChildren(n)
+	}
+
+	el := element(tag)
+	for _, key := range allowed {
+		if val := attr(n, key); val != "" {
+			el.Attr = append(el.Attr, html.Attribute{Key: key, Val: val})
+		}
+	}
+	appendAll(el, sanitizeChildren(n))
+	if !hasContent(el) {
+		return nil
+	}
+	// 公众号编辑器常见的多层嵌套容器只保留一层
+	if tag == "div" && el.FirstChild != nil && el.FirstChild == el.LastChild &&
+		el.FirstChild.Type == html.ElementNode && el.FirstChild.Data == "div" {
+		child := el.FirstChild
+		el.RemoveChild(child)
+		return []*html.Node{child}
+	}
+	return []*html.Node{el}
+}
+
+// sanitizeChildren 清洗子节点
+func sanitizeChildren(n *html.Node) []*html.Node {
+	var result []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result = append(result, sanitize(c)...)
+	}
+	return result
+}
+
+// imageSource 图片的真实地址，公众号图片懒加载时真实地址在 data-src 中，src 是占位图
+func imageSource(n *html.Node) string {
+	for _, key := range []string{"data-src", "src"} {
+		if src := safeURL(attr(n, key)); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// safeURL 只保留 http/https 地址，协议相对地址补全为 https
+func safeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "//"):
+		return "https:" + raw
+	case strings.HasPrefix(raw, "http://"), strings.HasPrefix(raw, "https://"):
+		return raw
+	default:
+		return ""
+	}
+}
+
+// hasContent 元素内是否有文字、图片或分隔线
+func hasContent(n *html.Node) bool {
+	if n.Type == html.TextNode {
+		return strings.TrimSpace(n.Data) != ""
+	}
+	if n.Type == html.ElementNode && contentTags[n.Data] {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasContent(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// element 创建元素节点
+func element(tag string, attrs ...html.Attribute) *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: tag, Attr: attrs}
+}
+
+// appendAll 追加子节点
+func appendAll(parent *html.Node, children []*html.Node) {
+	for _, c := range children {
+		parent.AppendChild(c)
+	}
+}
@@ -177,6 +177,74 @@ func (f *FeishuNotifier) SendArticleCard(title string, articles []*model.Article
 	return f.sendMessage(message)
 }
 
+// SendRemovedArticleCard 发送文章被删除/屏蔽的提醒卡片
+func (f *FeishuNotifier) SendRemovedArticleCard(articles []*model.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	elements := []interface{}{}
+	elements = append(elements, map[string]interface{}{
+		"tag": "div",
+		"text": map[string]interface{}{
+			"tag":     "lark_md",
+			"content": fmt.Sprintf("🕐 %s | 📊 共 %d 篇文章已无法访问，已保存的正文可在管理后台查看", time.Now().Format("2006-01-02 15:04:05"), len(articles)),
+		},
+	})
+	elements = append(elements, map[string]interface{}{
+		"tag": "hr",
+	})
+
+	for i, article := range articles {
+		status := "已删除"
+		if article.Status == model.ArticleStatusBlocked {
+			status = "已屏蔽"
+		}
+		contentText := fmt.Sprintf("**%s** 【%s】\n👤 %s | 📅 发布于 %s | 🔍 发现于 %s",
+			article.Title,
+			status,
+			article.AccountName,
+			time.Unix(article.PublishTime, 0).Format("2006-01-02 15:04"),
+			article.StatusChangedAt.Format("2006-01-02 15:04"),
+		)
+
+		elements = append(elements, map[string]interface{}{
+			"tag": "div",
+			"text": map[string]interface{}{
+				"tag":     "lark_md",
+				"content": contentText,
+			},
+		})
+
+		if i < len(articles)-1 {
+			elements = append(elements, map[string]interface{}{
+				"tag": "hr",
+			})
+		}
+	}
+
+	card := map[string]interface{}{
+		"config": map[string]interface{}{
+			"wide_screen_mode": true,
+		},
+		"header": map[string]interface{}{
+			"template": "red",
+			"title": map[string]interface{}{
+				"tag":     "plain_text",
+				"content": "公众号文章删除提醒",
+			},
+		},
+		"elements": elements,
+	}
+
+	message := FeishuCardMessage{
+		MsgType: "interactive",
+		Card:    card,
+	}
+
+	return f.sendMessage(message)
+}
+
 // sendMessage 发送消息到飞书webhook
 func (f *FeishuNotifier) sendMessage(message interface{}) error {
 	if f.webhookURL == "" {
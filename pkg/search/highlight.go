@@ -0,0 +1,121 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 高亮标记
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+	ellipsis  = "…"
+)
+
+// span 命中的字符区间 [start, end)
+type span struct{ start, end int }
+
+// findSpans 查找所有关键词的命中位置（不区分大小写），重叠的区间合并
+func findSpans(text []rune, terms []string) []span {
+	lower := toLower(append([]rune(nil), text...))
+
+	var spans []span
+	for _, term := range terms {
+		pattern := toLower([]rune(term))
+		if len(pattern) == 0 {
+			continue
+		}
+		for i := 0; i+len(pattern) <= len(lower); i++ {
+			if equalRunes(lower[i:i+len(pattern)], pattern) {
+				spans = append(spans, span{i, i + len(pattern)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			last.end = max(last.end, s.end)
+		} else {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// toLower 逐字转为小写，保持字符位置不变
+func toLower(runes []rune) []rune {
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// equalRunes 两段字符是否相同
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// renderSpans 输出 [from, to) 范围内转义后的HTML，命中部分用 <mark> 包裹
+func renderSpans(text []rune, spans []span, from, to int) string {
+	var sb strings.Builder
+	pos := from
+	for _, s := range spans {
+		if s.end <= from || s.start >= to {
+			continue
+		}
+		start, end := max(s.start, from), min(s.end, to)
+		sb.WriteString(html.EscapeString(string(text[pos:start])))
+		sb.WriteString(markOpen)
+		sb.WriteString(html.EscapeString(string(text[start:end])))
+		sb.WriteString(markClose)
+		pos = end
+	}
+	sb.WriteString(html.EscapeString(string(text[pos:to])))
+	return sb.String()
+}
+
+// Highlight 高亮整段文字中的关键词（如标题），返回转义后的HTML
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return renderSpans(runes, findSpans(runes, terms), 0, len(runes))
+}
+
+// Snippet 截取第一个命中位置附近约 width 个字的片段并高亮关键词，返回转义后的HTML
+// 没有命中时返回开头的片段，文字为空时返回空字符串
+func Snippet(text string, terms []string, width int) string {
+	runes := []rune(normalizeSpaces(text))
+	if len(runes) == 0 {
+		return ""
+	}
+	spans := findSpans(runes, terms)
+
+	from := 0
+	if len(spans) > 0 {
+		// 命中位置前保留约四分之一的上下文
+		from = max(0, spans[0].start-width/4)
+	}
+	to := min(len(runes), from+width)
+	from = max(0, min(from, to-width))
+
+	snippet := renderSpans(runes, spans, from, to)
+	if from > 0 {
+		snippet = ellipsis + snippet
+	}
+	if to < len(runes) {
+		snippet += ellipsis
+	}
+	return snippet
+}
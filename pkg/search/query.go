@@ -0,0 +1,138 @@
+package search
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptyGroup 某组条件只有排除词
+var ErrEmptyGroup = errors.New("每组搜索条件至少需要一个非排除的关键词")
+
+// maxTerms 单次搜索最多的关键词数
+const maxTerms = 20
+
+// Term 关键词或短语，在标题、摘要、作者和正文中按原文连续匹配（不区分大小写）
+type Term struct {
+	Text   string   // 关键词，短语中的连续空白合并为一个空格
+	Tokens []string // 需要全部命中的检索词，用于索引查询
+}
+
+// Pattern 匹配关键词的正则表达式，短语中的空格匹配任意空白
+func (t Term) Pattern() string {
+	parts := strings.Fields(t.Text)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, `\s+`)
+}
+
+// Group 同时满足的一组条件
+type Group struct {
+	Include []Term // 必须包含
+	Exclude []Term // 不能包含
+}
+
+// Query 解析后的搜索条件，满足任意一组即命中
+type Query struct {
+	Groups []Group
+}
+
+// Terms 所有需要包含的关键词（去重），用于高亮
+func (q *Query) Terms() []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, g := range q.Groups {
+		for _, t := range g.Include {
+			if !seen[t.Text] {
+				seen[t.Text] = true
+				terms = append(terms, t.Text)
+			}
+		}
+	}
+	return terms
+}
+
+// Parse 解析搜索语法，没有关键词时返回nil：
+//   - 空格分隔的关键词需同时包含
+//   - "双引号" 内为短语，可包含空格
+//   - 关键词或短语前加 - 表示排除
+//   - OR 或 | 分隔的条件满足其一即可（OR 必须大写）
+func Parse(input string) (*Query, error) {
+	var groups []Group
+	var current Group
+	terms := 0
+	finish := func() error {
+		if len(current.Include) == 0 && len(current.Exclude) == 0 {
+			return nil
+		}
+		if len(current.Include) == 0 {
+			return ErrEmptyGroup
+		}
+		groups = append(groups, current)
+		current = Group{}
+		return nil
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		exclude := false
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			exclude = true
+			i++
+		}
+
+		var text string
+		phrase := runes[i] == '"'
+		if phrase {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			i = end + 1 // 缺少右引号时短语延续到末尾
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+
+		if !phrase && !exclude && (text == "OR" || text == "|") {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		term := Term{Text: normalizeSpaces(text), Tokens: termTokens(text)}
+		if len(term.Tokens) == 0 {
+			// 只有标点符号的关键词无法检索
+			continue
+		}
+		if terms++; terms > maxTerms {
+			return nil, errors.New("搜索关键词过多")
+		}
+		if exclude {
+			current.Exclude = append(current.Exclude, term)
+		} else {
+			current.Include = append(current.Include, term)
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	return &Query{Groups: groups}, nil
+}
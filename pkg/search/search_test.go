@@ -0,0 +1,65 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	got := Tokens("微信公众号 GoLang 1.22", "公众号")
+	want := []string{"微", "微信", "信", "信公", "公", "公众", "众", "众号", "号", "golang", "1", "22"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens: got %v, want %v", got, want)
+	}
+
+	if got := termTokens("AI芯片"); !reflect.DeepEqual(got, []string{"ai", "芯片"}) {
+		t.Errorf("termTokens: got %v", got)
+	}
+	if got := termTokens("猫"); !reflect.DeepEqual(got, []string{"猫"}) {
+		t.Errorf("termTokens single: got %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`大模型 "machine  learning" -广告 OR 芯片 | GPU`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(q.Groups) != 3 {
+		t.Fatalf("groups: got %d, want 3", len(q.Groups))
+	}
+	g := q.Groups[0]
+	if len(g.Include) != 2 || g.Include[1].Text != "machine learning" || len(g.Exclude) != 1 || g.Exclude[0].Text != "广告" {
+		t.Errorf("group 0: got %+v", g)
+	}
+	if got := g.Include[1].Pattern(); got != `machine\s+learning` {
+		t.Errorf("Pattern: got %q", got)
+	}
+	if got := q.Terms(); !reflect.DeepEqual(got, []string{"大模型", "machine learning", "芯片", "GPU"}) {
+		t.Errorf("Terms: got %v", got)
+	}
+
+	if q, err := Parse("  ... "); q != nil || err != nil {
+		t.Errorf("empty query: got %v, %v", q, err)
+	}
+	if _, err := Parse("-广告"); err != ErrEmptyGroup {
+		t.Errorf("exclude only: got %v", err)
+	}
+	if q, _ := Parse(`"未闭合 短语`); q == nil || q.Groups[0].Include[0].Text != "未闭合 短语" {
+		t.Errorf("unterminated phrase: got %+v", q)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	if got := Highlight("Go <语言> 入门：go 并发", []string{"GO", "语言"}); got != "<mark>Go</mark> &lt;<mark>语言</mark>&gt; 入门：<mark>go</mark> 并发" {
+		t.Errorf("Highlight: got %q", got)
+	}
+
+	text := "第一段内容。\n\n第二段提到了关键词，后面还有很多文字"
+	if got := Snippet(text, []string{"关键词"}, 12); got != "…提到了<mark>关键词</mark>，后面还有很…" {
+		t.Errorf("Snippet: got %q", got)
+	}
+	if got := Snippet(text, nil, 6); got != "第一段内容。…" {
+		t.Errorf("Snippet without match: got %q", got)
+	}
+}
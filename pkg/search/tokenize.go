@@ -0,0 +1,119 @@
+// Package search 文章全文检索：中文按字和二元组切分的检索词、查询语法解析和命中高亮
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxTokens 单篇文章最多保存的检索词数，超出部分（通常是超长正文的尾部）不参与检索
+const MaxTokens = 20000
+
+// isCJK 是否为中日韩文字，这类文字没有空格分词，按字和相邻两字切分
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWordRune 是否为英文单词、数字的组成字符
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// segment 把文本切分为连续的中文片段和英文单词（转为小写），其他字符作为分隔
+func segment(text string) []string {
+	var segments []string
+	var current []rune
+	cjk := false
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, string(current))
+			current = current[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+			}
+			cjk = true
+			current = append(current, r)
+		case isWordRune(r):
+			if cjk {
+				flush()
+			}
+			cjk = false
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return segments
+}
+
+// segmentTokens 片段的检索词：中文为单字和相邻两字，英文单词为其本身
+func segmentTokens(seg string) []string {
+	runes := []rune(seg)
+	if !isCJK(runes[0]) {
+		return []string{seg}
+	}
+	tokens := make([]string, 0, len(runes)*2)
+	for i := range runes {
+		tokens = append(tokens, string(runes[i]))
+		if i+1 < len(runes) {
+			tokens = append(tokens, string(runes[i:i+2]))
+		}
+	}
+	return tokens
+}
+
+// Tokens 生成文本的检索词（去重），用于建立索引
+func Tokens(texts ...string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, text := range texts {
+		for _, seg := range segment(text) {
+			for _, token := range segmentTokens(seg) {
+				if seen[token] {
+					continue
+				}
+				if len(tokens) >= MaxTokens {
+					return tokens
+				}
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// termTokens 查询词需要全部命中的检索词：中文片段只取相邻两字（单字片段取单字），英文单词取其本身
+func termTokens(term string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	for _, seg := range segment(term) {
+		runes := []rune(seg)
+		if !isCJK(runes[0]) || len(runes) == 1 {
+			add(seg)
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			add(string(runes[i : i+2]))
+		}
+	}
+	return tokens
+}
+
+// normalizeSpaces 合并连续空白
+func normalizeSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,139 @@
+package textdiff
+
+// 对比结果中每一行的类型
+const (
+	KindEqual  = "equal"  // 两个版本相同
+	KindDelete = "delete" // 只在旧版本中存在
+	KindInsert = "insert" // 只在新版本中存在
+	KindChange = "change" // 并排显示时旧版本的一行被替换为新版本的一行
+)
+
+// Line 逐行对比的一行，行号从1开始，不存在时为0
+type Line struct {
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	OldNo int    `json:"old_no"`
+	NewNo int    `json:"new_no"`
+}
+
+// Row 并排对比的一行，左侧为旧版本，右侧为新版本，行号为0表示该侧为空
+type Row struct {
+	Kind    string `json:"kind"`
+	Left    string `json:"left"`
+	Right   string `json:"right"`
+	LeftNo  int    `json:"left_no"`
+	RightNo int    `json:"right_no"`
+}
+
+// Stats 对比结果的统计
+type Stats struct {
+	Added   int `json:"added"`   // 新增的行数
+	Removed int `json:"removed"` // 删除的行数
+}
+
+// Lines 基于最长公共子序列逐行对比两个版本
+func Lines(a, b []string) []Line {
+	// 去掉相同的开头和结尾，只对中间变化的部分求最长公共子序列
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		result = append(result, Line{Kind: KindEqual, Text: a[i], OldNo: i + 1, NewNo: i + 1})
+	}
+
+	oldMid, newMid := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(oldMid), len(newMid)
+	// lcs[i][j] 为 oldMid[i:] 和 newMid[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldMid[i] == newMid[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldMid[i] == newMid[j]:
+			result = append(result, Line{Kind: KindEqual, Text: oldMid[i], OldNo: prefix + i + 1, NewNo: prefix + j + 1})
+			i++
+			j++
+		case j >= m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			result = append(result, Line{Kind: KindDelete, Text: oldMid[i], OldNo: prefix + i + 1})
+			i++
+		default:
+			result = append(result, Line{Kind: KindInsert, Text: newMid[j], NewNo: prefix + j + 1})
+			j++
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		result = append(result, Line{
+			Kind:  KindEqual,
+			Text:  a[len(a)-suffix+k],
+			OldNo: len(a) - suffix + k + 1,
+			NewNo: len(b) - suffix + k + 1,
+		})
+	}
+	return result
+}
+
+// SideBySide 并排对比两个版本，相邻的删除和新增配对为修改行
+func SideBySide(a, b []string) ([]Row, Stats) {
+	lines := Lines(a, b)
+	var rows []Row
+	var stats Stats
+	for k := 0; k < len(lines); {
+		if lines[k].Kind == KindEqual {
+			rows = append(rows, Row{Kind: KindEqual, Left: lines[k].Text, Right: lines[k].Text, LeftNo: lines[k].OldNo, RightNo: lines[k].NewNo})
+			k++
+			continue
+		}
+
+		// 收集连续的删除和新增
+		var deleted, inserted []Line
+		for ; k < len(lines) && lines[k].Kind != KindEqual; k++ {
+			if lines[k].Kind == KindDelete {
+				deleted = append(deleted, lines[k])
+			} else {
+				inserted = append(inserted, lines[k])
+			}
+		}
+		stats.Removed += len(deleted)
+		stats.Added += len(inserted)
+
+		for p := 0; p < len(deleted) || p < len(inserted); p++ {
+			var row Row
+			if p < len(deleted) {
+				row.Left, row.LeftNo = deleted[p].Text, deleted[p].OldNo
+			}
+			if p < len(inserted) {
+				row.Right, row.RightNo = inserted[p].Text, inserted[p].NewNo
+			}
+			switch {
+			case row.LeftNo == 0:
+				row.Kind = KindInsert
+			case row.RightNo == 0:
+				row.Kind = KindDelete
+			default:
+				row.Kind = KindChange
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, stats
+}
@@ -0,0 +1,91 @@
+// Package textdiff 文章正文的文本规范化和逐段对比
+package textdiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// imagePlaceholder 图片在规范化文本中的占位符，增删图片会被视为修改，图片地址变化则不会
+const imagePlaceholder = "[图片]"
+
+// blockTags 块级元素，元素边界处换行，使每个段落成为一行
+var blockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true,
+	"table": true, "tr": true, "figure": true, "figcaption": true,
+}
+
+// skipTags 内容不属于正文文本的元素
+var skipTags = map[string]bool{"script": true, "style": true, "noscript": true}
+
+// NormalizeHTML 把正文HTML转为规范化的文本行：去掉标签、脚本和样式，合并连续空白，丢弃空行
+// 只修改标签属性（如样式、图片链接中的参数）不会改变规范化文本
+func NormalizeHTML(s string) []string {
+	var (
+		lines []string
+		line  strings.Builder
+		skip  int
+	)
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			flush()
+			return lines
+		case html.TextToken:
+			if skip == 0 {
+				line.Write(z.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case skipTags[tag]:
+				skip++
+			case tag == "img":
+				line.WriteString(" " + imagePlaceholder + " ")
+			case blockTags[tag]:
+				flush()
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if skipTags[tag] && skip > 0 {
+				skip--
+			} else if blockTags[tag] {
+				flush()
+			}
+		}
+	}
+}
+
+// Join 把规范化文本行合并为一段文本
+func Join(lines []string) string {
+	return strings.Join(lines, "\n")
+}
+
+// Split 把合并后的规范化文本拆回文本行
+func Split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// Hash 规范化文本的摘要，用于判断两个版本的正文是否相同
+func Hash(lines []string) string {
+	sum := sha256.Sum256([]byte(Join(lines)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package textdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 验证只有文本内容变化才影响规范化结果，标签属性和空白不影响
+func TestNormalizeHTML(t *testing.T) {
+	a := `<div id="js_content"><p style="color:red">第一段&nbsp; 内容</p><p><img src="a.png?t=1"></p><script>var x=1</script><section>第二段<br>第三段</section></div>`
+	b := `<div id="js_content">
+		<p>第一段 内容</p>
+		<p><img data-src="b.png?t=2"/></p>
+		<section>第二段<br/>第三段</section>
+	</div>`
+
+	want := []string{"第一段 内容", "[图片]", "第二段", "第三段"}
+	if got := NormalizeHTML(a); !reflect.DeepEqual(got, want) {
+		t.Fatalf("规范化结果不正确: %q", got)
+	}
+	if Hash(NormalizeHTML(a)) != Hash(NormalizeHTML(b)) {
+		t.Fatal("只有标签属性和空白不同时摘要应相同")
+	}
+	if !reflect.DeepEqual(Split(Join(want)), want) {
+		t.Fatal("合并后拆分应得到原文本行")
+	}
+}
+
+// 验证并排对比时相邻的删除和新增配对为修改行
+func TestSideBySide(t *testing.T) {
+	a := []string{"标题", "第一段", "第二段", "结尾"}
+	b := []string{"标题", "第一段（已更正）", "第二段", "补充说明", "结尾"}
+
+	rows, stats := SideBySide(a, b)
+	want := []Row{
+		{Kind: KindEqual, Left: "标题", Right: "标题", LeftNo: 1, RightNo: 1},
+		{Kind: KindChange, Left: "第一段", Right: "第一段（已更正）", LeftNo: 2, RightNo: 2},
+		{Kind: KindEqual, Left: "第二段", Right: "第二段", LeftNo: 3, RightNo: 3},
+		{Kind: KindInsert, Right: "补充说明", RightNo: 4},
+		{Kind: KindEqual, Left: "结尾", Right: "结尾", LeftNo: 4, RightNo: 5},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("对比结果不正确:\n got %+v\nwant %+v", rows, want)
+	}
+	if stats != (Stats{Added: 2, Removed: 1}) {
+		t.Fatalf("统计不正确: %+v", stats)
+	}
+}
@@ -52,7 +52,7 @@ create_dirs() {
 # 编译项目
 build_project() {
     log_info "编译项目..."
-    if go build -o wechat-crawler cmd/main.go; then
+    if go build -o wechat-crawler ./cmd; then
         log_info "编译成功！"
     else
         log_error "编译失败！"